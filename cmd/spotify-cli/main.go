@@ -2,12 +2,16 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
+	"sync"
 
+	"github.com/jedruniu/spotify-cli/pkg/app"
 	"github.com/jedruniu/spotify-cli/pkg/player"
 	"github.com/jedruniu/spotify-cli/pkg/web"
 
@@ -23,12 +27,47 @@ type albumsList struct {
 	box   tui.Box
 }
 
-var debugMode bool
+var (
+	debugMode        bool
+	searchCategories []string
+	typeAhead        bool
+	profileName      string
+	clearHistory     bool
+	refresh          bool
+	recordPath       string
+	replayPath       string
+	exportDir        string
+)
+
+// reconnectInterval is how often app checks whether Spotify is reachable
+// again, while it works offline.
+const reconnectInterval = 10 * time.Second
+
+// debugStateInterval is how often simulated device in debug mode is checked
+// for changes of what it plays.
+const debugStateInterval = 500 * time.Millisecond
 
 func checkMode() {
 	debugModeFlag := flag.Bool("debug", false, "When set to true, app is populated with faked data and is not connecting with Spotify Web API.")
+	searchCategoriesFlag := flag.String("search-categories", strings.Join(player.DefaultSearchCategories, ","),
+		"Comma separated categories of search results, available are: "+strings.Join(player.SearchCategories, ", ")+".")
+	typeAheadFlag := flag.Bool("type-ahead", false, "When set to true, search starts while query is typed.")
+	profileFlag := flag.String("profile", player.DefaultProfile, "Name of the profile under which search history and other state is stored.")
+	clearHistoryFlag := flag.Bool("clear-search-history", false, "When set to true, search history of the profile is cleared, saved searches are kept.")
+	refreshFlag := flag.Bool("refresh", false, "When set to true, cached library of the profile is dropped and fetched again from Spotify.")
+	recordFlag := flag.String("record", "", "File into which requests to Spotify Web API and responses to them are recorded, with tokens and user IDs scrubbed.")
+	replayFlag := flag.String("replay", "", "File with recorded responses of Spotify Web API which are shown in debug mode instead of faked data.")
+	exportDirFlag := flag.String("export-dir", "", "Directory into which top tracks and artists are exported, by default it is spotify-cli/exports in the data directory of the user.")
 	flag.Parse()
 	debugMode = *debugModeFlag
+	searchCategories = strings.Split(*searchCategoriesFlag, ",")
+	typeAhead = *typeAheadFlag
+	profileName = *profileFlag
+	clearHistory = *clearHistoryFlag
+	refresh = *refreshFlag
+	recordPath = *recordFlag
+	replayPath = *replayFlag
+	exportDir = *exportDirFlag
 }
 
 func NewSpotifyAuthenticator() spotify.Authenticator {
@@ -51,6 +90,13 @@ func NewSpotifyAuthenticator() spotify.Authenticator {
 		spotify.ScopeUserReadPlaybackState,
 		spotify.ScopeUserModifyPlaybackState,
 		spotify.ScopeUserLibraryRead,
+		spotify.ScopeUserLibraryModify,
+		spotify.ScopeUserFollowRead,
+		spotify.ScopeUserFollowModify,
+		spotify.ScopeUserReadRecentlyPlayed,
+		spotify.ScopePlaylistReadPrivate,
+		// Used for resume points of podcast episodes
+		"user-read-playback-position",
 		// Used for Web Playback SDK
 		"streaming",
 		spotify.ScopeUserReadEmail,
@@ -59,6 +105,53 @@ func NewSpotifyAuthenticator() spotify.Authenticator {
 	return auth
 }
 
+// loadSearchHistory loads search history of the profile, and clears it
+// when user asked for it.
+func loadSearchHistory(profile *player.Profile) (*player.SearchHistory, error) {
+	path, err := profile.Path("search-history.json")
+	if err != nil {
+		return nil, err
+	}
+	history, err := player.LoadSearchHistory(path)
+	if err != nil {
+		return nil, err
+	}
+	if clearHistory {
+		if err := history.Clear(); err != nil {
+			return nil, err
+		}
+	}
+	return history, nil
+}
+
+// loadAlbumSettings loads how albums of the profile are arranged.
+func loadAlbumSettings(profile *player.Profile) (*player.AlbumSettings, error) {
+	path, err := profile.Path("albums.json")
+	if err != nil {
+		return nil, err
+	}
+	return player.LoadAlbumSettings(path)
+}
+
+// loadLibraryCache loads cached library of the profile, and clears it
+// when user asked for it.
+func loadLibraryCache(profile *player.Profile) (*player.LibraryCache, error) {
+	path, err := profile.CachePath("library.json")
+	if err != nil {
+		return nil, err
+	}
+	cache, err := player.LoadLibraryCache(path)
+	if err != nil {
+		return nil, err
+	}
+	if refresh {
+		if err := cache.Clear(); err != nil {
+			return nil, err
+		}
+	}
+	return cache, nil
+}
+
 func main() {
 	log.SetFlags(log.Llongfile)
 	f, _ := os.Create("log.txt")
@@ -68,13 +161,8 @@ func main() {
 	checkMode()
 
 	var client player.SpotifyClient
-	var spotifyAuthenticator = NewSpotifyAuthenticator()
-
-	authHandler := &web.AuthHandler{
-		Client:        make(chan *spotify.Client),
-		State:         uuid.New().String(),
-		Authenticator: spotifyAuthenticator,
-	}
+	var webPlayerID spotify.ID
+	connection := player.NewConnection(true)
 
 	webSocketHandler := &web.WebsocketHandler{
 		PlayerShutdown:    make(chan bool),
@@ -82,13 +170,42 @@ func main() {
 		PlayerStateChange: make(chan *web.WebPlaybackState),
 	}
 
+	// reconnect tells whether app can work online again, user is asked to
+	// log in when app was started offline.
+	reconnect := func() error { return nil }
+	// transport sends requests to Spotify Web API, it is shared by clients
+	// created every time user logs in.
+	var transport *player.RetryTransport
+
 	if debugMode {
-		client = player.NewDebugClient()
-		go func() {
-			webSocketHandler.PlayerDeviceID <- "debug"
-		}()
+		if replayPath != "" {
+			recording, err := player.LoadRecording(replayPath)
+			if err != nil {
+				log.Fatalf("could not replay recording, %s", err)
+			}
+			client = player.NewReplayClient(recording)
+		} else {
+			debugClient := player.NewDebugClient().(player.DebugClient)
+			// simulated device tells what it plays, like web player does
+			go debugClient.Device.Run(webSocketHandler.PlayerStateChange, debugStateInterval, nil)
+			client = debugClient
+		}
+		webPlayerID = player.DebugDeviceID
 	} else {
-		var err error
+		var spotifyAuthenticator = NewSpotifyAuthenticator()
+		transport = player.NewRetryTransport(nil)
+		// responses are recorded after retries, so that replay gets the
+		// ones which app got
+		var roundTripper http.RoundTripper = transport
+		if recordPath != "" {
+			roundTripper = player.NewRecordingTransport(transport, recordPath)
+		}
+
+		authHandler := &web.AuthHandler{
+			Client:        make(chan *spotify.Client),
+			State:         uuid.New().String(),
+			Authenticator: spotifyAuthenticator,
+		}
 
 		h := http.NewServeMux()
 		h.Handle("/ws", webSocketHandler)
@@ -99,65 +216,111 @@ func main() {
 			log.Fatal(http.ListenAndServe(":8888", h))
 		}()
 
-		err = player.StartRemoteAuthentication(spotifyAuthenticator, authHandler.State)
-		if err != nil {
-			log.Printf("could not get client, shutting down, err: %v", err)
+		authenticate := func() {
+			err := player.StartRemoteAuthentication(spotifyAuthenticator, authHandler.State)
+			if err != nil {
+				log.Printf("could not get client, shutting down, err: %v", err)
+			}
+		}
+		reachable := player.CheckSpotifyReachable()
+		if reachable != nil {
+			log.Printf("Spotify is not reachable, starting offline, err: %v", reachable)
+			connection = player.NewConnection(false)
+		}
+		connectedClient := player.NewConnectedClient(nil, connection)
+		client = connectedClient
+		reconnect = player.CheckSpotifyReachable
+
+		if reachable != nil {
+			var authenticating sync.Once
+			reconnect = func() error {
+				if err := player.CheckSpotifyReachable(); err != nil {
+					return err
+				}
+				if connectedClient.HasClient() {
+					return nil
+				}
+				authenticating.Do(func() {
+					authenticate()
+					go func() {
+						connectedClient.SetClient(player.NewClient(<-authHandler.Client, roundTripper))
+					}()
+				})
+				return fmt.Errorf("waiting for user to log in")
+			}
+		} else {
+			authenticate()
+			// wait for authentication to complete
+			connectedClient.SetClient(player.NewClient(<-authHandler.Client, roundTripper))
+			// wait for device to be ready
+			webPlayerID = <-webSocketHandler.PlayerDeviceID
 		}
 	}
 
-	// wait for authentication to complete
-	client = <-authHandler.Client
-
-	// wait for device to be ready
-	webPlayerID := <-webSocketHandler.PlayerDeviceID
-
-	sidebar, _ := player.NewSideBar(client)
-	search := player.NewSearch(client)
-	playback := player.NewPlayback(client, webSocketHandler.PlayerStateChange, webPlayerID)
-
-	mainFrame := tui.NewVBox(
-		search.Box,
-		tui.NewSpacer(),
-		playback.Box,
-	)
-	mainFrame.SetSizePolicy(tui.Expanding, tui.Expanding)
-
-	window := tui.NewHBox(
-		sidebar.Box,
-		mainFrame,
-	)
-	window.SetTitle("SPOTIFY CLI")
-
-	playBackButtons := []tui.Widget{playback.Playback.Previous, playback.Playback.Play, playback.Playback.Stop, playback.Playback.Next}
-	focusables := append(playBackButtons, sidebar.AlbumList.Table)
-	focusables = append(focusables, search.Focusables...)
-	focusables = append(focusables, playback.Devices.Table)
-
-	tui.DefaultFocusChain.Set(focusables...)
-
-	theme := tui.DefaultTheme
-	theme.SetStyle("box.focused.border", tui.Style{Fg: tui.ColorYellow, Bg: tui.ColorDefault})
-	theme.SetStyle("table.focused.border", tui.Style{Fg: tui.ColorYellow, Bg: tui.ColorDefault})
-
-	ui, err := tui.New(window)
+	profile, err := player.NewProfile(profileName)
 	if err != nil {
-		panic(err)
+		log.Fatalf("could not use profile, %s", err)
 	}
-
-	ui.SetKeybinding("Esc", func() {
-		ui.Quit()
-		webSocketHandler.PlayerShutdown <- true
-		return
-	})
-
-	go func() {
-		for range time.Tick(500 * time.Millisecond) {
-			ui.Update(func() {})
+	library := player.NewLibrary(client)
+	if !debugMode { // faked library is not cached
+		libraryCache, err := loadLibraryCache(profile)
+		if err != nil {
+			log.Printf("could not load library cache, err: %v", err)
+		} else {
+			library.SetCache(libraryCache)
 		}
-	}()
+	}
+	events := app.Events{
+		PlayerStates: webSocketHandler.PlayerStateChange,
+		Connection:   connection,
+		Ticks:        time.Tick(500 * time.Millisecond),
+	}
+	if !connection.Online() {
+		// web player is ready after user logs in
+		events.WebPlayers = webSocketHandler.PlayerDeviceID
+	}
+	if exportDir == "" {
+		exportDir, err = player.DefaultExportDir()
+		if err != nil {
+			log.Fatalf("could not find export directory, %s", err)
+		}
+	}
+	spotifyCLI, err := app.New(client, library, events, webPlayerID, searchCategories, exportDir)
+	if err != nil {
+		log.Fatalf("could not create app, %s", err)
+	}
+	searchHistory, err := loadSearchHistory(profile)
+	if err != nil {
+		log.Printf("could not load search history, err: %v", err)
+	} else {
+		spotifyCLI.Search.SetHistory(searchHistory)
+	}
+	albumSettings, err := loadAlbumSettings(profile)
+	if err != nil {
+		log.Printf("could not load album settings, err: %v", err)
+	} else {
+		spotifyCLI.SideBar.SetSettings(albumSettings)
+	}
+	spotifyCLI.Search.SetTypeAhead(typeAhead)
+	spotifyCLI.OnQuit(func() {
+		if !debugMode { // in debug mode there is no web player to shut down
+			webSocketHandler.PlayerShutdown <- true
+		}
+	})
 
+	ui, err := tui.New(spotifyCLI.Widget)
+	if err != nil {
+		panic(err)
+	}
+	spotifyCLI.Start(ui)
+	if !debugMode {
+		go connection.Watch(reconnectInterval, reconnect, nil)
+	}
 	if err := ui.Run(); err != nil {
 		panic(err)
 	}
+	if transport != nil {
+		log.Printf("Spotify Web API requests: %+v", transport.Stats())
+	}
 
 }
@@ -0,0 +1,326 @@
+package app
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedruniu/spotify-cli/pkg/player"
+	"github.com/jedruniu/spotify-cli/pkg/web"
+
+	tui "github.com/marcusolsson/tui-go"
+	"github.com/zmb3/spotify"
+)
+
+// Component is a part of the app window, like sidebar or search, its
+// focusables are in the focus chain in the order in which components were
+// added. Components, and views displayed in Views, may also implement
+// hooks: Starter, ConnectionListener, Binder, RadioStarter and
+// ErrorReporter, which are called by App.
+type Component interface {
+	Focusables() []tui.Widget
+}
+
+// Starter is implemented by components which start working in background
+// when app runs. Changes are applied in UI goroutine with update.
+type Starter interface {
+	Start(update func(func()))
+}
+
+// ConnectionListener is implemented by components which change when
+// Spotify becomes reachable, or stops being reachable.
+type ConnectionListener interface {
+	ConnectionChanged(online bool, update func(func()))
+}
+
+// Binder is implemented by components which have global key bindings.
+type Binder interface {
+	SetKeybindings(ui tui.UI)
+}
+
+// RadioStarter is implemented by components from which user starts radio,
+// it is displayed in Views.
+type RadioStarter interface {
+	OnStartRadio(fn func(player.RadioSeed))
+}
+
+// ErrorReporter is implemented by components which display errors of
+// actions triggered by user in the status line.
+type ErrorReporter interface {
+	SetStatusLine(status *player.StatusLine)
+}
+
+// Events are sources of events which come from outside of the UI. Sources
+// which are nil are not used.
+type Events struct {
+	// PlayerStates are states of the web player, sent when it plays
+	// another track.
+	PlayerStates chan *web.WebPlaybackState
+	// WebPlayers are IDs of the web player, sent when it becomes ready
+	// after app started.
+	WebPlayers <-chan spotify.ID
+	// Connection tells whether Spotify is reachable, app is online when
+	// it is nil.
+	Connection *player.Connection
+	// Ticks make UI repainted, so that changes made in background, like
+	// the track which is played, are displayed.
+	Ticks <-chan time.Time
+}
+
+// App is the window of spotify-cli, saved searches and albums are on the
+// left, search, views, playback and status line on the right.
+type App struct {
+	Widget     tui.Widget
+	SideBar    *player.SideBar
+	Search     *player.Search
+	Playback   *player.CurrentlyPlaying
+	Views      *player.Views
+	StatusLine *player.StatusLine
+
+	client     player.SpotifyClient
+	library    *player.Library
+	events     Events
+	components []Component
+	views      []player.View
+	radio      *player.Radio
+	focusChain *player.FocusChain
+	keys       *keymap
+	onQuit     func()
+}
+
+// New creates app which talks with Spotify using client, tracks are played
+// on device with webPlayerID. Search results are shown for searchCategories,
+// lists exported by user are written to exportDir.
+func New(client player.SpotifyClient, library *player.Library, events Events, webPlayerID spotify.ID, searchCategories []string, exportDir string) (*App, error) {
+	sidebar, err := player.NewSideBar(client, library)
+	if err != nil {
+		return nil, err
+	}
+	search, err := player.NewSearch(client, library, searchCategories)
+	if err != nil {
+		return nil, err
+	}
+	playerStates := events.PlayerStates
+	if playerStates == nil {
+		playerStates = make(chan *web.WebPlaybackState)
+	}
+	states := player.SplitPlayerStates(playerStates, 2)
+	playback := player.NewPlayback(client, library, states[0], webPlayerID)
+
+	a := &App{
+		SideBar:  sidebar,
+		Search:   search,
+		Playback: playback,
+		client:   client,
+		library:  library,
+		events:   events,
+	}
+	a.Views = a.newViews(states[1], exportDir)
+	a.StatusLine = player.NewStatusLine()
+
+	mainFrame := tui.NewVBox(
+		search.Box,
+		a.Views.Box,
+		playback.Box,
+		a.StatusLine.Label,
+	)
+	mainFrame.SetSizePolicy(tui.Expanding, tui.Expanding)
+
+	window := tui.NewHBox(
+		tui.NewVBox(search.SavedSearches, sidebar.Box),
+		mainFrame,
+	)
+	window.SetTitle("SPOTIFY CLI")
+	a.Widget = window
+
+	a.Add(a.Playback, sidebar, search, a.Views)
+	a.focusChain = player.NewFocusChain(a.focusables)
+	return a, nil
+}
+
+// newViews creates views of the library and radio.
+func (a *App) newViews(playerStates chan *web.WebPlaybackState, exportDir string) *player.Views {
+	add := func(name string, view player.View, err error) {
+		if err != nil {
+			log.Printf("could not create %s view, err: %v", name, err)
+			return
+		}
+		a.views = append(a.views, view)
+	}
+	likedSongs, err := player.NewLikedSongs(a.client, a.library)
+	add("liked songs", likedSongs, err)
+	playlists, err := player.NewPlaylists(a.client, a.library)
+	add("playlists", playlists, err)
+	history, err := player.NewHistory(a.client, playerStates)
+	add("history", history, err)
+	top, err := player.NewTop(a.client, exportDir)
+	add("top", top, err)
+	podcasts, err := player.NewPodcasts(a.client)
+	add("podcasts", podcasts, err)
+	a.radio = player.NewRadio(a.client)
+	a.views = append(a.views, a.radio)
+	return player.NewViews(a.views...)
+}
+
+// Add adds components to the app, they have to be added before app runs.
+func (a *App) Add(components ...Component) {
+	a.components = append(a.components, components...)
+}
+
+// hooked returns components and views, whose hooks are called by App.
+func (a *App) hooked() []interface{} {
+	hooked := []interface{}{}
+	for _, component := range a.components {
+		hooked = append(hooked, component)
+	}
+	for _, view := range a.views {
+		hooked = append(hooked, view)
+	}
+	return hooked
+}
+
+// OnQuit sets function which is called after user quits app with Esc.
+func (a *App) OnQuit(fn func()) {
+	a.onQuit = fn
+}
+
+// focusables returns focusables of all components, which change when
+// search categories are toggled.
+func (a *App) focusables() []tui.Widget {
+	var focusables []tui.Widget
+	for _, component := range a.components {
+		focusables = append(focusables, component.Focusables()...)
+	}
+	return focusables
+}
+
+// Start sets theme, focus chain and keybindings of the app in ui, starts
+// components and listens to events, before ui runs.
+func (a *App) Start(ui tui.UI) {
+	theme := tui.DefaultTheme
+	theme.SetStyle("box.focused.border", tui.Style{Fg: tui.ColorYellow, Bg: tui.ColorDefault})
+	theme.SetStyle("table.focused.border", tui.Style{Fg: tui.ColorYellow, Bg: tui.ColorDefault})
+	theme.SetStyle("label.error", tui.Style{Fg: tui.ColorRed, Bg: tui.ColorDefault})
+	theme.SetStyle("label.match", tui.Style{Fg: tui.ColorYellow, Bg: tui.ColorDefault, Bold: tui.DecorationOn})
+
+	ui.SetFocusChain(a.focusChain)
+	a.bindKeys(ui)
+	a.StatusLine.Start(ui.Update)
+	a.library.SetStatusLine(a.StatusLine)
+	a.library.SetUpdater(ui.Update)
+	for _, hooked := range a.hooked() {
+		if reporter, ok := hooked.(ErrorReporter); ok {
+			reporter.SetStatusLine(a.StatusLine)
+		}
+		if starter, ok := hooked.(RadioStarter); ok {
+			starter.OnStartRadio(a.startRadio)
+		}
+		if starter, ok := hooked.(Starter); ok {
+			starter.Start(ui.Update)
+		}
+	}
+	a.library.SyncPending(ui.Update)
+
+	if connection := a.events.Connection; connection != nil {
+		connection.OnChange(func(online bool) {
+			ui.Update(func() { a.setOnline(online, ui.Update) })
+		})
+		if !connection.Online() {
+			a.setOnline(false, ui.Update)
+		}
+	}
+	if webPlayers := a.events.WebPlayers; webPlayers != nil {
+		go func() {
+			for id := range webPlayers {
+				a.Playback.SetWebPlayer(id, ui.Update)
+			}
+		}()
+	}
+	if ticks := a.events.Ticks; ticks != nil {
+		go func() {
+			for range ticks {
+				ui.Update(func() {})
+			}
+		}()
+	}
+}
+
+// startRadio starts radio from seed and displays it, failure is reported
+// in the status line.
+func (a *App) startRadio(seed player.RadioSeed) {
+	if a.radio.TryStart(seed) {
+		a.Views.ShowView(a.radio)
+	}
+}
+
+// setOnline tells components whether Spotify is reachable, changes made to
+// library while it was not are synchronized when it is again.
+func (a *App) setOnline(online bool, update func(func())) {
+	for _, hooked := range a.hooked() {
+		if listener, ok := hooked.(ConnectionListener); ok {
+			listener.ConnectionChanged(online, update)
+		}
+	}
+	if online {
+		a.library.SyncPending(update)
+	}
+}
+
+// bindKeys binds all global keys, those of components and Esc which quits
+// app.
+func (a *App) bindKeys(ui tui.UI) {
+	a.keys = newKeymap(ui)
+	for _, hooked := range a.hooked() {
+		if binder, ok := hooked.(Binder); ok {
+			binder.SetKeybindings(a.keys)
+		}
+	}
+	a.keys.SetKeybinding("Esc", func() {
+		ui.Quit()
+		if a.onQuit != nil {
+			a.onQuit()
+		}
+	})
+}
+
+// Keys returns names of global keys bound when app started, in lower case
+// and sorted.
+func (a *App) Keys() []string {
+	if a.keys == nil {
+		return nil
+	}
+	return a.keys.names()
+}
+
+// keymap is the UI in which components bind global keys, it reports keys
+// which are bound more than once, as only one binding of the key should
+// handle it.
+type keymap struct {
+	tui.UI
+	bound map[string]bool
+}
+
+func newKeymap(ui tui.UI) *keymap {
+	return &keymap{UI: ui, bound: map[string]bool{}}
+}
+
+// SetKeybinding binds key in UI, keys are compared without case, the same
+// way as UI does.
+func (k *keymap) SetKeybinding(seq string, fn func()) {
+	name := strings.ToLower(seq)
+	if k.bound[name] {
+		log.Printf("Key %s is bound more than once", seq)
+	}
+	k.bound[name] = true
+	k.UI.SetKeybinding(seq, fn)
+}
+
+func (k *keymap) names() []string {
+	names := make([]string, 0, len(k.bound))
+	for name := range k.bound {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
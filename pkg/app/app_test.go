@@ -0,0 +1,346 @@
+package app
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jedruniu/spotify-cli/pkg/player"
+
+	tui "github.com/marcusolsson/tui-go"
+	"github.com/zmb3/spotify"
+)
+
+var updateSnapshots = flag.Bool("update", false, "When set to true, golden files are updated with screens rendered by tests.")
+
+// size of the screen on which app is rendered in tests
+const screenWidth, screenHeight = 160, 50
+
+type headlessKeybinding struct {
+	sequence string
+	handler  func()
+}
+
+// headlessUI is tui.UI which paints on test surface instead of terminal,
+// key events are sent by tests. Events and updates are handled one at
+// a time, like in UI goroutine, and app is painted after every one of
+// them, as widgets are sized when they are painted.
+type headlessUI struct {
+	mu          sync.Mutex
+	surface     *tui.TestSurface
+	root        tui.Widget
+	theme       *tui.Theme
+	keybindings []headlessKeybinding
+	chain       tui.FocusChain
+	focused     tui.Widget
+}
+
+func (ui *headlessUI) SetWidget(w tui.Widget) { ui.root = w }
+
+func (ui *headlessUI) SetTheme(theme *tui.Theme) { ui.theme = theme }
+
+func (ui *headlessUI) SetKeybinding(seq string, fn func()) {
+	ui.keybindings = append(ui.keybindings, headlessKeybinding{sequence: seq, handler: fn})
+}
+
+func (ui *headlessUI) ClearKeybindings() { ui.keybindings = nil }
+
+// SetFocusChain focuses the first widget of the chain, which terminal UI
+// does when it starts.
+func (ui *headlessUI) SetFocusChain(chain tui.FocusChain) {
+	if ui.focused != nil {
+		ui.focused.SetFocused(false)
+	}
+	ui.chain = chain
+	ui.focused = chain.FocusDefault()
+	if ui.focused != nil {
+		ui.focused.SetFocused(true)
+	}
+}
+
+// Run is not called, as events are sent by tests.
+func (ui *headlessUI) Run() error { return nil }
+
+func (ui *headlessUI) Update(fn func()) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	fn()
+	ui.repaint()
+}
+
+func (ui *headlessUI) Quit() {}
+
+// press handles key event the same way as terminal UI does.
+func (ui *headlessUI) press(ev tui.KeyEvent) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	for _, binding := range ui.keybindings {
+		if strings.ToLower(binding.sequence) == strings.ToLower(ev.Name()) {
+			binding.handler()
+		}
+	}
+	if ui.focused != nil && (ev.Key == tui.KeyTab || ev.Key == tui.KeyBacktab) {
+		ui.focused.SetFocused(false)
+		if ev.Key == tui.KeyTab {
+			ui.focused = ui.chain.FocusNext(ui.focused)
+		} else {
+			ui.focused = ui.chain.FocusPrev(ui.focused)
+		}
+		ui.focused.SetFocused(true)
+	}
+	ui.root.OnKeyEvent(ev)
+	ui.repaint()
+}
+
+// repaint paints the app, it is called with mu locked.
+func (ui *headlessUI) repaint() {
+	ui.surface = tui.NewTestSurface(screenWidth, screenHeight)
+	tui.NewPainter(ui.surface, ui.theme).Repaint(ui.root)
+}
+
+// screen renders the app and returns characters on the screen, with
+// trailing empty cells of every line removed. Highlighted texts, i.e.
+// selected rows and focused buttons, are listed below, as highlight can
+// not be seen in characters.
+func (ui *headlessUI) screen() string {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.repaint()
+	lines := strings.Split(strings.Trim(ui.surface.String(), "\n"), "\n")
+	decorations := strings.Split(strings.Trim(ui.surface.Decorations(), "\n"), "\n")
+	var highlighted []string
+	for i, line := range lines {
+		text, reversed := []rune(line), []rune(decorations[i])
+		start := -1
+		for j := 0; j <= len(text); j++ {
+			// decorations of cell are hex digits of a mask, 1 is reverse
+			isReversed := j < len(text) && j < len(reversed) && strings.ContainsRune("13579bdf", reversed[j])
+			if isReversed && start < 0 {
+				start = j
+			}
+			if !isReversed && start >= 0 {
+				highlighted = append(highlighted, strings.TrimSpace(string(text[start:j])))
+				start = -1
+			}
+		}
+		lines[i] = strings.TrimRight(line, ".")
+	}
+	return strings.Join(lines, "\n") + "\nHighlighted: " + strings.Join(highlighted, " | ") + "\n"
+}
+
+// appHarness drives app created with debug client, and compares screens
+// rendered after every step with golden file.
+type appHarness struct {
+	t      *testing.T
+	ui     *headlessUI
+	app    *App
+	client player.SpotifyClient
+	// screens are screens rendered so far, with the names of steps.
+	screens bytes.Buffer
+}
+
+func newAppHarness(t *testing.T, events Events) *appHarness {
+	return newAppHarnessWithClient(t, player.NewDebugClient(), events)
+}
+
+// newAppHarnessWithClient creates harness with given client, i.e. with
+// debug client which is not connected.
+func newAppHarnessWithClient(t *testing.T, client player.SpotifyClient, events Events) *appHarness {
+	app, err := New(client, player.NewLibrary(client), events, player.DebugDeviceID, player.DefaultSearchCategories, os.TempDir())
+	if err != nil {
+		t.Fatalf("Expected app to be created, got %s", err)
+	}
+	ui := &headlessUI{root: app.Widget, theme: tui.DefaultTheme}
+	app.Start(ui)
+	// terminal UI paints app when it starts
+	ui.Update(func() {})
+	h := &appHarness{t: t, ui: ui, app: app, client: client}
+	h.waitFor("albums to be loaded", func() bool { return !app.SideBar.AlbumList.Loading() })
+	return h
+}
+
+// waitFor waits until condition, checked in UI goroutine, is met.
+func (h *appHarness) waitFor(description string, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		h.ui.mu.Lock()
+		met := condition()
+		h.ui.mu.Unlock()
+		if met {
+			return
+		}
+		if time.Now().After(deadline) {
+			h.t.Fatalf("Expected %s, screen is:\n%s", description, h.ui.screen())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// waitForText waits until text is displayed.
+func (h *appHarness) waitForText(text string) {
+	h.waitFor(text+" to be displayed", func() bool {
+		h.ui.mu.Unlock()
+		defer h.ui.mu.Lock()
+		return strings.Contains(h.ui.screen(), text)
+	})
+}
+
+// press sends keys with given names, i.e. "Down" or "Tab".
+func (h *appHarness) press(keys ...string) {
+	names := map[string]tui.Key{}
+	for _, key := range []tui.Key{tui.KeyUp, tui.KeyDown, tui.KeyPgUp, tui.KeyPgDn, tui.KeyHome, tui.KeyEnd, tui.KeyEnter, tui.KeyTab, tui.KeyBacktab, tui.KeyEsc, tui.KeyF1, tui.KeyF2} {
+		ev := tui.KeyEvent{Key: key}
+		names[ev.Name()] = key
+	}
+	for _, name := range keys {
+		key, ok := names[name]
+		if !ok {
+			h.t.Fatalf("Unknown key %s", name)
+		}
+		h.ui.press(tui.KeyEvent{Key: key})
+	}
+}
+
+// typeText sends keys of the text.
+func (h *appHarness) typeText(text string) {
+	for _, r := range text {
+		h.ui.press(tui.KeyEvent{Key: tui.KeyRune, Rune: r})
+	}
+}
+
+// focus moves focus with Tab until widget is focused.
+func (h *appHarness) focus(w tui.Widget) {
+	for i := 0; i < 50 && !w.IsFocused(); i++ {
+		h.press("Tab")
+	}
+	if !w.IsFocused() {
+		h.t.Fatalf("Expected widget to be focused with Tab")
+	}
+}
+
+// snapshot renders the screen after step.
+func (h *appHarness) snapshot(step string) {
+	h.screens.WriteString("== " + step + " ==\n")
+	h.screens.WriteString(h.ui.screen())
+}
+
+// compare compares screens with testdata/<name>.golden, which is updated
+// instead when tests are run with -update flag.
+func (h *appHarness) compare(name string) {
+	path := filepath.Join("testdata", name+".golden")
+	if *updateSnapshots {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			h.t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, h.screens.Bytes(), 0644); err != nil {
+			h.t.Fatal(err)
+		}
+		return
+	}
+	golden, err := ioutil.ReadFile(path)
+	if err != nil {
+		h.t.Fatalf("Could not read golden file, run tests with -update flag to create it: %s", err)
+	}
+	if !bytes.Equal(golden, h.screens.Bytes()) {
+		h.t.Errorf("Expected screens to be the same as in %s, run tests with -update flag if change is expected, got:\n%s", path, h.screens.String())
+	}
+}
+
+func TestAppPagesAlbumList(t *testing.T) {
+	h := newAppHarness(t, Events{})
+	h.snapshot("started")
+
+	h.focus(h.app.SideBar.AlbumList.Table)
+	h.press("PgDn")
+	h.snapshot("PgDn")
+	h.press("PgDn", "Down", "Down")
+	h.snapshot("PgDn Down Down")
+	h.press("End")
+	h.snapshot("End")
+	h.press("PgUp", "Home")
+	h.snapshot("PgUp Home")
+	h.compare("albums")
+}
+
+func TestAppSearches(t *testing.T) {
+	h := newAppHarness(t, Events{})
+	// saved searches are followed by search input and tables of results
+	focusables := h.app.Search.Focusables()
+	h.focus(focusables[1])
+	h.typeText("jazz")
+	h.snapshot("typed jazz")
+	h.press("Enter")
+	h.waitForText("jazz Song 1")
+	h.snapshot("Enter")
+
+	tracks := h.app.Search.Focusables()[2]
+	h.focus(tracks)
+	h.press("Down")
+	h.snapshot("selected the second track")
+	h.press("Enter")
+	current, err := h.client.PlayerCurrentlyPlaying()
+	if err != nil || current.Item == nil || current.Item.URI != "spotify:track:searchtrack2" || !current.Playing {
+		t.Fatalf("Expected activated track to be played, got %+v and %v", current, err)
+	}
+	h.focus(h.app.Playback.Playback.Play)
+	h.press("Enter")
+	h.snapshot("activated the second track and pressed Play")
+	h.compare("search")
+}
+
+func TestAppBindsGlobalKeys(t *testing.T) {
+	h := newAppHarness(t, Events{})
+	expected := []string{"alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6", "ctrl+p", "ctrl+space", "esc", "f1", "f2", "f3", "f4", "f5", "f6"}
+	if keys := h.app.Keys(); strings.Join(keys, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected global keys %v, got %v", expected, keys)
+	}
+
+	h.press("F2")
+	h.waitForText("[F2 Playlists]")
+	quit := false
+	h.app.OnQuit(func() { quit = true })
+	h.press("Esc")
+	if !quit {
+		t.Errorf("Expected app to quit with Esc")
+	}
+}
+
+func TestAppGoesOnline(t *testing.T) {
+	connection := player.NewConnection(false)
+	webPlayers := make(chan spotify.ID)
+	h := newAppHarness(t, Events{Connection: connection, WebPlayers: webPlayers})
+	h.waitForText("Currently playing (offline, playback unavailable)")
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go connection.Watch(time.Millisecond, func() error { return nil }, stop)
+	h.waitForText("┌Currently playing─")
+
+	webPlayers <- "ipad"
+	h.waitFor("playback to be transferred to web player", func() bool {
+		devices, _ := h.client.PlayerDevices()
+		return devices[1].Active
+	})
+}
+
+func TestAppStartsOfflineAndLoadsViewsWhenConnected(t *testing.T) {
+	connection := player.NewConnection(false)
+	client := player.NewConnectedClient(player.NewDebugClient(), connection)
+	h := newAppHarnessWithClient(t, client, Events{Connection: connection})
+	h.waitForText("F3 History  F4 Top  F5 Podcasts  F6 Radio")
+
+	h.ui.Update(func() { h.app.Views.Show(4) })
+	h.waitForText("Offline, podcasts are loaded when connection returns")
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go connection.Watch(time.Millisecond, func() error { return nil }, stop)
+	h.waitForText("Show Name 1")
+	h.waitForText("┌Currently playing─")
+}
@@ -0,0 +1,170 @@
+package player
+
+import (
+	"fmt"
+	"strings"
+
+	tui "github.com/marcusolsson/tui-go"
+	runewidth "github.com/mattn/go-runewidth"
+)
+
+// albumFilter narrows albums displayed in the album list to ones which
+// fuzzy match the pattern. Pattern is typed after pressing "/", Enter stops
+// typing and keeps albums filtered, Backspace on empty pattern removes filter.
+type albumFilter struct {
+	typing  bool
+	pattern string
+	// matching are albums which match the pattern, in order of album list.
+	matching []albumDescription
+}
+
+// albumMatch tells which runes of title and artist of the album matched
+// the pattern, it returns false when album does not match.
+func albumMatch(pattern string, album albumDescription) (title, artist map[int]bool, ok bool) {
+	indexes, ok := fuzzyMatchIndexes(pattern, album.title+" "+album.artist)
+	if !ok {
+		return nil, nil, false
+	}
+	title, artist = map[int]bool{}, map[int]bool{}
+	titleLength := len([]rune(album.title))
+	for _, i := range indexes {
+		if i < titleLength {
+			title[i] = true
+		} else if i > titleLength {
+			artist[i-titleLength-1] = true
+		}
+	}
+	return title, artist, true
+}
+
+// shownAlbums returns albums displayed in the list, which are all albums
+// unless they are filtered.
+func (albumList *AlbumList) shownAlbums() []albumDescription {
+	if albumList.filter.pattern != "" {
+		return albumList.filter.matching
+	}
+	return albumList.albumsDescriptions
+}
+
+// onFilterKey starts typing the filter with "/", and handles keys typed
+// into the filter until typing is finished.
+func (albumList *AlbumList) onFilterKey(ev tui.KeyEvent) bool {
+	if !albumList.filter.typing {
+		if ev.Key == tui.KeyRune && ev.Rune == '/' {
+			albumList.filter.typing = true
+			albumList.box.SetTitle(albumList.title())
+			return true
+		}
+		return false
+	}
+	pattern := []rune(albumList.filter.pattern)
+	switch ev.Key {
+	case tui.KeyRune:
+		pattern = append(pattern, ev.Rune)
+	case tui.KeyBackspace, tui.KeyBackspace2:
+		if len(pattern) == 0 {
+			albumList.filter.typing = false
+			break
+		}
+		pattern = pattern[:len(pattern)-1]
+	case tui.KeyCtrlU:
+		pattern = nil
+	case tui.KeyEnter:
+		albumList.filter.typing = false
+		albumList.box.SetTitle(albumList.title())
+		return true
+	default:
+		return false // i.e. albums are still selected with arrows
+	}
+	albumList.setFilter(string(pattern))
+	return true
+}
+
+// setFilter displays only albums matching the pattern, from the first one.
+func (albumList *AlbumList) setFilter(pattern string) {
+	albumList.filter.pattern = pattern
+	albumList.filter.matching = matchingAlbums(pattern, albumList.albumsDescriptions)
+	albumList.renderFromStart()
+}
+
+// renderFromStart displays shown albums from the first one, after they
+// were filtered or arranged differently.
+func (albumList *AlbumList) renderFromStart() {
+	albumList.list.reset()
+	albumList.box.SetTitle(albumList.title())
+}
+
+// refilter matches albums again after album list changed.
+func (albumList *AlbumList) refilter() {
+	if albumList.filter.pattern == "" {
+		return
+	}
+	albumList.filter.matching = matchingAlbums(albumList.filter.pattern, albumList.albumsDescriptions)
+	albumList.box.SetTitle(albumList.title())
+}
+
+func matchingAlbums(pattern string, albums []albumDescription) []albumDescription {
+	matching := []albumDescription{}
+	for _, album := range albums {
+		if _, _, ok := albumMatch(pattern, album); ok {
+			matching = append(matching, album)
+		}
+	}
+	return matching
+}
+
+// title returns title of the album list, telling how albums are arranged
+// and filtered, and how many of them are loaded.
+func (albumList *AlbumList) title() string {
+	details := []string{}
+	if description := albumList.settings.description(); description != "" {
+		details = append(details, description)
+	}
+	if albumList.loading.inProgress() {
+		details = append(details, fmt.Sprintf("loading %d of %d", albumList.loading.loaded, albumList.loading.total))
+	}
+	if albumList.loading.syncing {
+		details = append(details, "syncing")
+	}
+	if albumList.loading.offline {
+		details = append(details, "offline")
+	}
+	title := albumListTitle
+	if len(details) > 0 {
+		title = fmt.Sprintf("%s (%s)", albumListTitle, strings.Join(details, ", "))
+	}
+	switch {
+	case albumList.filter.typing:
+		return fmt.Sprintf("/%s_ (%d of %d)", albumList.filter.pattern, len(albumList.shownAlbums()), len(albumList.albumsDescriptions))
+	case albumList.filter.pattern != "":
+		return fmt.Sprintf("%s /%s (%d of %d)", title, albumList.filter.pattern, len(albumList.shownAlbums()), len(albumList.albumsDescriptions))
+	}
+	return title
+}
+
+// highlightLabel is a label which displays some of its runes with
+// "label.match" style, i.e. ones which matched the filter.
+type highlightLabel struct {
+	*tui.Label
+	highlighted map[int]bool
+}
+
+func newHighlightLabel(text string, highlighted map[int]bool) *highlightLabel {
+	return &highlightLabel{Label: tui.NewLabel(text), highlighted: highlighted}
+}
+
+// Draw draws text of the label rune by rune, so that highlighted
+// runes have their own style.
+func (l *highlightLabel) Draw(p *tui.Painter) {
+	x := 0
+	for i, r := range []rune(l.Text()) {
+		style := "label"
+		if l.highlighted[i] {
+			style = "label.match"
+		}
+		p.WithStyle(style, func(p *tui.Painter) {
+			p.DrawRune(x, 0, r)
+		})
+		x += runewidth.RuneWidth(r)
+	}
+}
@@ -0,0 +1,141 @@
+package player
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/marcusolsson/tui-go"
+)
+
+func typeIntoAlbumList(albumList *AlbumList, keys ...tui.KeyEvent) {
+	for _, ev := range keys {
+		albumList.list.OnKeyEvent(ev)
+	}
+}
+
+func runes(text string) []tui.KeyEvent {
+	keys := []tui.KeyEvent{}
+	for _, r := range text {
+		keys = append(keys, tui.KeyEvent{Key: tui.KeyRune, Rune: r})
+	}
+	return keys
+}
+
+func TestFilterAlbumList(t *testing.T) {
+	client := NewDebugClient()
+	sideBar, err := NewSideBar(client, NewLibrary(client))
+	if err != nil {
+		t.Fatalf("Unexpected error occured: %s", err)
+	}
+	albumList := sideBar.AlbumList
+	albumList.Table.SetFocused(true)
+
+	cases := []struct {
+		keys           []tui.KeyEvent
+		expectedTitle  string
+		expectedAlbums int
+	}{
+		{keys: runes("/"), expectedTitle: "/_ (135 of 135)", expectedAlbums: 135},
+		{keys: runes("125"), expectedTitle: "/125_ (2 of 135)", expectedAlbums: 2},
+		{keys: runes("x"), expectedTitle: "/125x_ (0 of 135)", expectedAlbums: 0},
+		{keys: []tui.KeyEvent{{Key: tui.KeyBackspace2}}, expectedTitle: "/125_ (2 of 135)", expectedAlbums: 2},
+		{keys: []tui.KeyEvent{{Key: tui.KeyEnter}}, expectedTitle: "User albums /125 (2 of 135)", expectedAlbums: 2},
+		{keys: append(runes("/"), tui.KeyEvent{Key: tui.KeyCtrlU}), expectedTitle: "/_ (135 of 135)", expectedAlbums: 135},
+		{keys: []tui.KeyEvent{{Key: tui.KeyBackspace2}}, expectedTitle: "User albums", expectedAlbums: 135},
+	}
+	for _, c := range cases {
+		typeIntoAlbumList(albumList, c.keys...)
+		if albumList.title() != c.expectedTitle {
+			t.Errorf("Expected title %q, got %q", c.expectedTitle, albumList.title())
+		}
+		if len(albumList.shownAlbums()) != c.expectedAlbums {
+			t.Errorf("Expected %d albums to be shown, got %d", c.expectedAlbums, len(albumList.shownAlbums()))
+		}
+	}
+}
+
+func TestFilteredAlbumIsActivated(t *testing.T) {
+	client := NewDebugClient().(DebugClient)
+	fakePlayer := &FakePlayer{}
+	client.Player = fakePlayer
+	sideBar, err := NewSideBar(client, NewLibrary(client))
+	if err != nil {
+		t.Fatalf("Unexpected error occured: %s", err)
+	}
+	albumList := sideBar.AlbumList
+	albumList.Table.SetFocused(true)
+
+	typeIntoAlbumList(albumList, runes("/name 12")...)
+	typeIntoAlbumList(albumList, tui.KeyEvent{Key: tui.KeyEnter})
+	idx := albumList.selectedAlbumIdx()
+	if idx != 0 || albumList.shownAlbums()[idx].uri != "spotify:album:savedalbum12" {
+		t.Fatalf("Expected first matching album to be selected, got %d", idx)
+	}
+	typeIntoAlbumList(albumList, tui.KeyEvent{Key: tui.KeyEnter})
+	if fakePlayer.playOptCalls != 1 || *fakePlayer.lastPlayOpt.PlaybackContext != "spotify:album:savedalbum12" {
+		t.Errorf("Expected filtered album to be played, got %d calls", fakePlayer.playOptCalls)
+	}
+}
+
+func TestAlbumMatch(t *testing.T) {
+	cases := []struct {
+		pattern        string
+		album          albumDescription
+		expectedTitle  map[int]bool
+		expectedArtist map[int]bool
+		expectedMatch  bool
+	}{
+		{
+			pattern:        "kob",
+			album:          albumDescription{title: "Kind of Blue", artist: "Miles Davis"},
+			expectedTitle:  map[int]bool{0: true, 5: true, 8: true},
+			expectedArtist: map[int]bool{},
+			expectedMatch:  true,
+		},
+		{
+			pattern:        "blue md",
+			album:          albumDescription{title: "Kind of Blue", artist: "Miles Davis"},
+			expectedTitle:  map[int]bool{8: true, 9: true, 10: true, 11: true},
+			expectedArtist: map[int]bool{0: true, 6: true},
+			expectedMatch:  true,
+		},
+		{
+			pattern:        "ショパン",
+			album:          albumDescription{title: "ショパン", artist: "Chopin"},
+			expectedTitle:  map[int]bool{0: true, 1: true, 2: true, 3: true},
+			expectedArtist: map[int]bool{},
+			expectedMatch:  true,
+		},
+		{
+			pattern:       "zq",
+			album:         albumDescription{title: "Kind of Blue", artist: "Miles Davis"},
+			expectedMatch: false,
+		},
+	}
+	for _, c := range cases {
+		title, artist, ok := albumMatch(c.pattern, c.album)
+		if ok != c.expectedMatch {
+			t.Errorf("Expected match of %q to be %t, got %t", c.pattern, c.expectedMatch, ok)
+			continue
+		}
+		if ok && (!reflect.DeepEqual(title, c.expectedTitle) || !reflect.DeepEqual(artist, c.expectedArtist)) {
+			t.Errorf("Expected %q to match %v and %v, got %v and %v", c.pattern, c.expectedTitle, c.expectedArtist, title, artist)
+		}
+	}
+}
+
+func TestHighlightLabel(t *testing.T) {
+	theme := tui.NewTheme()
+	theme.SetStyle("label.match", tui.Style{Bold: tui.DecorationOn})
+	surface := tui.NewTestSurface(8, 1)
+	painter := tui.NewPainter(surface, theme)
+
+	label := newHighlightLabel("ショパン", map[int]bool{1: true})
+	painter.Repaint(label)
+	if surface.String() != "\nショパン\n" {
+		t.Errorf("Expected text to be drawn, got %q", surface.String())
+	}
+	if decorations := surface.Decorations(); decorations != "\n0.2.0.0.\n" {
+		t.Errorf("Expected only the second rune to be bold, got %q", decorations)
+	}
+}
@@ -0,0 +1,275 @@
+package player
+
+import (
+	"log"
+	"sync"
+
+	"github.com/zmb3/spotify"
+)
+
+// albumPagesFetchedAtOnce is the maximum number of pages of user albums
+// which are fetched at the same time.
+var albumPagesFetchedAtOnce = 4
+
+// albumsLoading is a progress of loading user albums, which are displayed
+// as soon as their page is fetched.
+type albumsLoading struct {
+	loaded int
+	total  int
+	// pending is the number of pages which are not fetched yet.
+	pending int
+	// failed tells that some page could not be fetched.
+	failed bool
+	// syncing tells that cached albums are displayed, and they are being
+	// synchronized with Spotify.
+	syncing bool
+	// offline tells that albums could not be loaded, because Spotify was
+	// not reachable.
+	offline bool
+}
+
+func (l albumsLoading) inProgress() bool {
+	return l.pending > 0
+}
+
+// albumPage is a page of user albums starting at offset, or the reason
+// why it could not be fetched.
+type albumPage struct {
+	offset int
+	albums []albumDescription
+	err    error
+}
+
+// remainingPageOffsets returns offsets of pages of albums after the first one.
+func remainingPageOffsets(total int) []int {
+	offsets := []int{}
+	for offset := spotifyAPIPageSize; offset < total; offset += spotifyAPIPageSize {
+		offsets = append(offsets, offset)
+	}
+	return offsets
+}
+
+// fetchAlbumPages fetches pages of albums starting at given offsets. Pages
+// are sent as soon as they are fetched, so they may come in any order,
+// pages is closed after the last one.
+func fetchAlbumPages(fetcher dataFetcher, offsets []int, pages chan<- albumPage) {
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < albumPagesFetchedAtOnce; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for offset := range queue {
+				albums, _, err := fetcher.fetchAlbumsPage(offset)
+				pages <- albumPage{offset: offset, albums: albums, err: err}
+			}
+		}()
+	}
+	for _, offset := range offsets {
+		queue <- offset
+	}
+	close(queue)
+	wg.Wait()
+	close(pages)
+}
+
+// LoadAlbums loads in background user albums which were not displayed
+// when sidebar was created, or synchronizes cached albums with Spotify.
+// Albums are displayed with update, which applies them in UI goroutine,
+// i.e. ui.Update.
+func (sideBar *SideBar) LoadAlbums(update func(func())) {
+	albumList := sideBar.AlbumList
+	switch {
+	case albumList.loading.offline:
+		return // albums are loaded with Reconnect
+	case albumList.loading.syncing:
+		cached, _ := albumList.cache().albums()
+		go albumList.syncAlbums(cached, update)
+	default:
+		go albumList.loadAlbumPages(remainingPageOffsets(albumList.loading.total), update)
+	}
+}
+
+// Reconnect loads albums which could not be loaded while Spotify was not
+// reachable, they are synchronized with cached albums, if there are any.
+func (sideBar *SideBar) Reconnect(update func(func())) {
+	albumList := sideBar.AlbumList
+	if !albumList.loading.offline {
+		return
+	}
+	albumList.loading = albumsLoading{syncing: true}
+	albumList.box.SetTitle(albumList.title())
+	cached, _ := albumList.cache().albums()
+	go albumList.syncAlbums(cached, update)
+}
+
+// Start loads albums in background when app runs, see LoadAlbums.
+func (sideBar *SideBar) Start(update func(func())) {
+	sideBar.LoadAlbums(update)
+}
+
+// ConnectionChanged loads albums when Spotify is reachable again, see
+// Reconnect.
+func (sideBar *SideBar) ConnectionChanged(online bool, update func(func())) {
+	if online {
+		sideBar.Reconnect(update)
+	}
+}
+
+// Loading tells whether albums are still being loaded.
+func (albumList *AlbumList) Loading() bool {
+	return albumList.loading.inProgress()
+}
+
+// loadAlbumPages fetches pages of albums starting at given offsets and
+// displays them with update, it returns after all of them are fetched.
+func (albumList *AlbumList) loadAlbumPages(offsets []int, update func(func())) {
+	pages := make(chan albumPage)
+	go fetchAlbumPages(albumList.dataFetcher, offsets, pages)
+	for page := range pages {
+		page := page
+		update(func() { albumList.addAlbumPage(page) })
+	}
+}
+
+// addAlbumPage displays fetched albums among already displayed ones,
+// selected album stays selected. Albums are cached after the last page.
+func (albumList *AlbumList) addAlbumPage(page albumPage) {
+	albumList.loading.pending--
+	if page.err != nil {
+		albumList.onLoadingFailed("Could not load albums: %s", page.err)
+		return
+	}
+	albumList.loading.loaded += len(page.albums)
+	albumList.replaceAlbums(append(albumList.albumsDescriptions, page.albums...))
+	albumList.saveCache()
+}
+
+// syncAlbums fetches the first page of albums, and when the only change
+// since albums were cached is that some were added, only they are added to
+// cached ones. Otherwise all albums are fetched again, cached ones are
+// displayed until then. Cached albums are ordered from the most recently
+// added, they are displayed with update.
+func (albumList *AlbumList) syncAlbums(cached []albumDescription, update func(func())) {
+	first, total, err := albumList.dataFetcher.fetchAlbumsPage(0)
+	if err != nil {
+		update(func() { albumList.finishSync(nil, err) })
+		return
+	}
+	if added, ok := addedSince(albumURIs(cached), albumURIs(first), len(cached), total); ok {
+		update(func() { albumList.finishSync(withAdded(albumList.albumsDescriptions, first[:added]), nil) })
+		return
+	}
+
+	pages := make(chan albumPage)
+	go fetchAlbumPages(albumList.dataFetcher, remainingPageOffsets(total), pages)
+	fetched := map[int][]albumDescription{0: first}
+	for page := range pages {
+		if page.err != nil {
+			err = page.err
+		}
+		fetched[page.offset] = page.albums
+	}
+	if err != nil {
+		update(func() { albumList.finishSync(nil, err) })
+		return
+	}
+	albums := []albumDescription{}
+	for offset := 0; offset < total; offset += spotifyAPIPageSize {
+		albums = append(albums, fetched[offset]...)
+	}
+	update(func() { albumList.finishSync(albums, nil) })
+}
+
+// finishSync displays synchronized albums and caches them, cached albums
+// stay displayed when synchronizing failed.
+func (albumList *AlbumList) finishSync(albums []albumDescription, err error) {
+	albumList.loading.syncing = false
+	if err != nil {
+		albumList.onLoadingFailed("Could not synchronize albums: %s", err)
+		return
+	}
+	albumList.loading = albumsLoading{loaded: len(albums), total: len(albums)}
+	albumList.replaceAlbums(albums)
+	albumList.saveCache()
+}
+
+// onLoadingFailed reports why albums could not be loaded. When Spotify is
+// not reachable, albums are loaded again with Reconnect.
+func (albumList *AlbumList) onLoadingFailed(format string, err error) {
+	if isConnectionError(err) {
+		log.Printf(format, err)
+		albumList.loading.offline = true
+	} else {
+		albumList.loading.failed = true
+		albumList.reportError(format, err)
+	}
+	albumList.box.SetTitle(albumList.title())
+}
+
+// replaceAlbums displays given albums instead of displayed ones, selected
+// album stays selected when it is among them.
+func (albumList *AlbumList) replaceAlbums(albums []albumDescription) {
+	selected := albumList.selectedAlbumIdx()
+	var selectedURI spotify.URI
+	if selected >= 0 {
+		selectedURI = albumList.shownAlbums()[selected].uri
+	}
+	for _, album := range albums {
+		albumList.library.albums.markSaved(idFromURI(album.uri))
+	}
+	albumList.albumsDescriptions = albums
+	albumList.arrange()
+	albumList.refilter()
+	for i, album := range albumList.shownAlbums() {
+		if album.uri == selectedURI {
+			selected = i
+			break
+		}
+	}
+	albumList.list.selectRow(selected)
+	albumList.box.SetTitle(albumList.title())
+}
+
+// saveCache caches displayed albums, unless some of them are not loaded.
+func (albumList *AlbumList) saveCache() {
+	loading := albumList.loading
+	if loading.inProgress() || loading.failed || loading.syncing || loading.offline {
+		return
+	}
+	if err := albumList.cache().setAlbums(albumList.albumsDescriptions); err != nil {
+		log.Printf("Could not cache albums: %s", err)
+	}
+}
+
+// cache returns cache of the library, or nil when library is not cached.
+func (albumList *AlbumList) cache() *LibraryCache {
+	if albumList.library == nil {
+		return nil
+	}
+	return albumList.library.cache
+}
+
+func albumURIs(albums []albumDescription) []spotify.URI {
+	uris := make([]spotify.URI, 0, len(albums))
+	for _, album := range albums {
+		uris = append(uris, album.uri)
+	}
+	return uris
+}
+
+// withAdded returns albums together with added ones, added albums which
+// already are among albums, i.e. saved in the app meanwhile, are skipped.
+func withAdded(albums, added []albumDescription) []albumDescription {
+	present := map[spotify.URI]bool{}
+	for _, album := range albums {
+		present[album.uri] = true
+	}
+	all := []albumDescription{}
+	for _, album := range added {
+		if !present[album.uri] {
+			all = append(all, album)
+		}
+	}
+	return append(all, albums...)
+}
@@ -0,0 +1,216 @@
+package player
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zmb3/spotify"
+)
+
+// FakePagedAlbumFetcher has given number of saved albums, and returns
+// them page by page. It can be called from many goroutines.
+type FakePagedAlbumFetcher struct {
+	total      int
+	failOffset int
+
+	mu          sync.Mutex
+	offsets     []int
+	inFlight    int
+	maxInFlight int
+}
+
+func (fake *FakePagedAlbumFetcher) CurrentUsersAlbumsOpt(opt *spotify.Options) (*spotify.SavedAlbumPage, error) {
+	fake.mu.Lock()
+	fake.offsets = append(fake.offsets, *opt.Offset)
+	fake.inFlight++
+	if fake.inFlight > fake.maxInFlight {
+		fake.maxInFlight = fake.inFlight
+	}
+	fake.mu.Unlock()
+	defer func() {
+		fake.mu.Lock()
+		fake.inFlight--
+		fake.mu.Unlock()
+	}()
+	time.Sleep(5 * time.Millisecond) // so that pages are fetched at the same time
+
+	if *opt.Offset == fake.failOffset {
+		return nil, fmt.Errorf("error")
+	}
+	end := *opt.Offset + *opt.Limit
+	if end > fake.total {
+		end = fake.total
+	}
+	page := &spotify.SavedAlbumPage{Albums: constructNSpotifySavedAlbums(end)[*opt.Offset:]}
+	page.Limit = *opt.Limit
+	page.Total = fake.total
+	return page, nil
+}
+
+func newPagedSideBar(t *testing.T, fetcher *FakePagedAlbumFetcher) *SideBar {
+	client := &DebugClient{UserAlbumFetcher: fetcher}
+	sideBar, err := NewSideBar(client, NewLibrary(client))
+	if err != nil {
+		t.Fatalf("Unexpected error occured: %s", err)
+	}
+	return sideBar
+}
+
+func TestAlbumsAreLoadedConcurrently(t *testing.T) {
+	fetcher := &FakePagedAlbumFetcher{total: 260, failOffset: -1}
+	sideBar := newPagedSideBar(t, fetcher)
+	albumList := sideBar.AlbumList
+
+	if len(albumList.albumsDescriptions) != 25 || albumList.title() != "User albums (loading 25 of 260)" {
+		t.Fatalf("Expected only the first page to be displayed, got %d albums and title %q", len(albumList.albumsDescriptions), albumList.title())
+	}
+
+	albumList.loadAlbumPages(remainingPageOffsets(260), func(fn func()) { fn() })
+	if len(albumList.albumsDescriptions) != 260 || albumList.title() != "User albums" {
+		t.Fatalf("Expected all albums to be displayed, got %d albums and title %q", len(albumList.albumsDescriptions), albumList.title())
+	}
+	if first, last := albumList.albumsDescriptions[0].uri, albumList.albumsDescriptions[259].uri; first != "spotify:album:savedalbum1" || last != "spotify:album:savedalbum260" {
+		t.Errorf("Expected albums to be in order in which they were added, got %s first and %s last", first, last)
+	}
+	if !sideBar.AlbumList.library.albums.isSaved("savedalbum260") {
+		t.Errorf("Expected loaded albums to be marked as saved")
+	}
+
+	sort.Ints(fetcher.offsets)
+	expectedOffsets := []int{0, 25, 50, 75, 100, 125, 150, 175, 200, 225, 250}
+	if fmt.Sprint(fetcher.offsets) != fmt.Sprint(expectedOffsets) {
+		t.Errorf("Expected every page to be fetched once, fetched %v", fetcher.offsets)
+	}
+	if fetcher.maxInFlight > albumPagesFetchedAtOnce {
+		t.Errorf("Expected at most %d pages to be fetched at once, fetched %d", albumPagesFetchedAtOnce, fetcher.maxInFlight)
+	}
+}
+
+func TestLoadedAlbumsAreDisplayedInUIGoroutine(t *testing.T) {
+	fetcher := &FakePagedAlbumFetcher{total: 60, failOffset: -1}
+	sideBar := newPagedSideBar(t, fetcher)
+	albumList := sideBar.AlbumList
+	albumList.settings.Sort = sortByTitle
+	albumList.rearrange()
+	albumList.list.selectRow(3)
+	selected := albumList.shownAlbums()[3].uri
+
+	updates := make(chan func())
+	sideBar.LoadAlbums(func(fn func()) { updates <- fn })
+
+	// pages may be fetched in any order, second one has 25 and third one 10 albums
+	cases := [][]string{
+		{"User albums (by title, loading 50 of 60)", "User albums (by title, loading 35 of 60)"},
+		{"User albums (by title)"},
+	}
+	for _, expectedTitles := range cases {
+		select {
+		case fn := <-updates:
+			fn()
+		case <-time.After(time.Second):
+			t.Fatalf("Expected page of albums to be fetched")
+		}
+		if !contains(expectedTitles, albumList.title()) {
+			t.Errorf("Expected one of titles %q, got %q", expectedTitles, albumList.title())
+		}
+		if uri := albumList.shownAlbums()[albumList.selectedAlbumIdx()].uri; uri != selected {
+			t.Errorf("Expected %s to stay selected, got %s", selected, uri)
+		}
+	}
+}
+
+func TestAlbumsAreLoadedWhenPageFails(t *testing.T) {
+	var str bytes.Buffer
+	log.SetOutput(&str)
+
+	for i := 0; i < 2; i++ { // albums are loaded again from the beginning
+		fetcher := &FakePagedAlbumFetcher{total: 80, failOffset: 50}
+		albumList := newPagedSideBar(t, fetcher).AlbumList
+		albumList.loadAlbumPages(remainingPageOffsets(80), func(fn func()) { fn() })
+
+		if len(albumList.albumsDescriptions) != 55 || albumList.title() != "User albums" {
+			t.Errorf("Expected all but the failed page to be loaded, got %d albums and title %q", len(albumList.albumsDescriptions), albumList.title())
+		}
+		if !strings.Contains(str.String(), "Could not load albums: could not fetch current user albums 51-75: error") {
+			t.Errorf("Expected failed page to be reported, log was %s", str.String())
+		}
+	}
+}
+
+func TestCachedAlbumsAreSynchronized(t *testing.T) {
+	albums := []albumDescription{}
+	for _, album := range constructNSpotifySavedAlbums(61) {
+		albums = append(albums, savedAlbumDescription(album))
+	}
+
+	cases := []struct {
+		name            string
+		cached          []albumDescription
+		expectedOffsets []int
+	}{
+		{name: "unchanged", cached: albums[:60], expectedOffsets: []int{0}},
+		{name: "added", cached: albums[2:60], expectedOffsets: []int{0}},
+		{name: "removed", cached: albums, expectedOffsets: []int{0, 25, 50}},
+	}
+	for _, c := range cases {
+		cache, cleanup := newTempCache(t)
+		cache.setAlbums(c.cached)
+		fetcher := &FakePagedAlbumFetcher{total: 60, failOffset: -1}
+		client := &DebugClient{UserAlbumFetcher: fetcher}
+		library := NewLibrary(client)
+		library.SetCache(cache)
+		sideBar, err := NewSideBar(client, library)
+		if err != nil {
+			t.Fatalf("Unexpected error occured: %s", err)
+		}
+		albumList := sideBar.AlbumList
+		if len(fetcher.offsets) != 0 || len(albumList.albumsDescriptions) != len(c.cached) || albumList.title() != "User albums (syncing)" {
+			t.Errorf("%s: expected cached albums to be displayed, got %d albums and title %q", c.name, len(albumList.albumsDescriptions), albumList.title())
+		}
+
+		updates := make(chan func())
+		sideBar.LoadAlbums(func(fn func()) { updates <- fn })
+		select {
+		case fn := <-updates:
+			fn()
+		case <-time.After(time.Second):
+			t.Fatalf("%s: expected albums to be synchronized", c.name)
+		}
+		if len(albumList.albumsDescriptions) != 60 || albumList.albumsDescriptions[0].uri != "spotify:album:savedalbum1" || albumList.title() != "User albums" {
+			t.Errorf("%s: expected all albums to be displayed, got %d albums and title %q", c.name, len(albumList.albumsDescriptions), albumList.title())
+		}
+		sort.Ints(fetcher.offsets)
+		if fmt.Sprint(fetcher.offsets) != fmt.Sprint(c.expectedOffsets) {
+			t.Errorf("%s: expected pages %v to be fetched, fetched %v", c.name, c.expectedOffsets, fetcher.offsets)
+		}
+		loaded, _ := LoadLibraryCache(cache.path)
+		if cached, _ := loaded.albums(); len(cached) != 60 {
+			t.Errorf("%s: expected synchronized albums to be cached, got %d", c.name, len(cached))
+		}
+		cleanup()
+	}
+}
+
+func TestLoadedAlbumsAreCached(t *testing.T) {
+	cache, cleanup := newTempCache(t)
+	defer cleanup()
+	fetcher := &FakePagedAlbumFetcher{total: 60, failOffset: -1}
+	client := &DebugClient{UserAlbumFetcher: fetcher}
+	library := NewLibrary(client)
+	library.SetCache(cache)
+	sideBar, _ := NewSideBar(client, library)
+
+	if _, ok := cache.albums(); ok {
+		t.Fatalf("Expected albums not to be cached before all of them are loaded")
+	}
+	sideBar.AlbumList.loadAlbumPages(remainingPageOffsets(60), func(fn func()) { fn() })
+	if cached, ok := cache.albums(); !ok || len(cached) != 60 {
+		t.Errorf("Expected all albums to be cached, got %d", len(cached))
+	}
+}
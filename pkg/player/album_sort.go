@@ -0,0 +1,193 @@
+package player
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	tui "github.com/marcusolsson/tui-go"
+)
+
+// albumSort is an order in which albums are displayed in the album list.
+type albumSort string
+
+const (
+	// sortByAdded displays recently added albums first, as Spotify does.
+	sortByAdded   albumSort = "added"
+	sortByArtist  albumSort = "artist"
+	sortByTitle   albumSort = "title"
+	sortByRelease albumSort = "released"
+	sortByRuntime albumSort = "runtime"
+)
+
+// albumSorts are orders which are switched between, in this order.
+var albumSorts = []albumSort{sortByAdded, sortByArtist, sortByTitle, sortByRelease, sortByRuntime}
+
+var albumSortDescriptions = map[albumSort]string{
+	sortByAdded:   "recently added",
+	sortByArtist:  "artist",
+	sortByTitle:   "title",
+	sortByRelease: "newest",
+	sortByRuntime: "longest",
+}
+
+// albumLess tells whether album a is displayed before album b.
+func albumLess(order albumSort, a, b albumDescription) bool {
+	switch order {
+	case sortByArtist:
+		if !strings.EqualFold(a.artist, b.artist) {
+			return strings.ToLower(a.artist) < strings.ToLower(b.artist)
+		}
+		return strings.ToLower(a.title) < strings.ToLower(b.title)
+	case sortByTitle:
+		return strings.ToLower(a.title) < strings.ToLower(b.title)
+	case sortByRelease:
+		return a.releaseDate.After(b.releaseDate)
+	case sortByRuntime:
+		return a.runtime > b.runtime
+	}
+	return a.addedAt.After(b.addedAt)
+}
+
+// arrangeAlbums returns copy of albums in given order. Grouped albums
+// are kept together with other albums of their artist, groups are
+// ordered by artist.
+func arrangeAlbums(albums []albumDescription, order albumSort, groupByArtist bool) []albumDescription {
+	arranged := append([]albumDescription{}, albums...)
+	sort.SliceStable(arranged, func(i, j int) bool {
+		a, b := arranged[i], arranged[j]
+		if groupByArtist && !strings.EqualFold(a.artist, b.artist) {
+			return strings.ToLower(a.artist) < strings.ToLower(b.artist)
+		}
+		return albumLess(order, a, b)
+	})
+	return arranged
+}
+
+// AlbumSettings are how albums are arranged in the album list. They are
+// saved after every change, so albums are arranged the same way next time.
+type AlbumSettings struct {
+	Sort          albumSort `json:"sort"`
+	GroupByArtist bool      `json:"group_by_artist"`
+
+	path string
+}
+
+// LoadAlbumSettings reads settings from the file, albums are displayed
+// in order in which they were added when there is no such file yet.
+func LoadAlbumSettings(path string) (*AlbumSettings, error) {
+	settings := &AlbumSettings{Sort: sortByAdded, path: path}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read album settings: %v", err)
+	}
+	if err := json.Unmarshal(data, settings); err != nil {
+		return nil, fmt.Errorf("could not parse album settings %s: %v", path, err)
+	}
+	if albumSortDescriptions[settings.Sort] == "" {
+		return nil, fmt.Errorf("there is no %q order of albums, available are: %s", settings.Sort, albumSortNames())
+	}
+	return settings, nil
+}
+
+func albumSortNames() string {
+	names := []string{}
+	for _, order := range albumSorts {
+		names = append(names, string(order))
+	}
+	return strings.Join(names, ", ")
+}
+
+func (s *AlbumSettings) save() error {
+	if s.path == "" {
+		return nil // settings are not stored, i.e. profile could not be loaded
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomically(s.path, data); err != nil {
+		return fmt.Errorf("could not save album settings: %v", err)
+	}
+	return nil
+}
+
+// nextSort switches to the next order of albums.
+func (s *AlbumSettings) nextSort() error {
+	for i, order := range albumSorts {
+		if order == s.Sort {
+			s.Sort = albumSorts[(i+1)%len(albumSorts)]
+			return s.save()
+		}
+	}
+	s.Sort = sortByAdded
+	return s.save()
+}
+
+func (s *AlbumSettings) toggleGroupByArtist() error {
+	s.GroupByArtist = !s.GroupByArtist
+	return s.save()
+}
+
+// description tells how albums are arranged, it is empty when they
+// are displayed as Spotify displays them.
+func (s *AlbumSettings) description() string {
+	if s == nil {
+		return ""
+	}
+	if s.Sort == sortByAdded && !s.GroupByArtist {
+		return ""
+	}
+	if s.GroupByArtist {
+		return fmt.Sprintf("by %s, grouped", albumSortDescriptions[s.Sort])
+	}
+	return fmt.Sprintf("by %s", albumSortDescriptions[s.Sort])
+}
+
+// SetSettings arranges albums with settings, which are changed with "s"
+// (next order) and "g" (group by artist) keys.
+func (sideBar *SideBar) SetSettings(settings *AlbumSettings) {
+	albumList := sideBar.AlbumList
+	albumList.settings = settings
+	albumList.rearrange()
+}
+
+// arrange puts albums in order defined by settings.
+func (albumList *AlbumList) arrange() {
+	if albumList.settings == nil {
+		return
+	}
+	albumList.albumsDescriptions = arrangeAlbums(albumList.albumsDescriptions, albumList.settings.Sort, albumList.settings.GroupByArtist)
+}
+
+// rearrange arranges albums again and displays them from the first one.
+func (albumList *AlbumList) rearrange() {
+	albumList.arrange()
+	albumList.refilter()
+	albumList.renderFromStart()
+	albumList.box.SetTitle(albumList.title())
+}
+
+func (albumList *AlbumList) onSortChanged() func(*tui.Table) {
+	return func(*tui.Table) {
+		if err := albumList.settings.nextSort(); err != nil {
+			albumList.reportError("Could not change order of albums: %s", err)
+		}
+		albumList.rearrange()
+	}
+}
+
+func (albumList *AlbumList) onGroupByArtistToggled() func(*tui.Table) {
+	return func(*tui.Table) {
+		if err := albumList.settings.toggleGroupByArtist(); err != nil {
+			albumList.reportError("Could not group albums by artist: %s", err)
+		}
+		albumList.rearrange()
+	}
+}
@@ -0,0 +1,139 @@
+package player
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zmb3/spotify"
+)
+
+func TestArrangeAlbums(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2020, 1, d, 0, 0, 0, 0, time.UTC) }
+	albums := []albumDescription{
+		{title: "Kind of Blue", artist: "Miles Davis", uri: "kob", addedAt: day(3), releaseDate: day(1).AddDate(-61, 0, 0), runtime: 45 * time.Minute},
+		{title: "A Love Supreme", artist: "John Coltrane", uri: "als", addedAt: day(2), releaseDate: day(1).AddDate(-55, 0, 0), runtime: 33 * time.Minute},
+		{title: "Bitches Brew", artist: "miles davis", uri: "bb", addedAt: day(4), releaseDate: day(1).AddDate(-50, 0, 0), runtime: 94 * time.Minute},
+		{title: "Giant Steps", artist: "John Coltrane", uri: "gs", addedAt: day(1), releaseDate: day(1).AddDate(-60, 0, 0), runtime: 37 * time.Minute},
+	}
+	cases := []struct {
+		order         albumSort
+		groupByArtist bool
+		expectedURIs  []spotify.URI
+	}{
+		{order: sortByAdded, expectedURIs: []spotify.URI{"bb", "kob", "als", "gs"}},
+		{order: sortByArtist, expectedURIs: []spotify.URI{"als", "gs", "bb", "kob"}},
+		{order: sortByTitle, expectedURIs: []spotify.URI{"als", "bb", "gs", "kob"}},
+		{order: sortByRelease, expectedURIs: []spotify.URI{"bb", "als", "gs", "kob"}},
+		{order: sortByRuntime, expectedURIs: []spotify.URI{"bb", "kob", "gs", "als"}},
+		{order: sortByAdded, groupByArtist: true, expectedURIs: []spotify.URI{"als", "gs", "bb", "kob"}},
+		{order: sortByRelease, groupByArtist: true, expectedURIs: []spotify.URI{"als", "gs", "bb", "kob"}},
+		{order: sortByRuntime, groupByArtist: true, expectedURIs: []spotify.URI{"gs", "als", "bb", "kob"}},
+	}
+	for _, c := range cases {
+		uris := []spotify.URI{}
+		for _, album := range arrangeAlbums(albums, c.order, c.groupByArtist) {
+			uris = append(uris, album.uri)
+		}
+		if !reflect.DeepEqual(uris, c.expectedURIs) {
+			t.Errorf("Expected albums sorted by %s (grouped: %t) to be %v, got %v", c.order, c.groupByArtist, c.expectedURIs, uris)
+		}
+	}
+	if albums[0].uri != "kob" {
+		t.Errorf("Expected arranged albums to be a copy")
+	}
+}
+
+func TestSavedAlbumDescription(t *testing.T) {
+	album := spotify.SavedAlbum{AddedAt: "2019-05-04T10:00:00Z"}
+	album.Name = "Kind of Blue"
+	album.URI = "spotify:album:kob"
+	album.Artists = []spotify.SimpleArtist{{Name: "Miles Davis"}}
+	album.ReleaseDate = "1959-08-17"
+	album.ReleaseDatePrecision = "day"
+	album.Tracks.Tracks = []spotify.SimpleTrack{{Duration: 60000}, {Duration: 30000}}
+
+	description := savedAlbumDescription(album)
+	if description.title != "Kind of Blue" || description.artist != "Miles Davis" {
+		t.Errorf("Expected title and artist of album, got %q and %q", description.title, description.artist)
+	}
+	if description.addedAt != time.Date(2019, 5, 4, 10, 0, 0, 0, time.UTC) || description.releaseDate.Year() != 1959 {
+		t.Errorf("Expected dates of album, got %s and %s", description.addedAt, description.releaseDate)
+	}
+	if description.runtime != 90*time.Second || albumRuntime(description) != "1:30" || albumYear(description) != "1959" {
+		t.Errorf("Expected runtime of tracks, got %s", description.runtime)
+	}
+}
+
+func TestAlbumSettingsArePersisted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "albums")
+	if err != nil {
+		t.Fatalf("Could not create directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "albums.json")
+
+	client := NewDebugClient()
+	sideBar, err := NewSideBar(client, NewLibrary(client))
+	if err != nil {
+		t.Fatalf("Unexpected error occured: %s", err)
+	}
+	settings, err := LoadAlbumSettings(path)
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	sideBar.SetSettings(settings)
+	albumList := sideBar.AlbumList
+
+	cases := []struct {
+		key           string
+		expectedTitle string
+		expectedFirst spotify.URI
+	}{
+		{key: "s", expectedTitle: "User albums (by artist)", expectedFirst: "spotify:album:savedalbum1"},
+		{key: "s", expectedTitle: "User albums (by title)", expectedFirst: "spotify:album:savedalbum1"},
+		{key: "s", expectedTitle: "User albums (by newest)", expectedFirst: "spotify:album:savedalbum109"},
+		{key: "g", expectedTitle: "User albums (by newest, grouped)", expectedFirst: "spotify:album:savedalbum1"},
+		{key: "s", expectedTitle: "User albums (by longest, grouped)", expectedFirst: "spotify:album:savedalbum121"},
+		{key: "s", expectedTitle: "User albums (by recently added, grouped)", expectedFirst: "spotify:album:savedalbum1"},
+		{key: "g", expectedTitle: "User albums", expectedFirst: "spotify:album:savedalbum1"},
+		{key: "s", expectedTitle: "User albums (by artist)", expectedFirst: "spotify:album:savedalbum1"},
+	}
+	for _, c := range cases {
+		albumList.list.actions[c.key](albumList.Table)
+		if albumList.title() != c.expectedTitle {
+			t.Errorf("Expected title %q, got %q", c.expectedTitle, albumList.title())
+		}
+		if first := albumList.shownAlbums()[0].uri; first != c.expectedFirst {
+			t.Errorf("Expected %s to be the first album when %s, got %s", c.expectedFirst, c.expectedTitle, first)
+		}
+	}
+
+	loaded, err := LoadAlbumSettings(path)
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	if loaded.Sort != sortByArtist || loaded.GroupByArtist {
+		t.Errorf("Expected settings to be saved, got %+v", loaded)
+	}
+}
+
+func TestLoadInvalidAlbumSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "albums")
+	if err != nil {
+		t.Fatalf("Could not create directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	for _, content := range []string{"{", `{"sort": "popularity"}`} {
+		path := filepath.Join(dir, "albums.json")
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Could not write settings: %s", err)
+		}
+		if _, err := LoadAlbumSettings(path); err == nil {
+			t.Errorf("Expected error for settings %s", content)
+		}
+	}
+}
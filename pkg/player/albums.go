@@ -3,8 +3,10 @@ package player
 import (
 	"fmt"
 	"log"
+	"time"
 
 	tui "github.com/marcusolsson/tui-go"
+	runewidth "github.com/mattn/go-runewidth"
 	"github.com/zmb3/spotify"
 )
 
@@ -12,104 +14,182 @@ import (
 type SideBar struct {
 	AlbumList *AlbumList
 	Box       *tui.Box
+	*radioStarter
+	*errorReporter
 }
 
 type renderer interface {
 	render() error
 }
 
-type pageRenderer interface {
-	renderPage([]albumDescription, int, int) error
-}
-
 type dataFetcher interface {
-	fetchUserAlbums() ([]albumDescription, error)
-}
-
-type pagination interface {
-	nextPage() bool
-	previousPage() bool
-	updateIndexes()
-
-	getCurrDataIdx() int
-	setLastTwoSelected([]int)
+	// fetchAlbumsPage fetches page of user albums starting at offset, it
+	// returns also the number of all user albums.
+	fetchAlbumsPage(offset int) ([]albumDescription, int, error)
 }
 
 // AlbumList represents list of albums with underlying data,
-// table to display, box in which table is places, indexes
-// pointing to currently playing item, and last chosen items.
+// table to display them, and box in which table is placed.
 type AlbumList struct {
 	client             SpotifyClient
+	library            *Library
 	albumsDescriptions []albumDescription
 	Table              *tui.Table
+	list               *virtualTable
 	box                *tui.Box
 
+	// pendingRemoval is an album which user wants to remove from library,
+	// it is removed after user confirms it.
+	pendingRemoval *albumDescription
+	// removedAt remembers where removed albums were, so they can be
+	// put back in the same place if removing them fails.
+	removedAt map[spotify.URI]removedAlbum
+
+	radio *radioStarter
+
+	filter   albumFilter
+	settings *AlbumSettings
+	loading  albumsLoading
+
+	*errorReporter
 	renderer
-	pageRenderer
 	dataFetcher
-	pagination
 }
 
 type albumDescription struct {
 	artist string
 	title  string
 	uri    spotify.URI
+
+	addedAt     time.Time
+	releaseDate time.Time
+	// runtime is the sum of durations of all album tracks.
+	runtime time.Duration
+}
+
+type removedAlbum struct {
+	idx   int
+	album albumDescription
 }
 
+const albumListTitle = "User albums"
+
 var (
-	visibleAlbums        = 45
-	spotifyAPIPageSize   = 25
-	spotifyAPIPageOffset = 25
-	uiColumnWidth        = 20
+	visibleAlbums      = 45
+	spotifyAPIPageSize = 25
+	uiColumnWidth      = 20
+	// albumTracksPageSize is the maximum number of album tracks which
+	// Spotify returns at once.
+	albumTracksPageSize = 50
 )
 
 // NewSideBar creates struct which holds references to
-// SideBar Box and AlbumList placed inside SideBar
-func NewSideBar(client SpotifyClient) (*SideBar, error) {
-	al := newEmptyAlbumList(client)
+// SideBar Box and AlbumList placed inside SideBar. Only the first
+// page of albums is displayed, remaining ones are loaded with LoadAlbums.
+func NewSideBar(client SpotifyClient, library *Library) (*SideBar, error) {
+	al := newEmptyAlbumList(client, library)
 	err := al.render()
 	if err != nil {
 		return nil, err
 	}
+	for _, album := range al.albumsDescriptions {
+		library.albums.markSaved(idFromURI(album.uri))
+	}
+	library.albums.onChange(al.onAlbumSavedChanged)
 	box := tui.NewHBox(al.box, tui.NewSpacer())
-	return &SideBar{AlbumList: al, Box: box}, nil
+	return &SideBar{AlbumList: al, Box: box, radioStarter: al.radio, errorReporter: al.errorReporter}, nil
 }
 
-func newEmptyAlbumList(client SpotifyClient) *AlbumList {
-	table := tui.NewTable(0, 0)
-	table.SetColumnStretch(0, 1)
-	table.SetColumnStretch(1, 1)
-	table.SetColumnStretch(2, 4)
+func newEmptyAlbumList(client SpotifyClient, library *Library) *AlbumList {
+	albumList := &AlbumList{
+		client:             client,
+		library:            library,
+		albumsDescriptions: []albumDescription{},
+		removedAt:          map[spotify.URI]removedAlbum{},
+		radio:              &radioStarter{},
+		settings:           &AlbumSettings{Sort: sortByAdded},
+		errorReporter:      &errorReporter{},
 
-	albumListBox := tui.NewVBox(table, tui.NewSpacer())
+		dataFetcher: &fetchUserAlbumsStruct{client: client},
+	}
+	list := newVirtualTable(albumList, visibleAlbums)
+	list.SetColumnStretch(0, 1)
+	list.SetColumnStretch(1, 1)
+	list.SetColumnStretch(2, 0)
+	list.SetColumnStretch(3, 0)
+	list.setHeader(
+		tui.NewLabel("Title"),
+		tui.NewLabel("Artist"),
+		fixedLabel("Year"),
+		fixedLabel("Time"),
+	)
+
+	albumListBox := tui.NewVBox(list, tui.NewSpacer())
 	albumListBox.SetBorder(true)
-	albumListBox.SetTitle("User albums")
+	albumListBox.SetTitle(albumListTitle)
 	albumListBox.SetSizePolicy(tui.Preferred, tui.Expanding)
 
-	return &AlbumList{
-		client:             client,
-		Table:              table,
-		box:                albumListBox,
-		albumsDescriptions: []albumDescription{},
+	albumList.Table = list.Table
+	albumList.list = list
+	albumList.box = albumListBox
+
+	list.intercept = albumList.onFilterKey
+	list.onKey("d", albumList.onRemoveRequested())
+	list.onKey("y", albumList.onRemoveConfirmed())
+	list.onKey("n", albumList.onRemoveCancelled())
+	list.onKey("s", albumList.onSortChanged())
+	list.onKey("g", albumList.onGroupByArtistToggled())
+	list.onKey("r", func(*tui.Table) {
+		idx := albumList.selectedAlbumIdx()
+		if idx < 0 {
+			return
+		}
+		album := albumList.shownAlbums()[idx]
+		albumList.radio.startRadio(radioSeedFromURI(album.uri, album.title))
+	})
+	list.OnItemActivated(albumList.onItemActivaed())
+	return albumList
+}
 
-		dataFetcher:  &fetchUserAlbumsStruct{client: client},
-		pageRenderer: &renderPageStruct{table: table},
-		pagination:   &paginatorStruct{table: table, lastTwoSelected: []int{-1, -1}, currDataIdx: 0},
-	}
+// Focusables returns table of albums.
+func (sideBar *SideBar) Focusables() []tui.Widget {
+	return []tui.Widget{sideBar.AlbumList.Table}
 }
 
+// render displays cached albums, or fetches the first page of user albums
+// and displays it. Remaining pages are loaded, and cached albums are
+// synchronized with LoadAlbums. When Spotify is not reachable, albums are
+// loaded with Reconnect.
 func (albumList *AlbumList) render() error {
-	albumsDescriptions, err := albumList.dataFetcher.fetchUserAlbums()
-	if err != nil {
+	if cached, ok := albumList.cache().albums(); ok {
+		albumList.albumsDescriptions = cached
+		albumList.loading = albumsLoading{loaded: len(cached), total: len(cached), syncing: true}
+	} else if err := albumList.renderFirstPage(); err != nil {
 		return err
 	}
-	albumList.albumsDescriptions = albumsDescriptions
-	err = albumList.pageRenderer.renderPage(albumList.albumsDescriptions, 0, visibleAlbums)
+	albumList.arrange()
+	albumList.list.reset()
+	albumList.box.SetTitle(albumList.title())
+	return nil
+}
+
+func (albumList *AlbumList) renderFirstPage() error {
+	albumsDescriptions, total, err := albumList.dataFetcher.fetchAlbumsPage(0)
+	if isConnectionError(err) {
+		log.Printf("Albums are loaded when connection returns: %s", err)
+		albumList.loading = albumsLoading{offline: true}
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	albumList.Table.OnSelectionChanged(albumList.onSelectedChanged())
-	albumList.Table.OnItemActivated(albumList.onItemActivaed())
+	albumList.albumsDescriptions = albumsDescriptions
+	albumList.loading = albumsLoading{
+		loaded:  len(albumsDescriptions),
+		total:   total,
+		pending: len(remainingPageOffsets(total)),
+	}
+	albumList.saveCache()
 	return nil
 }
 
@@ -117,141 +197,211 @@ type fetchUserAlbumsStruct struct {
 	client SpotifyClient
 }
 
-func (fetchUserAlbumsStruct *fetchUserAlbumsStruct) fetchUserAlbums() ([]albumDescription, error) {
-	initialPage, err := fetchUserAlbumsStruct.client.CurrentUsersAlbumsOpt(&spotify.Options{Limit: &spotifyAPIPageSize})
+func (fetchUserAlbumsStruct *fetchUserAlbumsStruct) fetchAlbumsPage(offset int) ([]albumDescription, int, error) {
+	limit := spotifyAPIPageSize
+	page, err := fetchUserAlbumsStruct.client.CurrentUsersAlbumsOpt(&spotify.Options{Limit: &limit, Offset: &offset})
 	if err != nil {
-		return nil, fmt.Errorf("could not fetch current user albums: %v", err)
+		return nil, 0, wrapError(err, "could not fetch current user albums %d-%d: %v", offset+1, offset+limit, err)
 	}
-	userAlbums := make([]spotify.SavedAlbum, 0)
-	userAlbums = append(userAlbums, initialPage.Albums...)
-
-	page := initialPage
-	for spotifyAPIPageOffset < initialPage.Total {
-		page, err = fetchUserAlbumsStruct.client.CurrentUsersAlbumsOpt(&spotify.Options{
-			Limit:  &initialPage.Limit,
-			Offset: &spotifyAPIPageOffset,
-		})
+	albumsDescriptions := make([]albumDescription, 0, len(page.Albums))
+	for _, album := range page.Albums {
+		description := savedAlbumDescription(album)
+		runtime, err := fetchUserAlbumsStruct.fetchRemainingRuntime(album)
+		if err != nil {
+			return nil, 0, wrapError(err, "could not fetch tracks of album %s: %v", album.ID, err)
+		}
+		description.runtime += runtime
+		albumsDescriptions = append(albumsDescriptions, description)
+	}
+	return albumsDescriptions, page.Total, nil
+}
+
+// fetchRemainingRuntime sums durations of album tracks which are not on the
+// first page of them, which comes with saved album, i.e. of box sets.
+func (fetchUserAlbumsStruct *fetchUserAlbumsStruct) fetchRemainingRuntime(album spotify.SavedAlbum) (time.Duration, error) {
+	var runtime time.Duration
+	offset := len(album.Tracks.Tracks)
+	for offset < album.Tracks.Total {
+		limit := albumTracksPageSize
+		page, err := fetchUserAlbumsStruct.client.GetAlbumTracksOpt(album.ID, &spotify.Options{Limit: &limit, Offset: &offset})
 		if err != nil {
-			return nil, fmt.Errorf("could not fetch page current user albums: %v", err)
+			return 0, err
+		}
+		if len(page.Tracks) == 0 {
+			break
 		}
-		spotifyAPIPageOffset += spotifyAPIPageSize
-		userAlbums = append(userAlbums, page.Albums...)
+		for _, track := range page.Tracks {
+			runtime += time.Duration(track.Duration) * time.Millisecond
+		}
+		offset += len(page.Tracks)
+	}
+	return runtime, nil
+}
+
+// savedAlbumDescription describes saved album, its runtime is the sum of
+// durations of the first page of its tracks.
+func savedAlbumDescription(album spotify.SavedAlbum) albumDescription {
+	description := albumDescription{
+		artist:      artistName(album.Artists),
+		title:       album.Name,
+		uri:         album.URI,
+		releaseDate: album.ReleaseDateTime(),
+	}
+	description.addedAt, _ = time.Parse(spotify.TimestampLayout, album.AddedAt)
+	for _, track := range album.Tracks.Tracks {
+		description.runtime += time.Duration(track.Duration) * time.Millisecond
 	}
+	return description
+}
 
-	albumsDescriptions := make([]albumDescription, 0)
-	for _, album := range userAlbums {
-		albumsDescriptions = append(albumsDescriptions, albumDescription{album.Name, album.Artists[0].Name, album.URI})
+// selectedAlbumIdx returns index of selected album in shown albums,
+// or -1 when no album is selected.
+func (albumList *AlbumList) selectedAlbumIdx() int {
+	idx := albumList.list.selectedRow()
+	if idx < 0 || idx >= len(albumList.shownAlbums()) {
+		return -1
 	}
-	return albumsDescriptions, nil
+	return idx
 }
 
-func (albumList *AlbumList) onSelectedChanged() func(*tui.Table) {
+func (albumList *AlbumList) onItemActivaed() func(*tui.Table) {
 	return func(t *tui.Table) {
-		if albumList.nextPage() {
-			err := albumList.renderPage(
-				albumList.albumsDescriptions,
-				(albumList.getCurrDataIdx()/visibleAlbums)*visibleAlbums,
-				(albumList.getCurrDataIdx()/visibleAlbums)*visibleAlbums+visibleAlbums,
-			)
-			if err != nil {
-				log.Printf("Could not render next page of albums with %s", err)
-				return
-			}
-			albumList.setLastTwoSelected([]int{-1, -1})
-			t.Select(1)
+		idx := albumList.selectedAlbumIdx()
+		if idx < 0 {
 			return
 		}
-		if albumList.previousPage() {
-			err := albumList.renderPage(
-				albumList.albumsDescriptions,
-				(albumList.getCurrDataIdx()/visibleAlbums)*visibleAlbums-visibleAlbums,
-				(albumList.getCurrDataIdx()/visibleAlbums)*visibleAlbums,
-			)
-			if err != nil {
-				log.Printf("Could not render previous page of albums with %s", err)
-				return
-			}
-			albumList.setLastTwoSelected([]int{visibleAlbums + 2, visibleAlbums + 1})
-			t.Select(visibleAlbums)
-			return
+		uri := &albumList.shownAlbums()[idx].uri
+		err := albumList.client.PlayOpt(&spotify.PlayOptions{PlaybackContext: uri})
+		if err != nil {
+			albumList.reportError("Error occured while trying to play track with uri: %s", *uri)
 		}
-		albumList.updateIndexes()
 	}
 }
 
-type paginatorStruct struct {
-	currDataIdx     int
-	lastTwoSelected []int
-	table           *tui.Table
+// onRemoveRequested asks user to confirm removing selected album from library.
+func (albumList *AlbumList) onRemoveRequested() func(*tui.Table) {
+	return func(t *tui.Table) {
+		idx := albumList.selectedAlbumIdx()
+		if idx < 0 {
+			return
+		}
+		album := albumList.shownAlbums()[idx]
+		albumList.pendingRemoval = &album
+		albumList.box.SetTitle(fmt.Sprintf("Remove %s? (y/n)", trimWithCommasIfTooLong(album.title, uiColumnWidth)))
+	}
 }
 
-func (paginator *paginatorStruct) setLastTwoSelected(lastTwoSelected []int) {
-	paginator.lastTwoSelected = lastTwoSelected
+func (albumList *AlbumList) onRemoveConfirmed() func(*tui.Table) {
+	return func(t *tui.Table) {
+		album := albumList.pendingRemoval
+		if album == nil {
+			return
+		}
+		albumList.pendingRemoval = nil
+		albumList.box.SetTitle(albumList.title())
+		item := libraryItem{id: idFromURI(album.uri), uri: album.uri, name: album.title, artist: album.artist}
+		albumList.library.albums.set(item, false, func(err error) {
+			if err != nil {
+				albumList.reportError("Could not remove album from library: %s", err)
+			}
+		})
+	}
 }
 
-func (paginator *paginatorStruct) getCurrDataIdx() int {
-	return paginator.currDataIdx
+func (albumList *AlbumList) onRemoveCancelled() func(*tui.Table) {
+	return func(t *tui.Table) {
+		albumList.pendingRemoval = nil
+		albumList.box.SetTitle(albumList.title())
+	}
 }
 
-func (paginator *paginatorStruct) nextPage() bool {
-	return paginator.lastTwoSelected[0] == visibleAlbums-1 && paginator.lastTwoSelected[1] == visibleAlbums
+// onAlbumSavedChanged updates albums in place when album is saved or removed
+// from library anywhere in the application.
+func (albumList *AlbumList) onAlbumSavedChanged(item libraryItem, saved bool) {
+	idx := -1
+	for i, album := range albumList.albumsDescriptions {
+		if album.uri == item.uri {
+			idx = i
+			break
+		}
+	}
+	switch {
+	case saved && idx < 0:
+		// Albums are ordered from the most recently added, unless album is
+		// put back after failed removal.
+		removed, wasRemoved := albumList.removedAt[item.uri]
+		if !wasRemoved || removed.idx > len(albumList.albumsDescriptions) {
+			removed = removedAlbum{album: albumDescription{artist: item.artist, title: item.name, uri: item.uri, addedAt: time.Now()}}
+		}
+		delete(albumList.removedAt, item.uri)
+		albumList.albumsDescriptions = append(albumList.albumsDescriptions, albumDescription{})
+		copy(albumList.albumsDescriptions[removed.idx+1:], albumList.albumsDescriptions[removed.idx:])
+		albumList.albumsDescriptions[removed.idx] = removed.album
+	case !saved && idx >= 0:
+		albumList.removedAt[item.uri] = removedAlbum{idx: idx, album: albumList.albumsDescriptions[idx]}
+		albumList.albumsDescriptions = append(albumList.albumsDescriptions[:idx], albumList.albumsDescriptions[idx+1:]...)
+	default:
+		return
+	}
+	albumList.arrange()
+	albumList.refilter()
+	albumList.list.refresh()
+	albumList.saveCache()
 }
 
-func (paginator *paginatorStruct) previousPage() bool {
-	return paginator.lastTwoSelected[1] == 1 && paginator.table.Selected() == 0 && paginator.currDataIdx >= visibleAlbums
+// rowCount returns number of shown albums, so that album list is a source
+// of rows of its table.
+func (albumList *AlbumList) rowCount() int {
+	return len(albumList.shownAlbums())
 }
 
-func (paginator *paginatorStruct) updateIndexes() {
-	paginator.lastTwoSelected[0] = paginator.lastTwoSelected[1]
-	paginator.lastTwoSelected[1] = paginator.table.Selected()
-
-	if paginator.lastTwoSelected[0] > paginator.lastTwoSelected[1] {
-		paginator.currDataIdx--
+// row returns cells of shown album with given index, parts of album which
+// match the filter are highlighted. When albums are grouped by artist,
+// artist is displayed only next to the first album of the artist.
+func (albumList *AlbumList) row(idx int) []tui.Widget {
+	albums := albumList.shownAlbums()
+	album := albums[idx]
+	title, artist, _ := albumMatch(albumList.filter.pattern, album)
+	artistName := album.artist
+	if albumList.settings.GroupByArtist && idx > 0 && albums[idx-1].artist == album.artist {
+		artistName = ""
 	}
-	if paginator.lastTwoSelected[0] < paginator.lastTwoSelected[1] {
-		paginator.currDataIdx++
+	return []tui.Widget{
+		newHighlightLabel(trimWithCommasIfTooLong(album.title, uiColumnWidth), title),
+		newHighlightLabel(trimWithCommasIfTooLong(artistName, uiColumnWidth), artist),
+		fixedLabel(albumYear(album)),
+		fixedLabel(albumRuntime(album)),
 	}
 }
 
-func (albumList *AlbumList) onItemActivaed() func(*tui.Table) {
-	return func(t *tui.Table) {
-		// -2 because tui.Table starts counting at 1, and additional 1 is added because first row is a header
-		uri := &albumList.albumsDescriptions[albumList.pagination.getCurrDataIdx()-2].uri
-		err := albumList.client.PlayOpt(&spotify.PlayOptions{PlaybackContext: uri})
-		if err != nil {
-			log.Printf("Error occured while trying to play track with uri: %s", *uri)
-		}
-	}
+// fixedLabel is a label which is never narrower than its text, so that
+// columns which do not stretch are as wide as their content. Text is
+// preceded by a space which separates it from the previous column.
+func fixedLabel(text string) *tui.Label {
+	label := tui.NewLabel(" " + text)
+	label.SetSizePolicy(tui.Minimum, tui.Preferred)
+	return label
 }
 
-type renderPageStruct struct {
-	table *tui.Table
+func albumYear(album albumDescription) string {
+	if album.releaseDate.IsZero() {
+		return ""
+	}
+	return formatYear(album.releaseDate.Year())
 }
 
-func (renderPageStruct *renderPageStruct) renderPage(albumsDescriptions []albumDescription, start, end int) error {
-	renderPageStruct.table.RemoveRows()
-	renderPageStruct.table.AppendRow(
-		tui.NewLabel("Title"),
-		tui.NewLabel("Artist"),
-	)
-	if len(albumsDescriptions) == 0 {
-		return fmt.Errorf("could not iterate over empty slice")
-	}
-	if len(albumsDescriptions) < end {
-		end = len(albumsDescriptions) // This means that there is less user albums than there is displayed at once on the page.
+func albumRuntime(album albumDescription) string {
+	if album.runtime == 0 {
+		return ""
 	}
-	for _, album := range albumsDescriptions[start:end] {
-		renderPageStruct.table.AppendRow(
-			tui.NewLabel(trimWithCommasIfTooLong(album.artist, uiColumnWidth)),
-			tui.NewLabel(trimWithCommasIfTooLong(album.title, uiColumnWidth)),
-		)
-	}
-	return nil
+	return formatDuration(int(album.runtime / time.Millisecond))
 }
 
+// trimWithCommasIfTooLong truncates text which takes more than maxLength
+// cells of the terminal, wide characters take two cells.
 func trimWithCommasIfTooLong(text string, maxLength int) string {
-	if len(text) > maxLength {
-		text = text[:maxLength] + "..."
+	if runewidth.StringWidth(text) > maxLength {
+		text = runewidth.Truncate(text, maxLength, "") + "..."
 	}
 	return text
 }
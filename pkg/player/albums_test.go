@@ -4,9 +4,9 @@ import (
 	"bytes"
 	"fmt"
 	"log"
-	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/marcusolsson/tui-go"
 
@@ -15,7 +15,7 @@ import (
 
 func TestNewSideBar(t *testing.T) {
 	client := NewDebugClient()
-	sideBar, err := NewSideBar(client)
+	sideBar, err := NewSideBar(client, NewLibrary(client))
 	if err != nil {
 		t.Fatalf("Unexpected error occured: %s", err)
 	}
@@ -45,133 +45,73 @@ func (fake *AlbumFetcherMock) CurrentUsersAlbumsOpt(opt *spotify.Options) (*spot
 	return returnValue, nil
 }
 
-func TestFetchUserAlbumListFetchesNoPages(t *testing.T) {
-	client := &DebugClient{}
-	fetcherMock := &AlbumFetcherMock{}
-	fetcherMock.callConfigs = []CallConfig{
-		{
-			executionError: false,
-			returnValue:    &spotify.SavedAlbumPage{Albums: make([]spotify.SavedAlbum, 0)},
-		},
-	}
-	client.UserAlbumFetcher = fetcherMock
-
-	albumList := newEmptyAlbumList(client)
-	albumList.fetchUserAlbums()
-
-	if len(albumList.albumsDescriptions) != 0 {
-		t.Fatalf("Expected albums descriptions to be empty, but it has length of %d", len(albumList.albumsDescriptions))
-	}
-}
-
-func TestFetchUserAlbumListFetchesSinglePage(t *testing.T) {
-	client := &DebugClient{}
-	fetcherMock := &AlbumFetcherMock{}
-
+func TestFetchAlbumsPage(t *testing.T) {
 	saved := &spotify.SavedAlbumPage{Albums: constructNSpotifySavedAlbums(25)}
-	saved.Total = 25 // Only one page
+	saved.Total = 40
 
-	fetcherMock.callConfigs = []CallConfig{
-		{
-			executionError: false,
-			returnValue:    saved,
-		},
-	}
-	client.UserAlbumFetcher = fetcherMock
-
-	albumList := newEmptyAlbumList(client)
-	albumsDescriptions, err := albumList.fetchUserAlbums()
-	if err != nil {
-		t.Fatalf("Did not expect to fail, but it did")
-	}
-	if len(albumsDescriptions) != 25 {
-		t.Fatalf("Expected albums descriptions to have 25 elements, but have %d elements", len(albumsDescriptions))
+	cases := []struct {
+		callConfig     CallConfig
+		expectedAlbums int
+		expectedTotal  int
+		expectedError  bool
+	}{
+		{callConfig: CallConfig{returnValue: &spotify.SavedAlbumPage{}}, expectedAlbums: 0},
+		{callConfig: CallConfig{returnValue: saved}, expectedAlbums: 25, expectedTotal: 40},
+		{callConfig: CallConfig{executionError: true}, expectedError: true},
 	}
-	if fetcherMock.call != 1 {
-		t.Fatalf("Expected CurrentUsersAlbumsOpt() to be called once, but it was called %d times", fetcherMock.call)
+	for _, c := range cases {
+		fetcherMock := &AlbumFetcherMock{callConfigs: []CallConfig{c.callConfig}}
+		albumList := newEmptyAlbumList(&DebugClient{UserAlbumFetcher: fetcherMock}, nil)
+		albumsDescriptions, total, err := albumList.fetchAlbumsPage(25)
+		if (err != nil) != c.expectedError {
+			t.Fatalf("Expected error to be %t, got %v", c.expectedError, err)
+		}
+		if len(albumsDescriptions) != c.expectedAlbums || total != c.expectedTotal {
+			t.Errorf("Expected %d of %d albums, got %d of %d", c.expectedAlbums, c.expectedTotal, len(albumsDescriptions), total)
+		}
+		if fetcherMock.call != 1 {
+			t.Errorf("Expected CurrentUsersAlbumsOpt() to be called once, but it was called %d times", fetcherMock.call)
+		}
 	}
 }
-func TestFetchUserAlbumListFetchesManyPages(t *testing.T) {
-	defer func() { spotifyAPIPageOffset = 25 }() // Reset after test
-	client := &DebugClient{}
-	fetcherMock := &AlbumFetcherMock{}
-
-	saved := &spotify.SavedAlbumPage{Albums: constructNSpotifySavedAlbums(25)}
-	saved.Total = 50
 
-	fetcherMock.callConfigs = []CallConfig{
-		{
-			executionError: false,
-			returnValue:    saved,
-		},
-		{
-			executionError: false,
-			returnValue:    saved,
-		},
-	}
-	client.UserAlbumFetcher = fetcherMock
-
-	albumList := newEmptyAlbumList(client)
-	albumsDescriptions, err := albumList.fetchUserAlbums()
-	if err != nil {
-		t.Fatalf("Did not expect to fail, but it did")
-	}
-	if len(albumsDescriptions) != 50 {
-		t.Fatalf("Expected albums descriptions to have 50 elements, but have %d elements", len(albumsDescriptions))
-	}
-	if fetcherMock.call != 2 {
-		t.Fatalf("Expected CurrentUsersAlbumsOpt() to be called twice, but it was called %d times", fetcherMock.call)
-	}
+// FakeAlbumTracks has albums with 120 tracks which are a minute long.
+type FakeAlbumTracks struct {
+	DebugRecommender
+	calls int
 }
-func TestFetchUserAlbumListFailsOnFirstCall(t *testing.T) {
-	client := &DebugClient{}
-	fetcherMock := &AlbumFetcherMock{}
-
-	fetcherMock.callConfigs = []CallConfig{
-		{
-			executionError: true,
-			returnValue:    nil,
-		},
-	}
-	client.UserAlbumFetcher = fetcherMock
 
-	albumList := newEmptyAlbumList(client)
-	_, err := albumList.fetchUserAlbums()
-	if err == nil {
-		t.Fatalf("Expected to fail, but it didn't")
-	}
-	if fetcherMock.call != 1 {
-		t.Fatalf("Expected CurrentUsersAlbumsOpt() to be called once, but it was called %d times", fetcherMock.call)
+func (fake *FakeAlbumTracks) GetAlbumTracksOpt(id spotify.ID, opt *spotify.Options) (*spotify.SimpleTrackPage, error) {
+	fake.calls++
+	page := &spotify.SimpleTrackPage{}
+	for i := *opt.Offset; i < *opt.Offset+*opt.Limit && i < 120; i++ {
+		page.Tracks = append(page.Tracks, spotify.SimpleTrack{Duration: 60000})
 	}
+	page.Total = 120
+	return page, nil
 }
 
-func TestFetchUserAlbumListFailsWhenFetchingNotFirstPage(t *testing.T) {
-	defer func() { spotifyAPIPageOffset = 25 }() // Reset after test
-	client := &DebugClient{}
-	fetcherMock := &AlbumFetcherMock{}
-
-	saved := &spotify.SavedAlbumPage{Albums: constructNSpotifySavedAlbums(25)}
-	saved.Total = 50
-
-	fetcherMock.callConfigs = []CallConfig{
-		{
-			executionError: false,
-			returnValue:    saved,
-		},
-		{
-			executionError: true,
-			returnValue:    nil,
-		},
+func TestFetchAlbumsPageSumsRuntimeOfAllTracks(t *testing.T) {
+	album := constructNSpotifySavedAlbums(1)[0]
+	album.Tracks.Tracks = []spotify.SimpleTrack{{Duration: 60000}, {Duration: 60000}}
+	album.Tracks.Total = 120
+	saved := &spotify.SavedAlbumPage{Albums: []spotify.SavedAlbum{album}}
+	saved.Total = 1
+	tracks := &FakeAlbumTracks{}
+	fetcher := &fetchUserAlbumsStruct{client: &DebugClient{
+		UserAlbumFetcher: &AlbumFetcherMock{callConfigs: []CallConfig{{returnValue: saved}}},
+		Recommender:      tracks,
+	}}
+
+	albums, _, err := fetcher.fetchAlbumsPage(0)
+	if err != nil {
+		t.Fatalf("Did not expect to fail, but it did with %s", err)
 	}
-	client.UserAlbumFetcher = fetcherMock
-
-	albumList := newEmptyAlbumList(client)
-	_, err := albumList.fetchUserAlbums()
-	if err == nil {
-		t.Fatalf("Expected to fail, but it didn't")
+	if albums[0].runtime != 120*time.Minute {
+		t.Errorf("Expected runtime of all 120 tracks, got %s", albums[0].runtime)
 	}
-	if fetcherMock.call != 2 {
-		t.Fatalf("Expected CurrentUsersAlbumsOpt() to be called twice, but it was called %d times", fetcherMock.call)
+	if tracks.calls != 3 {
+		t.Errorf("Expected remaining tracks to be fetched in 3 pages, fetched in %d", tracks.calls)
 	}
 }
 
@@ -179,28 +119,11 @@ type fakeDataFetcher struct {
 	ExecutionError bool
 }
 
-func (fake *fakeDataFetcher) fetchUserAlbums() ([]albumDescription, error) {
+func (fake *fakeDataFetcher) fetchAlbumsPage(offset int) ([]albumDescription, int, error) {
 	if fake.ExecutionError == true {
-		return nil, fmt.Errorf("error")
+		return nil, 0, fmt.Errorf("error")
 	}
-	return []albumDescription{{artist: "Artist", title: "Title", uri: "uri"}}, nil
-}
-
-type fakePageRenderer struct {
-	givenAlbumsDescriptions []albumDescription
-	givenStart              int
-	givenEnd                int
-	ExecutionError          bool
-}
-
-func (fake *fakePageRenderer) renderPage(albumsDescriptions []albumDescription, start, end int) error {
-	fake.givenAlbumsDescriptions = albumsDescriptions
-	fake.givenStart = start
-	fake.givenEnd = end
-	if fake.ExecutionError == true {
-		return fmt.Errorf("error")
-	}
-	return nil
+	return []albumDescription{{artist: "Artist", title: "Title", uri: "uri"}}, 1, nil
 }
 
 func TestRenderFailsWhenFetchingUserAlbumsFail(t *testing.T) {
@@ -212,187 +135,64 @@ func TestRenderFailsWhenFetchingUserAlbumsFail(t *testing.T) {
 	}
 }
 
-func TestRenderFailsWhenPageRenderingFail(t *testing.T) {
-	albumList := &AlbumList{}
-	albumList.dataFetcher = &fakeDataFetcher{ExecutionError: false}
-	albumList.pageRenderer = &fakePageRenderer{ExecutionError: true}
-	err := albumList.render()
-	if err == nil {
-		t.Fatalf("Expected to fail but it didn't")
-	}
-}
-
 func TestRenderSucceds(t *testing.T) {
-	albumList := &AlbumList{
-		dataFetcher:  &fakeDataFetcher{ExecutionError: false},
-		pageRenderer: &fakePageRenderer{ExecutionError: false},
-		Table:        &tui.Table{},
-	}
+	albumList := newEmptyAlbumList(&DebugClient{}, nil)
+	albumList.dataFetcher = &fakeDataFetcher{ExecutionError: false}
 	err := albumList.render()
 	if err != nil {
 		t.Fatalf("Did not expect to fail but it did with %#v", err)
 	}
-	// Here it should test that table has row added, but I don't see a way to this (query for table size would be nice)
-}
-
-func TestNextPage(t *testing.T) {
-	testPaginator := &paginatorStruct{table: &tui.Table{}}
-	cases := []struct {
-		lastTwoSelected    []int
-		shouldOpenNextPage bool
-	}{
-		{[]int{44, 45}, true}, // We are on last item, we should go to next page
-		{[]int{0, 1}, false},  // We are on the beginning on the list, we should not go to next page
-	}
-	for _, c := range cases {
-		testPaginator.lastTwoSelected = c.lastTwoSelected
-		if shouldOpenNextPage := testPaginator.nextPage(); shouldOpenNextPage != c.shouldOpenNextPage {
-			t.Fatalf("Got %v, but wanted %v for next page", shouldOpenNextPage, c.shouldOpenNextPage)
-		}
+	if albumList.selectedAlbumIdx() != 0 {
+		t.Fatalf("Expected the first album to be selected, got %d", albumList.selectedAlbumIdx())
 	}
 }
 
-func TestPreviousPage(t *testing.T) {
-	testPaginator := &paginatorStruct{table: &tui.Table{}}
-	cases := []struct {
-		lastTwoSelected        []int
-		selectedTableItem      int
-		currDataIdx            int
-		shouldOpenPreviousPage bool
-	}{
-		{[]int{0, 1}, 0, 46, true}, // Only conditions where next page will be displayed
-		{[]int{44, 45}, 0, 1, false},
-		{[]int{0, 1}, 1, 46, false},
-		{[]int{0, 1}, 0, 0, false},
-	}
-	for _, c := range cases {
-		testPaginator.lastTwoSelected = c.lastTwoSelected
-		testPaginator.currDataIdx = c.currDataIdx       // Pretend this is current data index
-		testPaginator.table.Select(c.selectedTableItem) // Pretend that this item is selected
-		if shouldOpenPreviousPage := testPaginator.previousPage(); shouldOpenPreviousPage != c.shouldOpenPreviousPage {
-			t.Fatalf("Got %v, but wanted %v for previous page", shouldOpenPreviousPage, c.shouldOpenPreviousPage)
-		}
-	}
-}
-
-func TestUpdateIndexes(t *testing.T) {
-	testPaginator := &paginatorStruct{table: &tui.Table{}}
-	cases := []struct {
-		lastTwoSelected   []int
-		newTwoSelected    []int
-		currDataIdx       int
-		newDataIdx        int
-		selectedTableItem int
-	}{
-		{[]int{1, 2}, []int{2, 3}, 100, 101, 3}, // last two were: 1, 2 and going to 3.
-		{[]int{1, 2}, []int{2, 1}, 100, 99, 1},  // last two were: 1, 2 and goind to 1 again.
+func TestRenderSuccedsWithoutAlbums(t *testing.T) {
+	fetcherMock := &AlbumFetcherMock{callConfigs: []CallConfig{{returnValue: &spotify.SavedAlbumPage{}}}}
+	albumList := newEmptyAlbumList(&DebugClient{UserAlbumFetcher: fetcherMock}, nil)
+	if err := albumList.render(); err != nil {
+		t.Fatalf("Did not expect to fail but it did with %#v", err)
 	}
-	for _, c := range cases {
-		testPaginator.lastTwoSelected = c.lastTwoSelected
-		testPaginator.currDataIdx = c.currDataIdx
-		testPaginator.table.Select(c.selectedTableItem)
-
-		testPaginator.updateIndexes()
-
-		if testPaginator.currDataIdx != c.newDataIdx {
-			t.Fatalf("Expected new data index to be %d, have %d", c.newDataIdx, testPaginator.currDataIdx)
-		}
-		if !reflect.DeepEqual(testPaginator.lastTwoSelected, c.newTwoSelected) {
-			t.Fatalf("Expected new last two selected to be %#v, have %#v", c.newTwoSelected, testPaginator.lastTwoSelected)
-		}
+	if albumList.selectedAlbumIdx() != -1 {
+		t.Fatalf("Expected no album to be selected, got %d", albumList.selectedAlbumIdx())
 	}
 }
 
-type fakePaginatorStruct struct {
-	nextPageReturnValue      bool
-	previousPageReturnValue  bool
-	currDataIdx              int
-	updateIndexesCalled      bool
-	lastTwoSelectedArguments interface{}
-}
-
-func (fake *fakePaginatorStruct) nextPage() bool {
-	return fake.nextPageReturnValue
-}
-
-func (fake *fakePaginatorStruct) previousPage() bool {
-	return fake.previousPageReturnValue
-}
-
-func (fake *fakePaginatorStruct) updateIndexes()      { fake.updateIndexesCalled = true }
-func (fake *fakePaginatorStruct) getCurrDataIdx() int { return fake.currDataIdx }
-func (fake *fakePaginatorStruct) setLastTwoSelected(lastTwo []int) {
-	fake.lastTwoSelectedArguments = lastTwo
-}
-
-func TestOnSelectionChangeUpdatesIndexesWhenNoPageChange(t *testing.T) {
-	fakePaginator := &fakePaginatorStruct{nextPageReturnValue: false, previousPageReturnValue: false}
-	albumList := &AlbumList{pagination: fakePaginator}
-	callback := albumList.onSelectedChanged()
-	callback(&tui.Table{})
-	if fakePaginator.updateIndexesCalled == false {
-		t.Logf("Expected updateIndexes() to be called, but it did not")
+func TestScrollAlbumList(t *testing.T) {
+	client := NewDebugClient()
+	sideBar, err := NewSideBar(client, NewLibrary(client))
+	if err != nil {
+		t.Fatalf("Unexpected error occured: %s", err)
 	}
-}
-
-func TestOnSelectionChangeError(t *testing.T) {
-	var str bytes.Buffer
-	log.SetOutput(&str)
+	albumList := sideBar.AlbumList
+	albumList.Table.SetFocused(true)
 
 	cases := []struct {
-		fakePaginator pagination
-		expectedLog   string
+		key              tui.KeyEvent
+		expectedAlbumIdx int
+		expectedOffset   int
 	}{
-		{
-			// Error when fetching next page
-			fakePaginator: &fakePaginatorStruct{nextPageReturnValue: true, previousPageReturnValue: false},
-			expectedLog:   "Could not render next page of albums with error\n",
-		},
-		{
-			// Error when fetching previous page
-			fakePaginator: &fakePaginatorStruct{nextPageReturnValue: false, previousPageReturnValue: true},
-			expectedLog:   "Could not render previous page of albums with error\n",
-		},
+		{key: tui.KeyEvent{Key: tui.KeyUp}, expectedAlbumIdx: 0, expectedOffset: 0},
+		{key: tui.KeyEvent{Key: tui.KeyDown}, expectedAlbumIdx: 1, expectedOffset: 0},
+		{key: tui.KeyEvent{Key: tui.KeyPgDn}, expectedAlbumIdx: 46, expectedOffset: 2},
+		{key: tui.KeyEvent{Key: tui.KeyPgDn}, expectedAlbumIdx: 91, expectedOffset: 47},
+		{key: tui.KeyEvent{Key: tui.KeyUp}, expectedAlbumIdx: 90, expectedOffset: 47},
+		{key: tui.KeyEvent{Key: tui.KeyEnd}, expectedAlbumIdx: 134, expectedOffset: 90},
+		{key: tui.KeyEvent{Key: tui.KeyDown}, expectedAlbumIdx: 134, expectedOffset: 90},
+		{key: tui.KeyEvent{Key: tui.KeyPgUp}, expectedAlbumIdx: 89, expectedOffset: 89},
+		{key: tui.KeyEvent{Key: tui.KeyHome}, expectedAlbumIdx: 0, expectedOffset: 0},
 	}
-
 	for _, c := range cases {
-		albumList := &AlbumList{
-			pagination:   c.fakePaginator,
-			pageRenderer: &fakePageRenderer{ExecutionError: true},
+		albumList.list.OnKeyEvent(c.key)
+		if idx := albumList.selectedAlbumIdx(); idx != c.expectedAlbumIdx {
+			t.Errorf("Expected album %d to be selected after %s, got %d", c.expectedAlbumIdx, c.key.Name(), idx)
 		}
-		callback := albumList.onSelectedChanged()
-		callback(&tui.Table{})
-
-		if !strings.HasSuffix(str.String(), c.expectedLog) {
-			t.Errorf("Expect log to have %s message, but log was %s", c.expectedLog, str.String())
+		if albumList.list.offset != c.expectedOffset {
+			t.Errorf("Expected albums from %d to be displayed after %s, got %d", c.expectedOffset, c.key.Name(), albumList.list.offset)
 		}
-		// Should also test, that item on table is selected
-	}
-}
-
-func TestOnSelectionChangeSuccess(t *testing.T) {
-	cases := []struct {
-		fakePaginator                     *fakePaginatorStruct
-		expectedSetLastTwoSelectedIndexes []int
-	}{
-		{
-			fakePaginator:                     &fakePaginatorStruct{nextPageReturnValue: true, previousPageReturnValue: false},
-			expectedSetLastTwoSelectedIndexes: []int{-1, -1},
-		},
-		{
-			fakePaginator:                     &fakePaginatorStruct{nextPageReturnValue: false, previousPageReturnValue: true},
-			expectedSetLastTwoSelectedIndexes: []int{47, 46},
-		},
-	}
-	for _, c := range cases {
-		albumList := &AlbumList{
-			pagination:   c.fakePaginator,
-			pageRenderer: &fakePageRenderer{},
-		}
-		callback := albumList.onSelectedChanged()
-		callback(&tui.Table{})
-		if !reflect.DeepEqual(c.fakePaginator.lastTwoSelectedArguments, c.expectedSetLastTwoSelectedIndexes) {
-			t.Fatalf("Expected argument of SetLastTwoSelcted() to be %v, but it was %v", c.expectedSetLastTwoSelectedIndexes, c.fakePaginator.lastTwoSelectedArguments)
+		// header is the first row of the table
+		if selected := albumList.Table.Selected(); selected != c.expectedAlbumIdx-c.expectedOffset+1 {
+			t.Errorf("Expected row %d of the table to be selected after %s, got %d", c.expectedAlbumIdx-c.expectedOffset+1, c.key.Name(), selected)
 		}
 	}
 }
@@ -402,11 +202,9 @@ func TestAlbumsOnItemActivatedCallback(t *testing.T) {
 	log.SetOutput(&str)
 
 	client := &DebugClient{}
-	albumList := &AlbumList{
-		client:             client,
-		pagination:         &fakePaginatorStruct{currDataIdx: 2},
-		albumsDescriptions: []albumDescription{{uri: "any"}, {uri: "any"}, {uri: "any"}},
-	}
+	albumList := newEmptyAlbumList(client, nil)
+	albumList.albumsDescriptions = []albumDescription{{uri: "any"}, {uri: "any"}, {uri: "any"}}
+	albumList.list.reset()
 	callback := albumList.onItemActivaed()
 
 	cases := []struct {
@@ -459,3 +257,100 @@ func TestTrimCommasIfTooLong(t *testing.T) {
 		}
 	}
 }
+
+func TestTrimCommasIfTooLongWithWideCharacters(t *testing.T) {
+	cases := []struct {
+		text           string
+		length         int
+		expectedResult string
+	}{
+		{"ショパン", 8, "ショパン"},
+		{"ショパン", 7, "ショパ..."},
+		{"Björk", 5, "Björk"},
+		{"Björk", 4, "Björ..."},
+	}
+	for _, c := range cases {
+		if result := trimWithCommasIfTooLong(c.text, c.length); result != c.expectedResult {
+			t.Errorf("Expected %q trimmed to %d to be %q, but it was %q", c.text, c.length, c.expectedResult, result)
+		}
+	}
+}
+
+type FakeAlbumLibrary struct {
+	DebugAlbumLibrary
+	removeError bool
+	removeCalls int
+}
+
+func (fake *FakeAlbumLibrary) RemoveAlbumsFromLibrary(ids ...spotify.ID) error {
+	fake.removeCalls++
+	if fake.removeError {
+		return fmt.Errorf("error")
+	}
+	return nil
+}
+
+func TestRemoveAlbumRequiresConfirmation(t *testing.T) {
+	cases := []struct {
+		confirmKey          string
+		removeError         bool
+		expectedRemoveCalls int
+		expectedAlbums      int
+	}{
+		{confirmKey: "y", expectedRemoveCalls: 1, expectedAlbums: 134},
+		{confirmKey: "n", expectedRemoveCalls: 0, expectedAlbums: 135},
+		{confirmKey: "y", removeError: true, expectedRemoveCalls: 1, expectedAlbums: 135}, // rolled back
+	}
+	for _, c := range cases {
+		fakeAlbumLibrary := &FakeAlbumLibrary{removeError: c.removeError}
+		client := NewDebugClient().(DebugClient)
+		client.AlbumLibrary = fakeAlbumLibrary
+		library := NewLibrary(client)
+		updates, updated := make(chan func()), false
+		library.SetUpdater(func(fn func()) { updates <- func() { fn(); updated = true } })
+		sideBar, err := NewSideBar(client, library)
+		if err != nil {
+			t.Fatalf("Unexpected error occured: %s", err)
+		}
+		albumList := sideBar.AlbumList
+		albumList.list.selectRow(1)
+
+		albumList.onRemoveRequested()(albumList.Table)
+		if albumList.pendingRemoval == nil || albumList.pendingRemoval.uri != "spotify:album:savedalbum2" {
+			t.Fatalf("Expected second album to wait for removal, got %v", albumList.pendingRemoval)
+		}
+		albumList.list.actions[c.confirmKey](albumList.Table)
+		if c.expectedRemoveCalls > 0 {
+			waitForUpdates(t, updates, func() bool { return updated })
+		}
+
+		if fakeAlbumLibrary.removeCalls != c.expectedRemoveCalls {
+			t.Errorf("Expected RemoveAlbumsFromLibrary() to be called %d times, was called %d times", c.expectedRemoveCalls, fakeAlbumLibrary.removeCalls)
+		}
+		if len(albumList.albumsDescriptions) != c.expectedAlbums {
+			t.Errorf("Expected to have %d albums, have %d", c.expectedAlbums, len(albumList.albumsDescriptions))
+		}
+		if c.expectedAlbums == 135 && albumList.albumsDescriptions[1].uri != "spotify:album:savedalbum2" {
+			t.Errorf("Expected album to stay at the same place, but it did not")
+		}
+		if albumList.pendingRemoval != nil {
+			t.Errorf("Expected nothing to wait for removal after confirmation")
+		}
+	}
+}
+
+func TestSavedAlbumIsAddedToAlbumList(t *testing.T) {
+	client := NewDebugClient()
+	library := NewLibrary(client)
+	sideBar, _ := NewSideBar(client, library)
+
+	errs := make(chan error)
+	library.albums.set(libraryItem{id: "new", uri: "spotify:album:new", name: "New Album", artist: "New Artist"}, true, func(err error) { errs <- err })
+	if err := <-errs; err != nil {
+		t.Fatalf("Did not expect to fail, but it did with %s", err)
+	}
+	albums := sideBar.AlbumList.albumsDescriptions
+	if len(albums) != 136 || albums[0].uri != "spotify:album:new" {
+		t.Fatalf("Expected saved album to be added on top of album list")
+	}
+}
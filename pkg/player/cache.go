@@ -0,0 +1,328 @@
+package player
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/zmb3/spotify"
+)
+
+// libraryCacheVersion is the version of the format in which library is
+// cached. Cache in an older format is upgraded with cacheMigrations.
+const libraryCacheVersion = 1
+
+// cacheMigrations upgrade cache in the format of the version which is their
+// key to the format of the next version. Cache which can not be upgraded is
+// dropped, it is fetched from Spotify again.
+var cacheMigrations = map[int]func(map[string]json.RawMessage) error{}
+
+// LibraryCache keeps saved albums, playlists and liked songs between runs, so
+// that they are displayed at once when the app starts, and only what changed
+// since then is fetched from Spotify. It is saved after every change.
+type LibraryCache struct {
+	Version int           `json:"version"`
+	Albums  []cachedAlbum `json:"albums,omitempty"`
+	// AlbumsCached tells whether albums were cached, as user may have none.
+	AlbumsCached bool          `json:"albums_cached"`
+	Tracks       []cachedTrack `json:"tracks,omitempty"`
+	// TracksTotal is the number of all liked songs, only the ones which
+	// user has seen are cached.
+	TracksTotal  int  `json:"tracks_total"`
+	TracksCached bool `json:"tracks_cached"`
+
+	Playlists []cachedPlaylist `json:"playlists,omitempty"`
+	// PlaylistsCached tells whether playlists were cached, as user may
+	// have none.
+	PlaylistsCached bool `json:"playlists_cached"`
+	// Pending are changes of the library which were made while Spotify
+	// was not reachable.
+	Pending []pendingChange `json:"pending,omitempty"`
+
+	path string
+	// mu guards cache, as pending changes are made in background.
+	mu sync.Mutex
+}
+
+type cachedAlbum struct {
+	URI         spotify.URI   `json:"uri"`
+	Title       string        `json:"title"`
+	Artist      string        `json:"artist"`
+	AddedAt     time.Time     `json:"added_at"`
+	ReleaseDate time.Time     `json:"release_date"`
+	Runtime     time.Duration `json:"runtime"`
+}
+
+type cachedTrack struct {
+	ID     spotify.ID  `json:"id"`
+	URI    spotify.URI `json:"uri"`
+	Name   string      `json:"name"`
+	Artist string      `json:"artist"`
+	Album  string      `json:"album"`
+}
+
+type cachedPlaylist struct {
+	URI    spotify.URI `json:"uri"`
+	Name   string      `json:"name"`
+	Owner  string      `json:"owner"`
+	Tracks int         `json:"tracks"`
+}
+
+// LoadLibraryCache reads cache from the file, cache is empty when there is
+// no such file yet, or when it can not be read in the current format.
+func LoadLibraryCache(path string) (*LibraryCache, error) {
+	cache := &LibraryCache{Version: libraryCacheVersion, path: path}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read library cache: %v", err)
+	}
+	data, err = migrateLibraryCache(data)
+	if err != nil {
+		log.Printf("Dropping library cache %s: %s", path, err)
+		return cache, nil
+	}
+	if err := json.Unmarshal(data, cache); err != nil {
+		log.Printf("Dropping library cache %s: %s", path, err)
+		return &LibraryCache{Version: libraryCacheVersion, path: path}, nil
+	}
+	return cache, nil
+}
+
+// migrateLibraryCache upgrades cached data to the current format.
+func migrateLibraryCache(data []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("could not parse: %v", err)
+	}
+	var version int
+	if err := json.Unmarshal(fields["version"], &version); err != nil {
+		return nil, fmt.Errorf("unknown version: %v", err)
+	}
+	if version > libraryCacheVersion {
+		return nil, fmt.Errorf("version %d is newer than %d", version, libraryCacheVersion)
+	}
+	for ; version < libraryCacheVersion; version++ {
+		migrate, ok := cacheMigrations[version]
+		if !ok {
+			return nil, fmt.Errorf("version %d can not be upgraded", version)
+		}
+		if err := migrate(fields); err != nil {
+			return nil, fmt.Errorf("could not upgrade version %d: %v", version, err)
+		}
+	}
+	fields["version"] = json.RawMessage(fmt.Sprint(libraryCacheVersion))
+	return json.Marshal(fields)
+}
+
+// save writes cache, previous cache is kept when writing fails. It is
+// called with mu locked.
+func (c *LibraryCache) save() error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomically(c.path, data); err != nil {
+		return fmt.Errorf("could not save library cache: %v", err)
+	}
+	return nil
+}
+
+// Clear forgets cached library, so that it is fetched again from Spotify.
+// Pending changes of the library are kept.
+func (c *LibraryCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Albums, c.AlbumsCached = nil, false
+	c.Tracks, c.TracksTotal, c.TracksCached = nil, 0, false
+	c.Playlists, c.PlaylistsCached = nil, false
+	return c.save()
+}
+
+// albums returns cached albums, ordered from the most recently added, it
+// returns false when albums were not cached. Cache may be nil.
+func (c *LibraryCache) albums() ([]albumDescription, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.AlbumsCached {
+		return nil, false
+	}
+	albums := make([]albumDescription, 0, len(c.Albums))
+	for _, album := range c.Albums {
+		albums = append(albums, albumDescription{
+			uri:         album.URI,
+			title:       album.Title,
+			artist:      album.Artist,
+			addedAt:     album.AddedAt,
+			releaseDate: album.ReleaseDate,
+			runtime:     album.Runtime,
+		})
+	}
+	return albums, true
+}
+
+func (c *LibraryCache) setAlbums(albums []albumDescription) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	albums = arrangeAlbums(albums, sortByAdded, false)
+	c.Albums = make([]cachedAlbum, 0, len(albums))
+	for _, album := range albums {
+		c.Albums = append(c.Albums, cachedAlbum{
+			URI:         album.uri,
+			Title:       album.title,
+			Artist:      album.artist,
+			AddedAt:     album.addedAt,
+			ReleaseDate: album.releaseDate,
+			Runtime:     album.runtime,
+		})
+	}
+	c.AlbumsCached = true
+	return c.save()
+}
+
+// tracks returns cached liked songs, ordered from the most recently added,
+// and the number of all of them. It returns false when they were not cached.
+func (c *LibraryCache) tracks() ([]trackDescription, int, bool) {
+	if c == nil {
+		return nil, 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.TracksCached {
+		return nil, 0, false
+	}
+	tracks := make([]trackDescription, 0, len(c.Tracks))
+	for _, track := range c.Tracks {
+		tracks = append(tracks, trackDescription{
+			id:     track.ID,
+			uri:    track.URI,
+			name:   track.Name,
+			artist: track.Artist,
+			album:  track.Album,
+		})
+	}
+	return tracks, c.TracksTotal, true
+}
+
+func (c *LibraryCache) setTracks(tracks []trackDescription, total int) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Tracks = make([]cachedTrack, 0, len(tracks))
+	for _, track := range tracks {
+		c.Tracks = append(c.Tracks, cachedTrack{
+			ID:     track.id,
+			URI:    track.uri,
+			Name:   track.name,
+			Artist: track.artist,
+			Album:  track.album,
+		})
+	}
+	c.TracksTotal = total
+	c.TracksCached = true
+	return c.save()
+}
+
+// playlists returns cached playlists in the order in which user arranged
+// them, it returns false when playlists were not cached. Cache may be nil.
+func (c *LibraryCache) playlists() ([]playlistDescription, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.PlaylistsCached {
+		return nil, false
+	}
+	playlists := make([]playlistDescription, 0, len(c.Playlists))
+	for _, playlist := range c.Playlists {
+		playlists = append(playlists, playlistDescription{
+			uri:    playlist.URI,
+			name:   playlist.Name,
+			owner:  playlist.Owner,
+			tracks: playlist.Tracks,
+		})
+	}
+	return playlists, true
+}
+
+func (c *LibraryCache) setPlaylists(playlists []playlistDescription) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Playlists = make([]cachedPlaylist, 0, len(playlists))
+	for _, playlist := range playlists {
+		c.Playlists = append(c.Playlists, cachedPlaylist{
+			URI:    playlist.uri,
+			Name:   playlist.name,
+			Owner:  playlist.owner,
+			Tracks: playlist.tracks,
+		})
+	}
+	c.PlaylistsCached = true
+	return c.save()
+}
+
+// pendingChanges returns changes of the library which were not made yet.
+func (c *LibraryCache) pendingChanges() []pendingChange {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]pendingChange{}, c.Pending...)
+}
+
+func (c *LibraryCache) setPendingChanges(changes []pendingChange) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Pending = append([]pendingChange{}, changes...)
+	return c.save()
+}
+
+// addedSince returns how many items at the beginning of the first page were
+// added since items were cached, when adding them is the only change. Items
+// are ordered from the most recently added, cachedTotal is the number of all
+// items when they were cached and total is the current number of them. It
+// returns false when items were also removed, or so many were added that the
+// first page does not reach cached ones, then all of them have to be fetched.
+func addedSince(cached, firstPage []spotify.URI, cachedTotal, total int) (int, bool) {
+	added := len(firstPage)
+	if len(cached) > 0 {
+		added = -1
+		for i, uri := range firstPage {
+			if uri == cached[0] {
+				added = i
+				break
+			}
+		}
+	}
+	if added < 0 || total != cachedTotal+added {
+		return 0, false
+	}
+	for i := added; i < len(firstPage) && i-added < len(cached); i++ {
+		if firstPage[i] != cached[i-added] {
+			return 0, false
+		}
+	}
+	return added, true
+}
@@ -0,0 +1,155 @@
+package player
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zmb3/spotify"
+)
+
+func newTempCache(t *testing.T) (*LibraryCache, func()) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := LoadLibraryCache(filepath.Join(dir, "library.json"))
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	return cache, func() { os.RemoveAll(dir) }
+}
+
+func TestLibraryCacheIsSavedAndLoaded(t *testing.T) {
+	cache, cleanup := newTempCache(t)
+	defer cleanup()
+	if _, ok := cache.albums(); ok {
+		t.Fatalf("Expected albums not to be cached yet")
+	}
+
+	albums := []albumDescription{}
+	for _, album := range constructNSpotifySavedAlbums(3) {
+		albums = append(albums, savedAlbumDescription(album))
+	}
+	albums[0], albums[2] = albums[2], albums[0]
+	if err := cache.setAlbums(albums); err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	if err := cache.setTracks(savedTrackDescriptions(constructNSpotifySavedTracks(2)), 10); err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	playlist := playlistDescription{uri: "spotify:playlist:1", name: "Mix", owner: "User", tracks: 3}
+	if err := cache.setPlaylists([]playlistDescription{playlist}); err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+
+	loaded, err := LoadLibraryCache(cache.path)
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	cachedAlbums, ok := loaded.albums()
+	if !ok || len(cachedAlbums) != 3 {
+		t.Fatalf("Expected 3 albums to be cached, got %v", cachedAlbums)
+	}
+	if cachedAlbums[0].uri != "spotify:album:savedalbum1" || !cachedAlbums[0].addedAt.Equal(albums[2].addedAt) || cachedAlbums[0].runtime != albums[2].runtime {
+		t.Errorf("Expected albums to be cached from the most recently added, got %+v", cachedAlbums[0])
+	}
+	tracks, total, ok := loaded.tracks()
+	if !ok || len(tracks) != 2 || total != 10 || tracks[1].name != "Song Name 2" || tracks[1].artist == "" {
+		t.Errorf("Expected 2 of 10 tracks to be cached, got %d of %d", len(tracks), total)
+	}
+	if playlists, ok := loaded.playlists(); !ok || len(playlists) != 1 || playlists[0] != playlist {
+		t.Errorf("Expected playlist to be cached, got %v", playlists)
+	}
+
+	if err := loaded.Clear(); err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	cleared, _ := LoadLibraryCache(cache.path)
+	if _, ok := cleared.albums(); ok {
+		t.Errorf("Expected albums to be dropped from cleared cache")
+	}
+	if _, _, ok := cleared.tracks(); ok {
+		t.Errorf("Expected tracks to be dropped from cleared cache")
+	}
+	if _, ok := cleared.playlists(); ok {
+		t.Errorf("Expected playlists to be dropped from cleared cache")
+	}
+}
+
+func TestLibraryCacheMigration(t *testing.T) {
+	cacheMigrations[0] = func(fields map[string]json.RawMessage) error {
+		fields["tracks_total"] = fields["total"]
+		delete(fields, "total")
+		return nil
+	}
+	defer delete(cacheMigrations, 0)
+
+	cases := []struct {
+		cache         string
+		expectedTotal int
+		expectedOk    bool
+	}{
+		{cache: `{"version": 0, "tracks_cached": true, "total": 7}`, expectedTotal: 7, expectedOk: true},
+		{cache: `{"version": 1, "tracks_cached": true, "tracks_total": 5}`, expectedTotal: 5, expectedOk: true},
+		{cache: `{"version": -1, "tracks_cached": true, "total": 7}`},
+		{cache: `{"version": 2, "tracks_cached": true, "tracks_total": 5}`},
+		{cache: `{"tracks_cached": true, "tracks_total": 5}`},
+		{cache: `{"version": 1, "tracks_cached": true, "tracks_total": "5"}`},
+		{cache: `not json`},
+	}
+	for _, c := range cases {
+		cache, cleanup := newTempCache(t)
+		if err := ioutil.WriteFile(cache.path, []byte(c.cache), 0600); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadLibraryCache(cache.path)
+		if err != nil {
+			t.Errorf("Expected cache %s to be dropped instead of failing, got %s", c.cache, err)
+		}
+		_, total, ok := loaded.tracks()
+		if ok != c.expectedOk || total != c.expectedTotal {
+			t.Errorf("Expected cache %s to have %d tracks (%t), got %d (%t)", c.cache, c.expectedTotal, c.expectedOk, total, ok)
+		}
+		if loaded.Version != libraryCacheVersion {
+			t.Errorf("Expected cache to be in version %d, got %d", libraryCacheVersion, loaded.Version)
+		}
+		cleanup()
+	}
+}
+
+func TestAddedSince(t *testing.T) {
+	uris := func(ids ...string) []spotify.URI {
+		uris := []spotify.URI{}
+		for _, id := range ids {
+			uris = append(uris, spotify.URI(id))
+		}
+		return uris
+	}
+	cases := []struct {
+		name          string
+		cached        []spotify.URI
+		firstPage     []spotify.URI
+		cachedTotal   int
+		total         int
+		expectedAdded int
+		expectedOk    bool
+	}{
+		{name: "unchanged", cached: uris("a", "b", "c"), firstPage: uris("a", "b"), cachedTotal: 3, total: 3, expectedOk: true},
+		{name: "added", cached: uris("a", "b", "c"), firstPage: uris("x", "a"), cachedTotal: 3, total: 4, expectedAdded: 1, expectedOk: true},
+		{name: "only some cached", cached: uris("a"), firstPage: uris("x", "y", "a"), cachedTotal: 10, total: 12, expectedAdded: 2, expectedOk: true},
+		{name: "nothing cached", cached: uris(), firstPage: uris("x"), cachedTotal: 0, total: 1, expectedAdded: 1, expectedOk: true},
+		{name: "first removed", cached: uris("a", "b", "c"), firstPage: uris("b", "c"), cachedTotal: 3, total: 2},
+		{name: "later removed", cached: uris("a", "b", "c"), firstPage: uris("a", "b"), cachedTotal: 3, total: 2},
+		{name: "added and removed", cached: uris("a", "b", "c"), firstPage: uris("x", "a", "c"), cachedTotal: 3, total: 3},
+		{name: "too many added", cached: uris("a", "b"), firstPage: uris("x", "y"), cachedTotal: 2, total: 5},
+	}
+	for _, c := range cases {
+		added, ok := addedSince(c.cached, c.firstPage, c.cachedTotal, c.total)
+		if added != c.expectedAdded || ok != c.expectedOk {
+			t.Errorf("%s: expected %d added (%t), got %d (%t)", c.name, c.expectedAdded, c.expectedOk, added, ok)
+		}
+	}
+}
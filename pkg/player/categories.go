@@ -0,0 +1,147 @@
+package player
+
+import (
+	"fmt"
+	"strings"
+
+	tui "github.com/marcusolsson/tui-go"
+	"github.com/zmb3/spotify"
+)
+
+// SearchCategories are names of all categories of search results.
+var SearchCategories = []string{"songs", "albums", "artists", "playlists", "shows", "episodes"}
+
+// DefaultSearchCategories are names of categories which are displayed
+// when no categories are configured.
+var DefaultSearchCategories = []string{"songs", "albums", "artists"}
+
+// searchCategory is a kind of searched items with box displaying them.
+// Categories which are not enabled are neither displayed nor searched for.
+type searchCategory struct {
+	name    string
+	title   string
+	results *searchResults
+	enabled bool
+
+	// searchType is set for categories which spotify library searches for,
+	// the remaining ones are podcasts.
+	searchType spotify.SearchType
+}
+
+func newSearchCategories(client SpotifyClient, library *Library) []*searchCategory {
+	categories := []*searchCategory{
+		{name: "songs", title: "Songs", searchType: spotify.SearchTypeTrack,
+			results: newSavedSearchResults(client, "Songs", trackColumns, library.tracks, "l", heart)},
+		{name: "albums", title: "Albums", searchType: spotify.SearchTypeAlbum,
+			results: newSavedSearchResults(client, "Albums", albumColumns, library.albums, "l", heart)},
+		{name: "artists", title: "Artists", searchType: spotify.SearchTypeArtist,
+			results: newSavedSearchResults(client, "Artists", artistColumns, library.artists, "f", followMark)},
+		{name: "playlists", title: "Playlists", searchType: spotify.SearchTypePlaylist,
+			results: newSearchResults(client, "Playlists", playlistColumns)},
+		{name: "shows", title: "Shows",
+			results: newSearchResults(client, "Shows", showColumns)},
+		{name: "episodes", title: "Episodes",
+			results: newSearchResults(client, "Episodes", episodeColumns)},
+	}
+	for _, category := range categories {
+		if category.searchType != 0 {
+			category.results.fetchPage = searchPageFetcher(client, category.searchType)
+		} else {
+			category.results.fetchPage = podcastPageFetcher(client, strings.TrimSuffix(category.name, "s"))
+		}
+	}
+	return categories
+}
+
+// enableSearchCategories enables categories with given names, it fails
+// when there is no category with one of the names.
+func enableSearchCategories(categories []*searchCategory, names []string) error {
+	for _, name := range names {
+		category := findSearchCategory(categories, name)
+		if category == nil {
+			return fmt.Errorf("there is no %q search category, available are: %s", name, strings.Join(SearchCategories, ", "))
+		}
+		category.enabled = true
+	}
+	return nil
+}
+
+func findSearchCategory(categories []*searchCategory, name string) *searchCategory {
+	for _, category := range categories {
+		if category.name == name {
+			return category
+		}
+	}
+	return nil
+}
+
+// categoriesText lists categories with marks telling which of them are
+// enabled, together with keys toggling them.
+func categoriesText(categories []*searchCategory) string {
+	names := make([]string, 0, len(categories))
+	for i, category := range categories {
+		mark := " "
+		if category.enabled {
+			mark = "x"
+		}
+		names = append(names, fmt.Sprintf("[%s] %s (%s)", mark, category.title, categoryKey(i)))
+	}
+	return strings.Join(names, "  ")
+}
+
+func categoryKey(idx int) string {
+	return fmt.Sprintf("Alt+%d", idx+1)
+}
+
+// SetKeybindings binds Alt+1, Alt+2, ... keys to toggling consecutive
+// categories of search results, Ctrl+Space to completing filters and
+// Ctrl+P to pinning the typed query.
+func (s *Search) SetKeybindings(ui tui.UI) {
+	ui.SetKeybinding("Ctrl+Space", s.completeQuery)
+	ui.SetKeybinding("Ctrl+P", s.pinQuery)
+	for i := range s.categories {
+		idx := i
+		ui.SetKeybinding(categoryKey(idx), func() { s.toggleCategory(idx) })
+	}
+}
+
+// toggleCategory shows or hides category with given index. Category which
+// is shown is searched for the last query in background.
+func (s *Search) toggleCategory(idx int) {
+	if idx < 0 || idx >= len(s.categories) {
+		return
+	}
+	category := s.categories[idx]
+	category.enabled = !category.enabled
+	if category.enabled && s.query != "" {
+		query, update := s.query, s.update
+		go func() {
+			items, total, err := category.results.fetchPage(query, 0)
+			update(func() {
+				if !category.enabled || s.query != query {
+					return // results are stale
+				}
+				if err != nil {
+					s.reportError("Could not search for %s: %s", category.name, err)
+					failSearchResults(category.results, err)
+					return
+				}
+				fillSearchResults(category.results, query, items, total)
+			})
+		}()
+	}
+	s.renderCategories()
+}
+
+// renderCategories displays boxes of enabled categories.
+func (s *Search) renderCategories() {
+	for s.resultsBox.Length() > 0 {
+		s.resultsBox.Remove(0)
+	}
+	for _, category := range s.categories {
+		if category.enabled {
+			s.resultsBox.Append(category.results.getBox())
+		}
+	}
+	s.categoriesLabel.SetText(categoriesText(s.categories))
+}
@@ -0,0 +1,227 @@
+package player
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/zmb3/spotify"
+	"golang.org/x/oauth2"
+)
+
+const spotifyAPIBaseURL = "https://api.spotify.com/v1/"
+
+// Client is a SpotifyClient which talks with Spotify Web API. It wraps
+// spotify.Client and adds calls which are not supported by spotify library.
+type Client struct {
+	*spotify.Client
+	http *http.Client
+}
+
+// NewClient creates Client which is authorized with the same, automatically
+// refreshed, token as authenticated spotify.Client. All calls, also the ones
+// of spotify library, are sent with transport, which may be shared between
+// clients.
+func NewClient(client *spotify.Client, transport http.RoundTripper) *Client {
+	return newClient(clientTokenSource{client}, transport)
+}
+
+// newClient creates Client which sends requests authorized with tokens to
+// Web API with transport.
+func newClient(tokens oauth2.TokenSource, transport http.RoundTripper) *Client {
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: transport})
+	httpClient := oauth2.NewClient(ctx, tokens)
+	spotifyClient := spotify.NewClient(httpClient)
+	return &Client{
+		Client: &spotifyClient,
+		http:   httpClient,
+	}
+}
+
+// WithContext returns client which sends the same requests, but they are
+// cancelled when ctx is done.
+func (c *Client) WithContext(ctx context.Context) SpotifyClient {
+	httpClient := &http.Client{Transport: contextTransport{ctx: ctx, base: c.http.Transport}}
+	spotifyClient := spotify.NewClient(httpClient)
+	return &Client{
+		Client: &spotifyClient,
+		http:   httpClient,
+	}
+}
+
+// contextClient is a SpotifyClient whose requests can be cancelled.
+type contextClient interface {
+	WithContext(ctx context.Context) SpotifyClient
+}
+
+// withContext returns client whose requests are cancelled when ctx is done,
+// clients which do not support it, i.e. debug ones, are returned unchanged.
+func withContext(ctx context.Context, client SpotifyClient) SpotifyClient {
+	if c, ok := client.(contextClient); ok {
+		return c.WithContext(ctx)
+	}
+	return client
+}
+
+// contextTransport sends requests with base transport, or with
+// http.DefaultTransport when base is nil, they are cancelled when ctx is done.
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req.WithContext(t.ctx))
+}
+
+type clientTokenSource struct {
+	client *spotify.Client
+}
+
+func (s clientTokenSource) Token() (*oauth2.Token, error) {
+	return s.client.Token()
+}
+
+// UserHasAlbums checks if albums are saved in the current user's library.
+func (c *Client) UserHasAlbums(ids ...spotify.ID) ([]bool, error) {
+	var result []bool
+	err := c.do("GET", "me/albums/contains", idsQuery(ids), nil, &result)
+	return result, err
+}
+
+// AddAlbumsToLibrary saves albums to the current user's library.
+func (c *Client) AddAlbumsToLibrary(ids ...spotify.ID) error {
+	return c.do("PUT", "me/albums", idsQuery(ids), nil, nil)
+}
+
+// RemoveAlbumsFromLibrary removes albums from the current user's library.
+func (c *Client) RemoveAlbumsFromLibrary(ids ...spotify.ID) error {
+	return c.do("DELETE", "me/albums", idsQuery(ids), nil, nil)
+}
+
+// CurrentUsersShows returns page of shows saved in the current user's library.
+func (c *Client) CurrentUsersShows(limit, offset int) (*SavedShowPage, error) {
+	var result SavedShowPage
+	err := c.do("GET", "me/shows", pageQuery(limit, offset), nil, &result)
+	return &result, err
+}
+
+// GetShowEpisodes returns page of episodes of the show, with resume points
+// of the current user.
+func (c *Client) GetShowEpisodes(id spotify.ID, limit, offset int) (*EpisodePage, error) {
+	var result EpisodePage
+	err := c.do("GET", "shows/"+string(id)+"/episodes", pageQuery(limit, offset), nil, &result)
+	return &result, err
+}
+
+// SearchPodcasts searches for shows and episodes.
+func (c *Client) SearchPodcasts(query string, limit, offset int) (*PodcastSearchResult, error) {
+	var result PodcastSearchResult
+	values := pageQuery(limit, offset)
+	values.Set("q", query)
+	values.Set("type", "show,episode")
+	err := c.do("GET", "search", values, nil, &result)
+	return &result, err
+}
+
+// PlayEpisode starts playing the episode from given position.
+func (c *Client) PlayEpisode(uri spotify.URI, positionMs int) error {
+	body := struct {
+		URIs       []spotify.URI `json:"uris"`
+		PositionMs int           `json:"position_ms"`
+	}{[]spotify.URI{uri}, positionMs}
+	return c.do("PUT", "me/player/play", nil, body, nil)
+}
+
+// PlayerCurrentlyPlayingEpisode returns currently playing episode, it returns
+// nil when nothing or a track is playing.
+func (c *Client) PlayerCurrentlyPlayingEpisode() (*Episode, error) {
+	var result struct {
+		Type string   `json:"currently_playing_type"`
+		Item *Episode `json:"item"`
+	}
+	err := c.do("GET", "me/player/currently-playing", url.Values{"additional_types": []string{"episode"}}, nil, &result)
+	if err != nil || result.Type != "episode" {
+		return nil, err
+	}
+	return result.Item, nil
+}
+
+func pageQuery(limit, offset int) url.Values {
+	return url.Values{"limit": []string{strconv.Itoa(limit)}, "offset": []string{strconv.Itoa(offset)}}
+}
+
+func idsQuery(ids []spotify.ID) url.Values {
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, string(id))
+	}
+	return url.Values{"ids": []string{strings.Join(values, ",")}}
+}
+
+// do sends request to Spotify Web API endpoint, with body encoded as JSON
+// unless body is nil, and decodes JSON response into result, unless result is nil.
+func (c *Client) do(method, endpoint string, query url.Values, body, result interface{}) error {
+	spotifyURL := spotifyAPIBaseURL + endpoint
+	if len(query) > 0 {
+		spotifyURL += "?" + query.Encode()
+	}
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequest(method, spotifyURL, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return decodeError(resp.StatusCode, respBody)
+	}
+	if result == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.NewDecoder(bytes.NewReader(respBody)).Decode(result)
+}
+
+// decodeError converts error response of Spotify Web API into spotify.Error,
+// so errors are the same as the ones returned by spotify library.
+func decodeError(status int, body []byte) error {
+	var e struct {
+		E spotify.Error `json:"error"`
+	}
+	if err := json.Unmarshal(body, &e); err != nil || e.E.Message == "" {
+		return spotify.Error{
+			Status:  status,
+			Message: fmt.Sprintf("spotify: HTTP %d: %s", status, http.StatusText(status)),
+		}
+	}
+	return e.E
+}
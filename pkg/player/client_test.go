@@ -0,0 +1,197 @@
+package player
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/zmb3/spotify"
+)
+
+func newTestClient(handler http.HandlerFunc) (*Client, func()) {
+	server := httptest.NewServer(handler)
+	client := &Client{http: &http.Client{Transport: redirectTransport{base: server.Client().Transport, baseURL: server.URL + "/"}}}
+	return client, server.Close
+}
+
+func TestClientUserHasAlbums(t *testing.T) {
+	client, closeServer := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/me/albums/contains" || r.URL.Query().Get("ids") != "a,b" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, "[true, false]")
+	})
+	defer closeServer()
+
+	saved, err := client.UserHasAlbums("a", "b")
+	if err != nil {
+		t.Fatalf("Did not expect to fail, but it did with %s", err)
+	}
+	if !reflect.DeepEqual(saved, []bool{true, false}) {
+		t.Fatalf("Expected [true false], got %v", saved)
+	}
+}
+
+func TestClientModifiesAlbumLibrary(t *testing.T) {
+	cases := []struct {
+		call           func(*Client) error
+		expectedMethod string
+	}{
+		{func(c *Client) error { return c.AddAlbumsToLibrary("a") }, "PUT"},
+		{func(c *Client) error { return c.RemoveAlbumsFromLibrary("a") }, "DELETE"},
+	}
+	for _, c := range cases {
+		var gotMethod string
+		client, closeServer := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+			gotMethod = r.Method
+		})
+		err := c.call(client)
+		closeServer()
+		if err != nil {
+			t.Fatalf("Did not expect to fail, but it did with %s", err)
+		}
+		if gotMethod != c.expectedMethod {
+			t.Errorf("Expected %s request, got %s", c.expectedMethod, gotMethod)
+		}
+	}
+}
+
+func TestClientDecodesErrors(t *testing.T) {
+	cases := []struct {
+		body            string
+		expectedMessage string
+	}{
+		{`{"error": {"status": 403, "message": "Insufficient client scope"}}`, "Insufficient client scope"},
+		{``, "spotify: HTTP 403: Forbidden"},
+	}
+	for _, c := range cases {
+		body := c.body
+		client, closeServer := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, body)
+		})
+		err := client.AddAlbumsToLibrary("a")
+		closeServer()
+
+		spotifyErr, ok := err.(spotify.Error)
+		if !ok {
+			t.Fatalf("Expected spotify.Error, got %#v", err)
+		}
+		if spotifyErr.Message != c.expectedMessage || spotifyErr.Status != http.StatusForbidden {
+			t.Errorf("Expected error %q with status 403, got %q with %d", c.expectedMessage, spotifyErr.Message, spotifyErr.Status)
+		}
+	}
+}
+
+func TestClientGetShowEpisodes(t *testing.T) {
+	client, closeServer := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/shows/show1/episodes" || r.URL.Query().Get("limit") != "10" || r.URL.Query().Get("offset") != "20" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"total": 21, "items": [{"name": "Episode", "duration_ms": 1000, "resume_point": {"fully_played": false, "resume_position_ms": 500}}]}`)
+	})
+	defer closeServer()
+
+	page, err := client.GetShowEpisodes("show1", 10, 20)
+	if err != nil {
+		t.Fatalf("Did not expect to fail, but it did with %s", err)
+	}
+	if page.Total != 21 || len(page.Items) != 1 || page.Items[0].progress() != "50%" {
+		t.Fatalf("Unexpected page of episodes %#v", page)
+	}
+}
+
+func TestClientPlayEpisode(t *testing.T) {
+	var gotBody string
+	client, closeServer := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer closeServer()
+
+	err := client.PlayEpisode("spotify:episode:1", 500)
+	if err != nil {
+		t.Fatalf("Did not expect to fail, but it did with %s", err)
+	}
+	expected := `{"uris":["spotify:episode:1"],"position_ms":500}`
+	if gotBody != expected {
+		t.Fatalf("Expected body %s, got %s", expected, gotBody)
+	}
+}
+
+func TestClientPlayerCurrentlyPlayingEpisode(t *testing.T) {
+	cases := []struct {
+		body         string
+		expectedName string
+	}{
+		{`{"currently_playing_type": "episode", "item": {"name": "Episode"}}`, "Episode"},
+		{`{"currently_playing_type": "track", "item": {"name": "Track"}}`, ""},
+		{``, ""}, // nothing is playing
+	}
+	for _, c := range cases {
+		body := c.body
+		client, closeServer := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("additional_types") != "episode" {
+				http.Error(w, "unexpected request", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, body)
+		})
+		episode, err := client.PlayerCurrentlyPlayingEpisode()
+		closeServer()
+		if err != nil {
+			t.Fatalf("Did not expect to fail, but it did with %s", err)
+		}
+		name := ""
+		if episode != nil {
+			name = episode.Name
+		}
+		if name != c.expectedName {
+			t.Errorf("Expected episode %q, got %q", c.expectedName, name)
+		}
+	}
+}
+
+func TestClientWithContextCancelsRequests(t *testing.T) {
+	cases := []struct {
+		name string
+		call func(SpotifyClient) error
+	}{
+		{"search", func(c SpotifyClient) error {
+			_, err := c.SearchOpt("query", spotify.SearchTypeTrack, nil)
+			return err
+		}},
+		{"podcasts search", func(c SpotifyClient) error {
+			_, err := c.SearchPodcasts("query", 10, 0)
+			return err
+		}},
+	}
+	for _, c := range cases {
+		requests := 0
+		client, closeServer := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			fmt.Fprint(w, "{}")
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancelled := client.WithContext(ctx)
+		if err := c.call(cancelled); err != nil {
+			t.Fatalf("Expected %s to succeed before it is cancelled, got %s", c.name, err)
+		}
+		cancel()
+		err := c.call(cancelled)
+		closeServer()
+		if err == nil || isConnectionError(err) {
+			t.Errorf("Expected cancelled %s to fail, but not as offline, got %v", c.name, err)
+		}
+		if requests != 1 {
+			t.Errorf("Expected cancelled %s not to be sent, got %d requests", c.name, requests)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+// Code generated by gen_connected_client.go; DO NOT EDIT.
+
+package player
+
+import "github.com/zmb3/spotify"
+
+// CurrentUsersAlbumsOpt calls CurrentUsersAlbumsOpt of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) CurrentUsersAlbumsOpt(opt *spotify.Options) (*spotify.SavedAlbumPage, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.CurrentUsersAlbumsOpt(opt)
+	return result, c.observe(err)
+}
+
+// Play calls Play of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) Play() error {
+	client, err := c.current()
+	if err != nil {
+		return err
+	}
+	return c.observe(client.Play())
+}
+
+// PlayOpt calls PlayOpt of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) PlayOpt(opt *spotify.PlayOptions) error {
+	client, err := c.current()
+	if err != nil {
+		return err
+	}
+	return c.observe(client.PlayOpt(opt))
+}
+
+// Search calls Search of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) Search(query string, t spotify.SearchType) (*spotify.SearchResult, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.Search(query, t)
+	return result, c.observe(err)
+}
+
+// SearchOpt calls SearchOpt of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) SearchOpt(query string, t spotify.SearchType, opt *spotify.Options) (*spotify.SearchResult, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.SearchOpt(query, t, opt)
+	return result, c.observe(err)
+}
+
+// GetAlbums calls GetAlbums of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) GetAlbums(ids ...spotify.ID) ([]*spotify.FullAlbum, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.GetAlbums(ids...)
+	return result, c.observe(err)
+}
+
+// CurrentUsersTracksOpt calls CurrentUsersTracksOpt of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) CurrentUsersTracksOpt(opt *spotify.Options) (*spotify.SavedTrackPage, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.CurrentUsersTracksOpt(opt)
+	return result, c.observe(err)
+}
+
+// UserHasTracks calls UserHasTracks of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) UserHasTracks(ids ...spotify.ID) ([]bool, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.UserHasTracks(ids...)
+	return result, c.observe(err)
+}
+
+// AddTracksToLibrary calls AddTracksToLibrary of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) AddTracksToLibrary(ids ...spotify.ID) error {
+	client, err := c.current()
+	if err != nil {
+		return err
+	}
+	return c.observe(client.AddTracksToLibrary(ids...))
+}
+
+// RemoveTracksFromLibrary calls RemoveTracksFromLibrary of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) RemoveTracksFromLibrary(ids ...spotify.ID) error {
+	client, err := c.current()
+	if err != nil {
+		return err
+	}
+	return c.observe(client.RemoveTracksFromLibrary(ids...))
+}
+
+// UserHasAlbums calls UserHasAlbums of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) UserHasAlbums(ids ...spotify.ID) ([]bool, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.UserHasAlbums(ids...)
+	return result, c.observe(err)
+}
+
+// AddAlbumsToLibrary calls AddAlbumsToLibrary of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) AddAlbumsToLibrary(ids ...spotify.ID) error {
+	client, err := c.current()
+	if err != nil {
+		return err
+	}
+	return c.observe(client.AddAlbumsToLibrary(ids...))
+}
+
+// RemoveAlbumsFromLibrary calls RemoveAlbumsFromLibrary of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) RemoveAlbumsFromLibrary(ids ...spotify.ID) error {
+	client, err := c.current()
+	if err != nil {
+		return err
+	}
+	return c.observe(client.RemoveAlbumsFromLibrary(ids...))
+}
+
+// CurrentUsersPlaylistsOpt calls CurrentUsersPlaylistsOpt of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) CurrentUsersPlaylistsOpt(opt *spotify.Options) (*spotify.SimplePlaylistPage, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.CurrentUsersPlaylistsOpt(opt)
+	return result, c.observe(err)
+}
+
+// CurrentUserFollows calls CurrentUserFollows of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) CurrentUserFollows(t string, ids ...spotify.ID) ([]bool, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.CurrentUserFollows(t, ids...)
+	return result, c.observe(err)
+}
+
+// FollowArtist calls FollowArtist of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) FollowArtist(ids ...spotify.ID) error {
+	client, err := c.current()
+	if err != nil {
+		return err
+	}
+	return c.observe(client.FollowArtist(ids...))
+}
+
+// UnfollowArtist calls UnfollowArtist of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) UnfollowArtist(ids ...spotify.ID) error {
+	client, err := c.current()
+	if err != nil {
+		return err
+	}
+	return c.observe(client.UnfollowArtist(ids...))
+}
+
+// CurrentUsersTopArtistsOpt calls CurrentUsersTopArtistsOpt of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) CurrentUsersTopArtistsOpt(opt *spotify.Options) (*spotify.FullArtistPage, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.CurrentUsersTopArtistsOpt(opt)
+	return result, c.observe(err)
+}
+
+// CurrentUsersTopTracksOpt calls CurrentUsersTopTracksOpt of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) CurrentUsersTopTracksOpt(opt *spotify.Options) (*spotify.FullTrackPage, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.CurrentUsersTopTracksOpt(opt)
+	return result, c.observe(err)
+}
+
+// GetRecommendations calls GetRecommendations of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) GetRecommendations(seeds spotify.Seeds, trackAttributes *spotify.TrackAttributes, opt *spotify.Options) (*spotify.Recommendations, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.GetRecommendations(seeds, trackAttributes, opt)
+	return result, c.observe(err)
+}
+
+// GetAlbumTracksOpt calls GetAlbumTracksOpt of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) GetAlbumTracksOpt(id spotify.ID, opt *spotify.Options) (*spotify.SimpleTrackPage, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.GetAlbumTracksOpt(id, opt)
+	return result, c.observe(err)
+}
+
+// CurrentUsersShows calls CurrentUsersShows of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) CurrentUsersShows(limit int, offset int) (*SavedShowPage, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.CurrentUsersShows(limit, offset)
+	return result, c.observe(err)
+}
+
+// GetShowEpisodes calls GetShowEpisodes of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) GetShowEpisodes(id spotify.ID, limit int, offset int) (*EpisodePage, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.GetShowEpisodes(id, limit, offset)
+	return result, c.observe(err)
+}
+
+// SearchPodcasts calls SearchPodcasts of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) SearchPodcasts(query string, limit int, offset int) (*PodcastSearchResult, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.SearchPodcasts(query, limit, offset)
+	return result, c.observe(err)
+}
+
+// PlayEpisode calls PlayEpisode of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) PlayEpisode(uri spotify.URI, positionMs int) error {
+	client, err := c.current()
+	if err != nil {
+		return err
+	}
+	return c.observe(client.PlayEpisode(uri, positionMs))
+}
+
+// PlayerCurrentlyPlayingEpisode calls PlayerCurrentlyPlayingEpisode of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) PlayerCurrentlyPlayingEpisode() (*Episode, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.PlayerCurrentlyPlayingEpisode()
+	return result, c.observe(err)
+}
+
+// Pause calls Pause of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) Pause() error {
+	client, err := c.current()
+	if err != nil {
+		return err
+	}
+	return c.observe(client.Pause())
+}
+
+// Previous calls Previous of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) Previous() error {
+	client, err := c.current()
+	if err != nil {
+		return err
+	}
+	return c.observe(client.Previous())
+}
+
+// Next calls Next of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) Next() error {
+	client, err := c.current()
+	if err != nil {
+		return err
+	}
+	return c.observe(client.Next())
+}
+
+// PlayerCurrentlyPlaying calls PlayerCurrentlyPlaying of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) PlayerCurrentlyPlaying() (*spotify.CurrentlyPlaying, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.PlayerCurrentlyPlaying()
+	return result, c.observe(err)
+}
+
+// PlayerRecentlyPlayedOpt calls PlayerRecentlyPlayedOpt of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) PlayerRecentlyPlayedOpt(opt *spotify.RecentlyPlayedOptions) ([]spotify.RecentlyPlayedItem, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.PlayerRecentlyPlayedOpt(opt)
+	return result, c.observe(err)
+}
+
+// PlayerDevices calls PlayerDevices of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) PlayerDevices() ([]spotify.PlayerDevice, error) {
+	client, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.PlayerDevices()
+	return result, c.observe(err)
+}
+
+// TransferPlayback calls TransferPlayback of the wrapped client, see ConnectedClient.
+func (c *ConnectedClient) TransferPlayback(deviceID spotify.ID, play bool) error {
+	client, err := c.current()
+	if err != nil {
+		return err
+	}
+	return c.observe(client.TransferPlayback(deviceID, play))
+}
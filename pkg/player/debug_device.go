@@ -0,0 +1,375 @@
+package player
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jedruniu/spotify-cli/pkg/web"
+	"github.com/zmb3/spotify"
+)
+
+// DebugDeviceID is the ID of the device which stands for the web player
+// in debug mode, tracks are played on it when app starts.
+const DebugDeviceID spotify.ID = "debug"
+
+var (
+	// debugTrackLength is how long tracks, which length is not known, are.
+	debugTrackLength = 3 * time.Minute
+	// debugRestartAfter is how much of the track has to be played, for
+	// previous to start it again instead of going to the previous one.
+	debugRestartAfter = 3 * time.Second
+)
+
+// DebugDevice simulates Spotify Connect devices in debug mode. Tracks are
+// played from the queue as time goes by, the queue is either a context,
+// like an album, or tracks which were asked to be played. Like Spotify,
+// it starts paused with the last played tracks queued.
+type DebugDevice struct {
+	// now tells what time it is, tracks are played according to it.
+	now func() time.Time
+
+	mu      sync.Mutex
+	devices []spotify.PlayerDevice
+	active  spotify.ID
+	context *spotify.URI
+	queue   []spotify.FullTrack
+	// position is the index of the current track in the queue.
+	position int
+	playing  bool
+	// progress is how much of the current track was played at since.
+	progress time.Duration
+	since    time.Time
+}
+
+// NewDebugDevice creates simulated devices, the one which stands for the
+// web player is active.
+func NewDebugDevice() *DebugDevice {
+	d := &DebugDevice{
+		now: time.Now,
+		devices: []spotify.PlayerDevice{
+			{ID: DebugDeviceID, Name: "spotify-cli", Type: "Computer"},
+			{ID: "ipad", Name: "iPad", Type: "Tablet"},
+			{ID: "iphone", Name: "iPhone", Type: "Smartphone"},
+			{ID: "mac", Name: "Mac", Type: "App Player"},
+		},
+		active: DebugDeviceID,
+	}
+	for i := 1; i <= likedSongsPageSize; i++ {
+		d.queue = append(d.queue, debugSavedTrack(i))
+	}
+	d.since = d.now()
+	return d
+}
+
+// Play resumes playback on the active device.
+func (d *DebugDevice) Play() error {
+	return d.PlayOpt(nil)
+}
+
+// PlayOpt starts playing context or tracks from options, or resumes
+// playback when neither is given.
+func (d *DebugDevice) PlayOpt(opt *spotify.PlayOptions) error {
+	if opt == nil {
+		opt = &spotify.PlayOptions{}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sync()
+	if opt.DeviceID != nil {
+		if !d.hasDevice(*opt.DeviceID) {
+			return debugDeviceNotFound
+		}
+		d.active = *opt.DeviceID
+	}
+	if d.active == "" {
+		return debugNoActiveDevice
+	}
+
+	var context *spotify.URI
+	var queue []spotify.FullTrack
+	switch {
+	case opt.PlaybackContext != nil:
+		tracks, err := debugContextTracks(*opt.PlaybackContext)
+		if err != nil {
+			return err
+		}
+		uri := *opt.PlaybackContext
+		context, queue = &uri, tracks
+	case len(opt.URIs) > 0:
+		for _, uri := range opt.URIs {
+			if !strings.HasPrefix(string(uri), "spotify:track:") {
+				return spotify.Error{Status: http.StatusBadRequest, Message: fmt.Sprintf("Unsupported uri kind: %s", uri)}
+			}
+			queue = append(queue, debugTrack(uri))
+		}
+	default:
+		if len(d.queue) == 0 {
+			return debugNoActiveDevice
+		}
+		d.playing = true
+		return nil
+	}
+
+	position := 0
+	if offset := opt.PlaybackOffset; offset != nil {
+		position = offset.Position
+		for i, track := range queue {
+			if offset.URI != "" && track.URI == offset.URI {
+				position = i
+			}
+		}
+		if position >= len(queue) {
+			return spotify.Error{Status: http.StatusBadRequest, Message: "Offset is out of range"}
+		}
+	}
+	d.context, d.queue, d.position = context, queue, position
+	d.playing, d.progress = true, 0
+	return nil
+}
+
+// Pause pauses playback on the active device.
+func (d *DebugDevice) Pause() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.active == "" {
+		return debugNoActiveDevice
+	}
+	d.sync()
+	d.playing = false
+	return nil
+}
+
+// Next skips to the next track in the queue.
+func (d *DebugDevice) Next() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.active == "" {
+		return debugNoActiveDevice
+	}
+	d.sync()
+	d.skip(1)
+	return nil
+}
+
+// Previous starts current track again, unless only its beginning was
+// played, then it skips to the previous track.
+func (d *DebugDevice) Previous() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.active == "" {
+		return debugNoActiveDevice
+	}
+	d.sync()
+	if d.progress > debugRestartAfter || d.position == 0 {
+		d.progress = 0
+		return nil
+	}
+	d.skip(-1)
+	return nil
+}
+
+// PlayerCurrentlyPlaying tells which track is played and how much of it.
+func (d *DebugDevice) PlayerCurrentlyPlaying() (*spotify.CurrentlyPlaying, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sync()
+	current := &spotify.CurrentlyPlaying{
+		Timestamp: d.since.UnixNano() / int64(time.Millisecond),
+		Progress:  int(d.progress / time.Millisecond),
+		Playing:   d.playing,
+	}
+	if d.position < len(d.queue) {
+		track := d.queue[d.position]
+		current.Item = &track
+	}
+	if d.context != nil {
+		current.PlaybackContext = spotify.PlaybackContext{Type: debugURIKind(*d.context), URI: *d.context}
+	}
+	return current, nil
+}
+
+// PlayerDevices returns simulated devices, telling which one is active.
+func (d *DebugDevice) PlayerDevices() ([]spotify.PlayerDevice, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	devices := make([]spotify.PlayerDevice, 0, len(d.devices))
+	for _, device := range d.devices {
+		device.Active = device.ID == d.active
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// TransferPlayback makes device the active one, playback continues on it
+// from the same place. Paused playback is resumed when play is set.
+func (d *DebugDevice) TransferPlayback(id spotify.ID, play bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.hasDevice(id) {
+		return debugDeviceNotFound
+	}
+	d.sync()
+	d.active = id
+	if play && len(d.queue) > 0 {
+		d.playing = true
+	}
+	return nil
+}
+
+// State describes the current track the same way as the web player does,
+// it is nil when nothing is queued.
+func (d *DebugDevice) State() *web.WebPlaybackState {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sync()
+	if d.position >= len(d.queue) {
+		return nil
+	}
+	track := d.queue[d.position]
+	state := &web.WebPlaybackState{
+		CurrentTrackName:  track.Name,
+		CurrentAlbumName:  track.Album.Name,
+		CurrentArtistName: artistName(track.Artists),
+		CurrentTrackURI:   string(track.URI),
+		CurrentItemType:   "track",
+	}
+	if d.context != nil {
+		state.ContextURI = string(*d.context)
+	}
+	return state
+}
+
+// Run sends state to states every time it changes, until stop is closed,
+// like the web player does. It checks whether state changed every interval.
+func (d *DebugDevice) Run(states chan<- *web.WebPlaybackState, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last web.WebPlaybackState
+	for {
+		if state := d.State(); state != nil && *state != last {
+			select {
+			case states <- state:
+				last = *state
+			case <-stop:
+				return
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// sync plays tracks for the time which passed since the last sync, it is
+// called with mu locked.
+func (d *DebugDevice) sync() {
+	now := d.now()
+	if d.playing {
+		d.progress += now.Sub(d.since)
+		for d.playing && d.position < len(d.queue) && d.progress >= debugLength(d.queue[d.position]) {
+			left := d.progress - debugLength(d.queue[d.position])
+			d.skip(1)
+			if d.playing {
+				d.progress = left
+			}
+		}
+	}
+	d.since = now
+}
+
+// skip moves by n tracks in the queue, playback stops at the beginning of
+// the queue after its last track, like Spotify does without repeat.
+func (d *DebugDevice) skip(n int) {
+	d.position += n
+	if d.position < 0 {
+		d.position = 0
+	}
+	if d.position >= len(d.queue) {
+		d.position, d.playing = 0, false
+	}
+	if n != 0 {
+		d.progress = 0
+	}
+}
+
+func (d *DebugDevice) hasDevice(id spotify.ID) bool {
+	for _, device := range d.devices {
+		if device.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	debugDeviceNotFound = spotify.Error{Status: http.StatusNotFound, Message: "Device not found"}
+	debugNoActiveDevice = spotify.Error{Status: http.StatusNotFound, Message: "Player command failed: No active device found"}
+)
+
+func debugLength(track spotify.FullTrack) time.Duration {
+	if track.Duration <= 0 {
+		return debugTrackLength
+	}
+	return time.Duration(track.Duration) * time.Millisecond
+}
+
+// debugURIKind returns kind of item, like "album", from its URI.
+func debugURIKind(uri spotify.URI) string {
+	parts := strings.Split(string(uri), ":")
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[1]
+}
+
+// debugTrack returns track with the URI, saved tracks are the same as in
+// fake library, others are named after their IDs.
+func debugTrack(uri spotify.URI) spotify.FullTrack {
+	id := spotify.ID(strings.TrimPrefix(string(uri), "spotify:track:"))
+	var i int
+	if _, err := fmt.Sscanf(string(id), "savedtrack%d", &i); err == nil && i > 0 {
+		return debugSavedTrack(i)
+	}
+	track := spotify.FullTrack{}
+	track.ID, track.URI, track.Name = id, uri, string(id)
+	return track
+}
+
+// debugContextTracks returns tracks which are played from the context,
+// saved albums have the same tracks as in fake library, other albums,
+// artists and playlists have tracks of fake albums.
+func debugContextTracks(uri spotify.URI) ([]spotify.FullTrack, error) {
+	kind := debugURIKind(uri)
+	if kind != "album" && kind != "artist" && kind != "playlist" {
+		return nil, spotify.Error{Status: http.StatusBadRequest, Message: fmt.Sprintf("Non supported context uri: %s", uri)}
+	}
+	id := spotify.ID(strings.TrimPrefix(string(uri), "spotify:"+kind+":"))
+	album := spotify.SimpleAlbum{ID: id, URI: uri, Name: string(id)}
+	simpleTracks := []spotify.SimpleTrack{}
+	var i int
+	if _, err := fmt.Sscanf(string(id), "savedalbum%d", &i); kind == "album" && err == nil && i > 0 {
+		saved := debugSavedAlbum(i)
+		album, simpleTracks = saved.SimpleAlbum, saved.Tracks.Tracks
+		album.Artists = saved.Artists
+	} else {
+		limit := 50
+		page, err := DebugRecommender{}.GetAlbumTracksOpt(id, &spotify.Options{Limit: &limit})
+		if err != nil {
+			return nil, err
+		}
+		simpleTracks = page.Tracks
+	}
+	tracks := make([]spotify.FullTrack, 0, len(simpleTracks))
+	for _, simpleTrack := range simpleTracks {
+		if len(simpleTrack.Artists) == 0 {
+			simpleTrack.Artists = album.Artists
+		}
+		tracks = append(tracks, spotify.FullTrack{SimpleTrack: simpleTrack, Album: album})
+	}
+	return tracks, nil
+}
@@ -0,0 +1,178 @@
+package player
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jedruniu/spotify-cli/pkg/web"
+	"github.com/zmb3/spotify"
+)
+
+// newTestDevice creates device with a clock which goes forward only when
+// test moves it.
+func newTestDevice() (*DebugDevice, func(time.Duration)) {
+	var mu sync.Mutex
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	device := NewDebugDevice()
+	device.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	device.since = now
+	return device, func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+}
+
+func playing(t *testing.T, device *DebugDevice) (string, time.Duration, bool) {
+	current, err := device.PlayerCurrentlyPlaying()
+	if err != nil || current.Item == nil {
+		t.Fatalf("Expected track to be played, got %+v and %v", current, err)
+	}
+	return current.Item.Name, time.Duration(current.Progress) * time.Millisecond, current.Playing
+}
+
+func TestDebugDevicePlaysContext(t *testing.T) {
+	device, wait := newTestDevice()
+	if name, _, isPlaying := playing(t, device); name != "Song Name 1" || isPlaying {
+		t.Fatalf("Expected device to start paused with liked songs, got %s, playing: %t", name, isPlaying)
+	}
+
+	album := spotify.URI("spotify:album:savedalbum2")
+	if err := device.PlayOpt(&spotify.PlayOptions{PlaybackContext: &album, PlaybackOffset: &spotify.PlaybackOffset{Position: 1}}); err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	// tracks of the second saved album are 182s long
+	wait(100 * time.Second)
+	if name, progress, isPlaying := playing(t, device); name != "Track 2" || progress != 100*time.Second || !isPlaying {
+		t.Errorf("Expected the second track to be played for 100s, got %s after %v", name, progress)
+	}
+	wait(100 * time.Second)
+	if name, progress, _ := playing(t, device); name != "Track 3" || progress != 18*time.Second {
+		t.Errorf("Expected the next track to be played when the previous one ended, got %s after %v", name, progress)
+	}
+	current, _ := device.PlayerCurrentlyPlaying()
+	if current.PlaybackContext.URI != album || current.Item.Album.Name != "Album Name 2" || artistName(current.Item.Artists) != "Artist Name 2" {
+		t.Errorf("Expected track to be played from the album, got %+v", current)
+	}
+
+	device.Pause()
+	wait(time.Hour)
+	if name, progress, isPlaying := playing(t, device); name != "Track 3" || progress != 18*time.Second || isPlaying {
+		t.Errorf("Expected paused track not to be played, got %s after %v", name, progress)
+	}
+	device.Play()
+	wait(10 * 182 * time.Second)
+	if name, progress, isPlaying := playing(t, device); name != "Track 1" || progress != 0 || isPlaying {
+		t.Errorf("Expected playback to stop after the last track, got %s after %v, playing: %t", name, progress, isPlaying)
+	}
+}
+
+func TestDebugDeviceSkipsTracks(t *testing.T) {
+	device, wait := newTestDevice()
+	uris := []spotify.URI{"spotify:track:savedtrack3", "spotify:track:searchtrack1", "spotify:track:savedtrack5"}
+	device.PlayOpt(&spotify.PlayOptions{URIs: uris, PlaybackOffset: &spotify.PlaybackOffset{URI: uris[1]}})
+
+	cases := []struct {
+		action   func() error
+		waited   time.Duration
+		expected string
+	}{
+		{action: device.Next, expected: "Song Name 5"},
+		{action: device.Previous, waited: 10 * time.Second, expected: "Song Name 5"},
+		{action: device.Previous, expected: "searchtrack1"},
+		{action: device.Previous, expected: "Song Name 3"},
+		{action: device.Previous, expected: "Song Name 3"},
+	}
+	for i, c := range cases {
+		wait(c.waited)
+		if err := c.action(); err != nil {
+			t.Fatalf("%d: did not expect error, got %s", i, err)
+		}
+		if name, progress, _ := playing(t, device); name != c.expected || progress != 0 {
+			t.Errorf("%d: expected %s to be played from the beginning, got %s after %v", i, c.expected, name, progress)
+		}
+	}
+
+	episode := spotify.URI("spotify:episode:show1episode1")
+	if err := device.PlayOpt(&spotify.PlayOptions{URIs: []spotify.URI{episode}}); err == nil {
+		t.Errorf("Expected episode not to be played as a track")
+	}
+	if err := device.PlayOpt(&spotify.PlayOptions{PlaybackContext: &uris[0]}); err == nil {
+		t.Errorf("Expected track not to be played as a context")
+	}
+	if name, _, _ := playing(t, device); name != "Song Name 3" {
+		t.Errorf("Expected failed requests not to change playback, got %s", name)
+	}
+}
+
+func TestDebugDeviceTransfersPlayback(t *testing.T) {
+	device, wait := newTestDevice()
+	if err := device.TransferPlayback("unknown", true); err == nil {
+		t.Errorf("Expected playback not to be transferred to unknown device")
+	}
+	if err := device.TransferPlayback("iphone", false); err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	devices, _ := device.PlayerDevices()
+	for _, d := range devices {
+		if d.Active != (d.ID == "iphone") {
+			t.Errorf("Expected only iPhone to be active, got %+v", devices)
+		}
+	}
+	wait(time.Minute)
+	if _, progress, isPlaying := playing(t, device); progress != 0 || isPlaying {
+		t.Errorf("Expected playback to stay paused, got %v, playing: %t", progress, isPlaying)
+	}
+	device.TransferPlayback(DebugDeviceID, true)
+	wait(time.Minute)
+	if name, progress, isPlaying := playing(t, device); name != "Song Name 1" || progress != time.Minute || !isPlaying {
+		t.Errorf("Expected playback to be resumed on the other device, got %s after %v", name, progress)
+	}
+	if err := device.PlayOpt(&spotify.PlayOptions{DeviceID: &devices[1].ID}); err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	if devices, _ := device.PlayerDevices(); !devices[1].Active {
+		t.Errorf("Expected device to be active after playing on it, got %+v", devices)
+	}
+}
+
+func TestDebugDeviceSendsStateChanges(t *testing.T) {
+	device, wait := newTestDevice()
+	states := make(chan *web.WebPlaybackState)
+	stop := make(chan struct{})
+	defer close(stop)
+	go device.Run(states, time.Millisecond, stop)
+
+	expect := func(expected web.WebPlaybackState) {
+		select {
+		case state := <-states:
+			if *state != expected {
+				t.Errorf("Expected state %+v, got %+v", expected, *state)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected state %+v to be sent", expected)
+		}
+	}
+	expect(web.WebPlaybackState{CurrentTrackName: "Song Name 1", CurrentAlbumName: "Album Name 1", CurrentArtistName: "Artist Name 1",
+		CurrentTrackURI: "spotify:track:savedtrack1", CurrentItemType: "track"})
+
+	album := spotify.URI("spotify:album:searchalbum1")
+	device.PlayOpt(&spotify.PlayOptions{PlaybackContext: &album})
+	expect(web.WebPlaybackState{CurrentTrackName: "Album Song 1", CurrentAlbumName: "searchalbum1",
+		CurrentTrackURI: "spotify:track:searchalbum1track1", ContextURI: string(album), CurrentItemType: "track"})
+	wait(debugTrackLength)
+	expect(web.WebPlaybackState{CurrentTrackName: "Album Song 2", CurrentAlbumName: "searchalbum1",
+		CurrentTrackURI: "spotify:track:searchalbum1track2", ContextURI: string(album), CurrentItemType: "track"})
+
+	device.Pause()
+	select {
+	case state := <-states:
+		t.Errorf("Expected state to be sent only when it changes, got %+v", state)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
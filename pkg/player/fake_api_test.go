@@ -0,0 +1,782 @@
+package player
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tui "github.com/marcusolsson/tui-go"
+	"github.com/zmb3/spotify"
+	"golang.org/x/oauth2"
+)
+
+// fakeSpotifyAPI is an in-process fake of Spotify Web API endpoints which
+// the app uses. It has a catalog of albums, of which some are saved in user
+// library, and a player, which plays tracks as time passes and is
+// controlled like Spotify Connect device.
+type fakeSpotifyAPI struct {
+	mu sync.Mutex
+
+	albums      []spotify.FullAlbum
+	tracks      map[spotify.ID]spotify.FullTrack
+	savedAlbums []spotify.SavedAlbum
+	savedTracks map[spotify.ID]bool
+	followed    map[spotify.ID]bool
+	devices     []spotify.PlayerDevice
+	player      fakePlayer
+
+	refreshToken  string
+	accessToken   string
+	tokenRequests int
+	// unexpected are methods and endpoints of requests, which fake does
+	// not have.
+	unexpected []string
+
+	now func() time.Time
+}
+
+// fakePlayer is the state of playback on the active device.
+type fakePlayer struct {
+	device   spotify.ID
+	context  *spotify.URI
+	queue    []spotify.ID
+	position int
+	playing  bool
+	// progress is how much of the track was played when it was last
+	// resumed at resumed.
+	progress time.Duration
+	resumed  time.Time
+}
+
+// newFakeSpotifyAPI creates fake with given number of albums in catalog,
+// the first saved ones are saved in user library, from the most recently
+// added. Album n is "Album n", its tracks are "Song n.1" to "Song n.3".
+// Web player "web" is the active device, nothing is played yet.
+func newFakeSpotifyAPI(albums, saved int) *fakeSpotifyAPI {
+	api := &fakeSpotifyAPI{
+		tracks:      map[spotify.ID]spotify.FullTrack{},
+		savedTracks: map[spotify.ID]bool{},
+		followed:    map[spotify.ID]bool{},
+		devices: []spotify.PlayerDevice{
+			{ID: "web", Name: "Spotify CLI", Type: "Computer"},
+			{ID: "phone", Name: "Phone", Type: "Smartphone"},
+		},
+		player:       fakePlayer{device: "web"},
+		refreshToken: "refresh-token",
+		now:          time.Now,
+	}
+	addedAt := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+	for n := 1; n <= albums; n++ {
+		artist := spotify.SimpleArtist{ID: spotify.ID(fmt.Sprintf("artist%d", n%5+1)), Name: fmt.Sprintf("Artist %d", n%5+1)}
+		artist.URI = spotify.URI("spotify:artist:" + artist.ID)
+		album := spotify.FullAlbum{
+			SimpleAlbum: spotify.SimpleAlbum{
+				ID:                   spotify.ID(fmt.Sprintf("album%d", n)),
+				Name:                 fmt.Sprintf("Album %d", n),
+				Artists:              []spotify.SimpleArtist{artist},
+				ReleaseDate:          fmt.Sprintf("%d-01-01", 1960+n),
+				ReleaseDatePrecision: "day",
+			},
+		}
+		album.URI = spotify.URI("spotify:album:" + album.ID)
+		for i := 1; i <= 3; i++ {
+			track := spotify.SimpleTrack{
+				ID:          spotify.ID(fmt.Sprintf("track%dx%d", n, i)),
+				Name:        fmt.Sprintf("Song %d.%d", n, i),
+				Artists:     album.Artists,
+				Duration:    180000 + i*1000,
+				TrackNumber: i,
+			}
+			track.URI = spotify.URI("spotify:track:" + track.ID)
+			album.Tracks.Tracks = append(album.Tracks.Tracks, track)
+			api.tracks[track.ID] = spotify.FullTrack{SimpleTrack: track, Album: album.SimpleAlbum}
+		}
+		album.Tracks.Total = len(album.Tracks.Tracks)
+		api.albums = append(api.albums, album)
+		if n <= saved {
+			added := addedAt.Add(-time.Duration(n) * time.Hour).Format(spotify.TimestampLayout)
+			api.savedAlbums = append(api.savedAlbums, spotify.SavedAlbum{AddedAt: added, FullAlbum: album})
+		}
+	}
+	return api
+}
+
+// newFakeAPIClient starts fake and returns Client which talks with it, it is
+// authorized with expired token, which client refreshes with the fake.
+func newFakeAPIClient(t *testing.T, api *fakeSpotifyAPI) (*Client, func()) {
+	server := httptest.NewServer(api)
+	config := &oauth2.Config{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		Endpoint:     oauth2.Endpoint{TokenURL: server.URL + "/api/token"},
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, server.Client())
+	expired := &oauth2.Token{AccessToken: "expired", RefreshToken: api.refreshToken, Expiry: time.Now().Add(-time.Hour)}
+	client := newClient(config.TokenSource(ctx, expired), redirectTransport{base: server.Client().Transport, baseURL: server.URL + "/v1/"})
+	return client, func() {
+		server.Close()
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		if len(api.unexpected) > 0 {
+			t.Errorf("Expected only endpoints of fake Spotify API to be called, got %v", api.unexpected)
+		}
+	}
+}
+
+// redirectTransport sends requests to Web API at baseURL instead of the one
+// of Spotify, other requests are sent unchanged.
+type redirectTransport struct {
+	base    http.RoundTripper
+	baseURL string
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	spotifyURL := req.URL.String()
+	if !strings.HasPrefix(spotifyURL, spotifyAPIBaseURL) {
+		return t.base.RoundTrip(req)
+	}
+	u, err := url.Parse(t.baseURL + strings.TrimPrefix(spotifyURL, spotifyAPIBaseURL))
+	if err != nil {
+		return nil, err
+	}
+	redirected := new(http.Request)
+	*redirected = *req
+	redirected.URL = u
+	redirected.Host = u.Host
+	return t.base.RoundTrip(redirected)
+}
+
+func (api *fakeSpotifyAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if r.URL.Path == "/api/token" {
+		api.token(w, r)
+		return
+	}
+	if api.accessToken == "" || r.Header.Get("Authorization") != "Bearer "+api.accessToken {
+		api.fail(w, http.StatusUnauthorized, "Invalid access token")
+		return
+	}
+	endpoint := r.Method + " " + strings.TrimPrefix(r.URL.Path, "/v1/")
+	handlers := map[string]func(http.ResponseWriter, *http.Request){
+		"GET me":                          api.currentUser,
+		"GET me/albums":                   api.savedAlbumsPage,
+		"GET me/albums/contains":          api.contains(api.isAlbumSaved),
+		"PUT me/albums":                   api.setAlbumsSaved(true),
+		"DELETE me/albums":                api.setAlbumsSaved(false),
+		"GET me/tracks/contains":          api.contains(func(id spotify.ID) bool { return api.savedTracks[id] }),
+		"PUT me/tracks":                   api.setSaved(api.savedTracks, true),
+		"DELETE me/tracks":                api.setSaved(api.savedTracks, false),
+		"GET me/following/contains":       api.contains(func(id spotify.ID) bool { return api.followed[id] }),
+		"PUT me/following":                api.setSaved(api.followed, true),
+		"DELETE me/following":             api.setSaved(api.followed, false),
+		"GET search":                      api.search,
+		"GET albums":                      api.getAlbums,
+		"GET me/player/currently-playing": api.currentlyPlaying,
+		"GET me/player/devices":           api.playerDevices,
+		"PUT me/player":                   api.transfer,
+		"PUT me/player/play":              api.play,
+		"PUT me/player/pause":             api.pause,
+		"POST me/player/next":             api.skip(1),
+		"POST me/player/previous":         api.skip(-1),
+	}
+	if strings.HasPrefix(endpoint, "GET users/") && strings.HasSuffix(endpoint, "/playlists") {
+		handlers[endpoint] = api.userPlaylists
+	}
+	handler, ok := handlers[endpoint]
+	if !ok {
+		api.unexpected = append(api.unexpected, endpoint)
+		api.fail(w, http.StatusNotFound, "Service not found")
+		return
+	}
+	handler(w, r)
+}
+
+// token refreshes access token, it is the token endpoint of Spotify
+// Accounts Service.
+func (api *fakeSpotifyAPI) token(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("grant_type") != "refresh_token" || r.FormValue("refresh_token") != api.refreshToken {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error": "invalid_grant", "error_description": "Invalid refresh token"}`)
+		return
+	}
+	api.tokenRequests++
+	api.accessToken = fmt.Sprintf("access-token-%d", api.tokenRequests)
+	api.reply(w, map[string]interface{}{"access_token": api.accessToken, "token_type": "Bearer", "expires_in": 3600})
+}
+
+func (api *fakeSpotifyAPI) reply(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func (api *fakeSpotifyAPI) fail(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]spotify.Error{"error": {Status: status, Message: message}})
+}
+
+// page returns limit and offset of requested page, and bounds of its items
+// among total items.
+func page(r *http.Request, total int) (int, int, int, int) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 20
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	start, end := offset, offset+limit
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+	return limit, offset, start, end
+}
+
+func queryIDs(r *http.Request) []spotify.ID {
+	ids := []spotify.ID{}
+	for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		ids = append(ids, spotify.ID(id))
+	}
+	return ids
+}
+
+func (api *fakeSpotifyAPI) savedAlbumsPage(w http.ResponseWriter, r *http.Request) {
+	result := spotify.SavedAlbumPage{}
+	var start, end int
+	result.Limit, result.Offset, start, end = page(r, len(api.savedAlbums))
+	result.Total = len(api.savedAlbums)
+	result.Albums = api.savedAlbums[start:end]
+	api.reply(w, result)
+}
+
+func (api *fakeSpotifyAPI) isAlbumSaved(id spotify.ID) bool {
+	for _, album := range api.savedAlbums {
+		if album.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (api *fakeSpotifyAPI) album(id spotify.ID) (spotify.FullAlbum, bool) {
+	for _, album := range api.albums {
+		if album.ID == id {
+			return album, true
+		}
+	}
+	return spotify.FullAlbum{}, false
+}
+
+func (api *fakeSpotifyAPI) contains(saved func(spotify.ID) bool) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result := []bool{}
+		for _, id := range queryIDs(r) {
+			result = append(result, saved(id))
+		}
+		api.reply(w, result)
+	}
+}
+
+func (api *fakeSpotifyAPI) setSaved(items map[spotify.ID]bool, saved bool) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for _, id := range queryIDs(r) {
+			items[id] = saved
+		}
+	}
+}
+
+// setAlbumsSaved saves albums as the most recently added ones, or removes
+// them from library.
+func (api *fakeSpotifyAPI) setAlbumsSaved(saved bool) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for _, id := range queryIDs(r) {
+			album, ok := api.album(id)
+			if !ok {
+				api.fail(w, http.StatusBadRequest, "invalid id")
+				return
+			}
+			kept := []spotify.SavedAlbum{}
+			if saved {
+				kept = append(kept, spotify.SavedAlbum{AddedAt: api.now().UTC().Format(spotify.TimestampLayout), FullAlbum: album})
+			}
+			for _, savedAlbum := range api.savedAlbums {
+				if savedAlbum.ID != id {
+					kept = append(kept, savedAlbum)
+				}
+			}
+			api.savedAlbums = kept
+		}
+	}
+}
+
+// search finds items of which name, or name of their artist or album,
+// contains all words of the query.
+func (api *fakeSpotifyAPI) search(w http.ResponseWriter, r *http.Request) {
+	words := strings.Fields(strings.ToLower(r.URL.Query().Get("q")))
+	matches := func(texts ...string) bool {
+		text := strings.ToLower(strings.Join(texts, " "))
+		for _, word := range words {
+			if !strings.Contains(text, word) {
+				return false
+			}
+		}
+		return true
+	}
+	var tracks []spotify.FullTrack
+	var albums []spotify.SimpleAlbum
+	var artists []spotify.FullArtist
+	seenArtists := map[spotify.ID]bool{}
+	for _, album := range api.albums {
+		if matches(album.Name, artistName(album.Artists)) {
+			albums = append(albums, album.SimpleAlbum)
+		}
+		for _, track := range album.Tracks.Tracks {
+			if matches(track.Name, artistName(track.Artists), album.Name) {
+				tracks = append(tracks, api.tracks[track.ID])
+			}
+		}
+		artist := album.Artists[0]
+		if matches(artist.Name) && !seenArtists[artist.ID] {
+			seenArtists[artist.ID] = true
+			artists = append(artists, spotify.FullArtist{SimpleArtist: artist, Genres: []string{"rock"}})
+		}
+	}
+
+	result := spotify.SearchResult{}
+	for _, t := range strings.Split(r.URL.Query().Get("type"), ",") {
+		switch t {
+		case "track":
+			result.Tracks = &spotify.FullTrackPage{}
+			var start, end int
+			result.Tracks.Limit, result.Tracks.Offset, start, end = page(r, len(tracks))
+			result.Tracks.Total, result.Tracks.Tracks = len(tracks), tracks[start:end]
+		case "album":
+			result.Albums = &spotify.SimpleAlbumPage{}
+			var start, end int
+			result.Albums.Limit, result.Albums.Offset, start, end = page(r, len(albums))
+			result.Albums.Total, result.Albums.Albums = len(albums), albums[start:end]
+		case "artist":
+			result.Artists = &spotify.FullArtistPage{}
+			var start, end int
+			result.Artists.Limit, result.Artists.Offset, start, end = page(r, len(artists))
+			result.Artists.Total, result.Artists.Artists = len(artists), artists[start:end]
+		case "playlist":
+			result.Playlists = &spotify.SimplePlaylistPage{}
+		}
+	}
+	api.reply(w, result)
+}
+
+func (api *fakeSpotifyAPI) getAlbums(w http.ResponseWriter, r *http.Request) {
+	result := struct {
+		Albums []*spotify.FullAlbum `json:"albums"`
+	}{}
+	for _, id := range queryIDs(r) {
+		var found *spotify.FullAlbum
+		if album, ok := api.album(id); ok {
+			found = &album
+		}
+		result.Albums = append(result.Albums, found)
+	}
+	api.reply(w, result)
+}
+
+// played returns how much of the current track was played, the next track
+// is played after the current one ends.
+func (api *fakeSpotifyAPI) played() time.Duration {
+	p := &api.player
+	for p.playing {
+		progress := p.progress + api.now().Sub(p.resumed)
+		duration := time.Duration(api.tracks[p.queue[p.position]].Duration) * time.Millisecond
+		if progress < duration {
+			return progress
+		}
+		p.resumed = p.resumed.Add(duration - p.progress)
+		p.progress = 0
+		if p.position == len(p.queue)-1 {
+			p.playing = false
+			break
+		}
+		p.position++
+	}
+	return p.progress
+}
+
+// currentlyPlaying returns the current track, it answers with no content
+// when nothing is played, as Spotify does.
+func (api *fakeSpotifyAPI) currentlyPlaying(w http.ResponseWriter, r *http.Request) {
+	if len(api.player.queue) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	progress := api.played()
+	track := api.tracks[api.player.queue[api.player.position]]
+	result := struct {
+		spotify.CurrentlyPlaying
+		Type string `json:"currently_playing_type"`
+	}{Type: "track"}
+	result.Timestamp = api.now().UnixNano() / int64(time.Millisecond)
+	result.Progress = int(progress / time.Millisecond)
+	result.Playing = api.player.playing
+	result.Item = &track
+	if api.player.context != nil {
+		result.PlaybackContext = spotify.PlaybackContext{URI: *api.player.context, Type: "album"}
+	}
+	api.reply(w, result)
+}
+
+// fakeUser is the user who is logged in, it has all personal details which
+// Spotify returns.
+var fakeUser = spotify.PrivateUser{
+	User:      spotify.User{DisplayName: "Jan Kowalski", ID: "jankowalski", URI: "spotify:user:jankowalski"},
+	Country:   "PL",
+	Email:     "jan.kowalski@example.com",
+	Birthdate: "1990-05-17",
+	Product:   "premium",
+}
+
+func (api *fakeSpotifyAPI) currentUser(w http.ResponseWriter, r *http.Request) {
+	api.reply(w, struct {
+		spotify.PrivateUser
+		Type string `json:"type"`
+	}{fakeUser, "user"})
+}
+
+// userPlaylists replies with a playlist of the user from URL.
+func (api *fakeSpotifyAPI) userPlaylists(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/users/"), "/playlists")
+	playlist := spotify.SimplePlaylist{ID: "playlist1", Name: "Mix", URI: "spotify:playlist:playlist1"}
+	playlist.Owner = spotify.User{ID: id, URI: spotify.URI("spotify:user:" + id)}
+	page := spotify.SimplePlaylistPage{Playlists: []spotify.SimplePlaylist{playlist}}
+	page.Total = 1
+	api.reply(w, page)
+}
+
+func (api *fakeSpotifyAPI) playerDevices(w http.ResponseWriter, r *http.Request) {
+	devices := []spotify.PlayerDevice{}
+	for _, device := range api.devices {
+		device.Active = device.ID == api.player.device
+		devices = append(devices, device)
+	}
+	api.reply(w, map[string][]spotify.PlayerDevice{"devices": devices})
+}
+
+func (api *fakeSpotifyAPI) hasDevice(id spotify.ID) bool {
+	for _, device := range api.devices {
+		if device.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (api *fakeSpotifyAPI) transfer(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		DeviceIDs []spotify.ID `json:"device_ids"`
+		Play      bool         `json:"play"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.DeviceIDs) != 1 {
+		api.fail(w, http.StatusBadRequest, "Malformed json")
+		return
+	}
+	if !api.hasDevice(body.DeviceIDs[0]) {
+		api.fail(w, http.StatusNotFound, "Device not found")
+		return
+	}
+	api.player.device = body.DeviceIDs[0]
+	if body.Play && len(api.player.queue) > 0 {
+		api.resume()
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// play plays tracks, or the album which is the context, or resumes playback.
+// Only tracks can be played as tracks, Spotify fails otherwise.
+func (api *fakeSpotifyAPI) play(w http.ResponseWriter, r *http.Request) {
+	if id := spotify.ID(r.URL.Query().Get("device_id")); id != "" {
+		if !api.hasDevice(id) {
+			api.fail(w, http.StatusNotFound, "Device not found")
+			return
+		}
+		api.player.device = id
+	}
+	if api.player.device == "" {
+		api.fail(w, http.StatusNotFound, "Player command failed: No active device found")
+		return
+	}
+	var body struct {
+		ContextURI *spotify.URI            `json:"context_uri"`
+		URIs       []spotify.URI           `json:"uris"`
+		Offset     *spotify.PlaybackOffset `json:"offset"`
+	}
+	data, _ := ioutil.ReadAll(r.Body)
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := json.Unmarshal(data, &body); err != nil {
+			api.fail(w, http.StatusBadRequest, "Malformed json")
+			return
+		}
+	}
+	switch {
+	case body.ContextURI != nil:
+		album, ok := api.album(idFromURI(*body.ContextURI))
+		if !ok || !strings.HasPrefix(string(*body.ContextURI), "spotify:album:") {
+			api.fail(w, http.StatusBadRequest, "Non supported context uri")
+			return
+		}
+		queue := []spotify.ID{}
+		for _, track := range album.Tracks.Tracks {
+			queue = append(queue, track.ID)
+		}
+		position := 0
+		if body.Offset != nil && body.Offset.Position < len(queue) {
+			position = body.Offset.Position
+		}
+		api.player = fakePlayer{device: api.player.device, context: body.ContextURI, queue: queue, position: position}
+	case len(body.URIs) > 0:
+		queue := []spotify.ID{}
+		for _, uri := range body.URIs {
+			id := idFromURI(uri)
+			if _, ok := api.tracks[id]; !ok || !strings.HasPrefix(string(uri), "spotify:track:") {
+				api.fail(w, http.StatusBadRequest, "Unsupported uri kind")
+				return
+			}
+			queue = append(queue, id)
+		}
+		api.player = fakePlayer{device: api.player.device, queue: queue}
+	case len(api.player.queue) == 0:
+		api.fail(w, http.StatusForbidden, "Player command failed: Restriction violated")
+		return
+	}
+	api.resume()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *fakeSpotifyAPI) resume() {
+	if api.player.playing {
+		return
+	}
+	api.player.playing = true
+	api.player.resumed = api.now()
+}
+
+func (api *fakeSpotifyAPI) pause(w http.ResponseWriter, r *http.Request) {
+	if api.player.device == "" || len(api.player.queue) == 0 {
+		api.fail(w, http.StatusNotFound, "Player command failed: No active device found")
+		return
+	}
+	api.player.progress = api.played()
+	api.player.playing = false
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// skip plays the next or the previous track of the queue. Previous track
+// restarts the current one, when more than 3 seconds of it were played.
+func (api *fakeSpotifyAPI) skip(tracks int) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if api.player.device == "" || len(api.player.queue) == 0 {
+			api.fail(w, http.StatusNotFound, "Player command failed: No active device found")
+			return
+		}
+		restart := tracks < 0 && api.played() > 3*time.Second
+		position := api.player.position + tracks
+		if !restart && position >= 0 && position < len(api.player.queue) {
+			api.player.position = position
+		}
+		api.player.progress = 0
+		api.player.playing = false
+		api.resume()
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// state returns copy of the state of the fake.
+func (api *fakeSpotifyAPI) state() fakeSpotifyAPI {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return fakeSpotifyAPI{
+		savedAlbums:   append([]spotify.SavedAlbum{}, api.savedAlbums...),
+		savedTracks:   copySavedSet(api.savedTracks),
+		player:        api.player,
+		tokenRequests: api.tokenRequests,
+	}
+}
+
+func copySavedSet(items map[spotify.ID]bool) map[spotify.ID]bool {
+	copied := map[spotify.ID]bool{}
+	for id, saved := range items {
+		copied[id] = saved
+	}
+	return copied
+}
+
+// waitForUpdates applies updates until done, or fails when they stop coming.
+func waitForUpdates(t *testing.T, updates chan func(), done func() bool) {
+	for !done() {
+		select {
+		case fn := <-updates:
+			fn()
+		case <-time.After(time.Second):
+			t.Fatalf("Expected update to be applied")
+		}
+	}
+}
+
+func TestSideBarWithFakeAPI(t *testing.T) {
+	api := newFakeSpotifyAPI(60, 55)
+	client, closeAPI := newFakeAPIClient(t, api)
+	defer closeAPI()
+
+	sideBar, err := NewSideBar(client, NewLibrary(client))
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	albumList := sideBar.AlbumList
+	if len(albumList.albumsDescriptions) != spotifyAPIPageSize {
+		t.Fatalf("Expected the first page of albums to be displayed, got %d albums", len(albumList.albumsDescriptions))
+	}
+	updates := make(chan func())
+	sideBar.LoadAlbums(func(fn func()) { updates <- fn })
+	waitForUpdates(t, updates, func() bool { return !albumList.loading.inProgress() })
+	if len(albumList.albumsDescriptions) != 55 || albumList.title() != "User albums" {
+		t.Fatalf("Expected all 55 albums to be loaded, got %d and title %q", len(albumList.albumsDescriptions), albumList.title())
+	}
+	first := albumList.shownAlbums()[0]
+	if first.title != "Album 1" || first.artist != "Artist 2" || albumYear(first) != "1961" || first.runtime != 546*time.Second {
+		t.Errorf("Expected the most recently added album to be the first one, got %+v", first)
+	}
+	token, err := client.Token()
+	if state := api.state(); err != nil || token.AccessToken != "access-token-1" || state.tokenRequests != 1 {
+		t.Errorf("Expected expired token to be refreshed once, got %v after %d requests", token, state.tokenRequests)
+	}
+
+	albumList.list.selectRow(2)
+	albumList.onItemActivaed()(albumList.Table)
+	if player := api.state().player; player.context == nil || *player.context != "spotify:album:album3" || !player.playing {
+		t.Errorf("Expected selected album to be played, got %+v", player)
+	}
+
+	albumList.list.selectRow(0)
+	albumList.onRemoveRequested()(albumList.Table)
+	removed := false
+	albumList.library.SetUpdater(func(fn func()) { updates <- func() { fn(); removed = true } })
+	albumList.onRemoveConfirmed()(albumList.Table)
+	waitForUpdates(t, updates, func() bool { return removed })
+	state := api.state()
+	if len(state.savedAlbums) != 54 || state.savedAlbums[0].ID != "album2" {
+		t.Errorf("Expected album to be removed from library, got %d saved albums", len(state.savedAlbums))
+	}
+	if len(albumList.albumsDescriptions) != 54 || albumList.shownAlbums()[0].title != "Album 2" {
+		t.Errorf("Expected album to be removed from the list, got %d albums", len(albumList.albumsDescriptions))
+	}
+}
+
+func TestSearchWithFakeAPI(t *testing.T) {
+	api := newFakeSpotifyAPI(60, 0)
+	api.savedTracks["track12x2"] = true
+	client, closeAPI := newFakeAPIClient(t, api)
+	defer closeAPI()
+
+	library := NewLibrary(client)
+	search, err := NewSearch(client, library, DefaultSearchCategories)
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	updates := make(chan func())
+	search.SetUpdater(func(fn func()) { updates <- fn })
+	search.search(client, "album 12", 0)
+	waitForUpdates(t, updates, func() bool { return search.searching == "" })
+
+	songs, albums, artists := search.categories[0].results, search.categories[1].results, search.categories[2].results
+	expectedSongs := []spotify.URI{"spotify:track:track12x1", "spotify:track:track12x2", "spotify:track:track12x3"}
+	if fmt.Sprint(songs.data) != fmt.Sprint(expectedSongs) || len(albums.data) != 1 || len(artists.data) != 0 {
+		t.Fatalf("Expected songs and album to be found, got %v, %v and %v", songs.data, albums.data, artists.data)
+	}
+	// saved state is looked up after results are displayed
+	waitForUpdates(t, updates, func() bool { return songs.marks[1].Text() == heart(true) })
+	if library.tracks.isSaved("track12x1") || !library.tracks.isSaved("track12x2") {
+		t.Errorf("Expected saved state of found songs to be checked")
+	}
+	if texts := albums.rowTexts(albums.items[0]); !strings.Contains(strings.Join(texts, " "), "1972") {
+		t.Errorf("Expected year of found album to be fetched, got %v", texts)
+	}
+
+	songs.table.selectRow(1)
+	songs.onItemActivated(client)(songs.getTable())
+	if player := api.state().player; len(player.queue) != 1 || player.queue[0] != "track12x2" || player.context != nil {
+		t.Errorf("Expected found song to be played, got %+v", player)
+	}
+	albums.onItemActivated(client)(albums.getTable())
+	if player := api.state().player; player.context == nil || *player.context != "spotify:album:album12" || len(player.queue) != 3 {
+		t.Errorf("Expected found album to be played as context, got %+v", player)
+	}
+}
+
+func TestPlaybackWithFakeAPI(t *testing.T) {
+	api := newFakeSpotifyAPI(5, 5)
+	now := time.Date(2019, 2, 1, 10, 0, 0, 0, time.UTC)
+	api.now = func() time.Time { return now }
+	client, closeAPI := newFakeAPIClient(t, api)
+	defer closeAPI()
+
+	library := NewLibrary(client)
+	updates, liked := make(chan func()), false
+	library.SetUpdater(func(fn func()) { updates <- func() { fn(); liked = true } })
+	playback := NewPlayback(client, library, nil, "web")
+	if playback.label.Text() != "None" || len(playback.Devices.devices) != 2 || playback.Devices.Table.Selected() != 1 {
+		t.Fatalf("Expected nothing to be played on web player, got %q on row %d", playback.label.Text(), playback.Devices.Table.Selected())
+	}
+
+	if err := client.PlayOpt(&spotify.PlayOptions{PlaybackContext: &api.albums[1].URI}); err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	activate := func(button *tui.Button) {
+		button.SetFocused(true)
+		button.OnKeyEvent(tui.KeyEvent{Key: tui.KeyEnter})
+		button.SetFocused(false)
+	}
+	activate(playback.Playback.Next)
+	if playback.label.Text() != "Song 2.2\nAlbum 2\nArtist 3" {
+		t.Errorf("Expected the next song to be played, got %q", playback.label.Text())
+	}
+
+	now = now.Add(200 * time.Second)
+	updateCurrentlyPlayingLabel(client, playback.label)
+	if playback.label.Text() != "Song 2.3\nAlbum 2\nArtist 3" {
+		t.Errorf("Expected the next song to be played after the previous one ended, got %q", playback.label.Text())
+	}
+	now = now.Add(10 * time.Second)
+	activate(playback.Playback.Previous)
+	if current, err := client.PlayerCurrentlyPlaying(); err != nil || current.Item.Name != "Song 2.3" || current.Progress != 0 {
+		t.Errorf("Expected previous to restart the song, got %+v, %v", current, err)
+	}
+
+	activate(playback.Playback.Like)
+	waitForUpdates(t, updates, func() bool { return liked })
+	if !api.state().savedTracks["track2x3"] {
+		t.Errorf("Expected played song to be liked")
+	}
+	activate(playback.Playback.Stop)
+	now = now.Add(10 * time.Second)
+	if current, err := client.PlayerCurrentlyPlaying(); err != nil || current.Playing || current.Progress != 0 {
+		t.Errorf("Expected playback to be paused, got %+v, %v", current, err)
+	}
+
+	playback.Devices.Table.Select(2)
+	playback.Devices.Table.SetFocused(true)
+	playback.Devices.Table.OnKeyEvent(tui.KeyEvent{Key: tui.KeyEnter})
+	if player := api.state().player; player.device != "phone" || !player.playing {
+		t.Errorf("Expected playback to be transferred to phone, got %+v", player)
+	}
+}
@@ -2,6 +2,8 @@ package player
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/zmb3/spotify"
 	"golang.org/x/oauth2"
@@ -10,38 +12,144 @@ import (
 // NewDebugClient returns Client which can be used in debug mode or in tests,
 // It does not communicate with Spotify API.
 func NewDebugClient() SpotifyClient {
+	device := NewDebugDevice()
 	return DebugClient{
-		Player:           &DebugPlayer{},
+		Device:           device,
+		Player:           device,
 		Searcher:         &DebugSearcher{},
 		UserAlbumFetcher: &DebugUserAlbumFetcher{},
+		TrackLibrary:     NewDebugTrackLibrary(likedSongsPageSize * 3),
+		AlbumLibrary:     &DebugAlbumLibrary{saved: debugSavedSet{}},
+		PlaylistLibrary:  DebugPlaylistLibrary{},
+		ArtistFollower:   &DebugArtistFollower{followed: debugSavedSet{}},
+		TopItemsFetcher:  DebugTopItemsFetcher{},
+		Recommender:      DebugRecommender{},
+		PodcastLibrary:   DebugPodcastLibrary{},
 	}
 }
 
-// DebugClient is a dummy struct used when running in debug mode
+// DebugClient is a dummy struct used when running in debug mode,
+// playback is simulated by Device, when it is set.
 type DebugClient struct {
+	Device *DebugDevice
 	Player
 	Searcher
 	UserAlbumFetcher
+	TrackLibrary
+	AlbumLibrary
+	PlaylistLibrary
+	ArtistFollower
+	TopItemsFetcher
+	Recommender
+	PodcastLibrary
 }
 
-type DebugPlayer struct {
-}
+// DebugSearcher finds items named after the query, there are debugSearchTotals
+// items of each type. Nothing is found for "nothing" query.
+type DebugSearcher struct{}
 
-// Play is a dummy implementation used when running in debug mode
-func (dp DebugPlayer) Play() error {
-	return nil
+var debugSearchTotals = map[spotify.SearchType]int{
+	spotify.SearchTypeTrack:    45,
+	spotify.SearchTypeAlbum:    25,
+	spotify.SearchTypeArtist:   8,
+	spotify.SearchTypePlaylist: 12,
 }
 
-// PlayOpt is a dummy implementation used when running in debug mode
-func (dp DebugPlayer) PlayOpt(opt *spotify.PlayOptions) error {
-	return nil
+// Search is a dummy implementation used when running in debug mode
+func (ds DebugSearcher) Search(query string, t spotify.SearchType) (*spotify.SearchResult, error) {
+	return ds.SearchOpt(query, t, nil)
 }
 
-type DebugSearcher struct{}
+// SearchOpt is a dummy implementation used when running in debug mode
+func (ds DebugSearcher) SearchOpt(query string, t spotify.SearchType, opt *spotify.Options) (*spotify.SearchResult, error) {
+	result := &spotify.SearchResult{}
+	bounds := func(t spotify.SearchType) (int, int, int) {
+		total := debugSearchTotals[t]
+		if query == "nothing" {
+			total = 0
+		}
+		limit := searchPageSize
+		if opt != nil && opt.Limit != nil {
+			limit = *opt.Limit
+		}
+		offset := 0
+		if opt != nil && opt.Offset != nil {
+			offset = *opt.Offset
+		}
+		start, end := debugPageBounds(&spotify.Options{Limit: &limit, Offset: &offset}, total)
+		return start, end, total
+	}
+	artist := func(i int) spotify.SimpleArtist {
+		id := spotify.ID(fmt.Sprintf("searchartist%d", i))
+		return spotify.SimpleArtist{Name: fmt.Sprintf("%s Artist %d", query, i), ID: id, URI: spotify.URI("spotify:artist:" + id)}
+	}
+	if t&spotify.SearchTypeTrack != 0 {
+		start, end, total := bounds(spotify.SearchTypeTrack)
+		result.Tracks = &spotify.FullTrackPage{}
+		result.Tracks.Total = total
+		for i := start + 1; i <= end; i++ {
+			track := spotify.FullTrack{}
+			track.Name = fmt.Sprintf("%s Song %d", query, i)
+			track.ID = spotify.ID(fmt.Sprintf("searchtrack%d", i))
+			track.URI = spotify.URI("spotify:track:" + track.ID)
+			track.Artists = []spotify.SimpleArtist{artist(i%debugSearchTotals[spotify.SearchTypeArtist] + 1)}
+			track.Album.Name = fmt.Sprintf("%s Album %d", query, i%debugSearchTotals[spotify.SearchTypeAlbum]+1)
+			track.Duration = 120000 + i*7000
+			track.Popularity = 100 - i
+			track.Explicit = i%5 == 0
+			result.Tracks.Tracks = append(result.Tracks.Tracks, track)
+		}
+	}
+	if t&spotify.SearchTypeAlbum != 0 {
+		start, end, total := bounds(spotify.SearchTypeAlbum)
+		result.Albums = &spotify.SimpleAlbumPage{}
+		result.Albums.Total = total
+		for i := start + 1; i <= end; i++ {
+			album := spotify.SimpleAlbum{Name: fmt.Sprintf("%s Album %d", query, i)}
+			album.ID = spotify.ID(fmt.Sprintf("searchalbum%d", i))
+			album.URI = spotify.URI("spotify:album:" + album.ID)
+			album.Artists = []spotify.SimpleArtist{artist(i%debugSearchTotals[spotify.SearchTypeArtist] + 1)}
+			result.Albums.Albums = append(result.Albums.Albums, album)
+		}
+	}
+	if t&spotify.SearchTypeArtist != 0 {
+		start, end, total := bounds(spotify.SearchTypeArtist)
+		result.Artists = &spotify.FullArtistPage{}
+		result.Artists.Total = total
+		for i := start + 1; i <= end; i++ {
+			fullArtist := spotify.FullArtist{SimpleArtist: artist(i), Genres: []string{"jazz", "bebop"}[:i%2+1]}
+			fullArtist.Followers.Count = uint(i * 1500)
+			result.Artists.Artists = append(result.Artists.Artists, fullArtist)
+		}
+	}
+	if t&spotify.SearchTypePlaylist != 0 {
+		start, end, total := bounds(spotify.SearchTypePlaylist)
+		result.Playlists = &spotify.SimplePlaylistPage{}
+		result.Playlists.Total = total
+		for i := start + 1; i <= end; i++ {
+			playlist := spotify.SimplePlaylist{Name: fmt.Sprintf("%s Playlist %d", query, i)}
+			playlist.ID = spotify.ID(fmt.Sprintf("searchplaylist%d", i))
+			playlist.URI = spotify.URI("spotify:playlist:" + playlist.ID)
+			playlist.Owner.DisplayName = fmt.Sprintf("User %d", i)
+			playlist.Tracks.Total = uint(i * 10)
+			result.Playlists.Playlists = append(result.Playlists.Playlists, playlist)
+		}
+	}
+	return result, nil
+}
 
-// Search is a dummy implementation used when running in debug mode
-func (ds DebugSearcher) Search(query string, t spotify.SearchType) (*spotify.SearchResult, error) {
-	return nil, nil
+// GetAlbums is a dummy implementation used when running in debug mode,
+// albums are released in subsequent years.
+func (ds DebugSearcher) GetAlbums(ids ...spotify.ID) ([]*spotify.FullAlbum, error) {
+	albums := []*spotify.FullAlbum{}
+	for i, id := range ids {
+		album := &spotify.FullAlbum{}
+		album.ID = id
+		album.ReleaseDate = fmt.Sprintf("%d-01-01", 1960+i)
+		album.ReleaseDatePrecision = "day"
+		albums = append(albums, album)
+	}
+	return albums, nil
 }
 
 type DebugUserAlbumFetcher struct{}
@@ -56,31 +164,412 @@ func (debugFetcher DebugUserAlbumFetcher) CurrentUsersAlbumsOpt(options *spotify
 func constructNSpotifySavedAlbums(n int) []spotify.SavedAlbum {
 	albums := make([]spotify.SavedAlbum, 0)
 	for i := 1; i <= n; i++ {
-		album := spotify.SavedAlbum{}
-		album.Name = fmt.Sprintf("Album Name %d", i)
-		album.Artists = []spotify.SimpleArtist{spotify.SimpleArtist{Name: fmt.Sprintf("Artist Name %d", i)}}
+		album := spotify.SavedAlbum{FullAlbum: debugSavedAlbum(i)}
+		// the most recently added album is the first one
+		album.AddedAt = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -i).Format(spotify.TimestampLayout)
 		albums = append(albums, album)
 	}
 	return albums
 }
 
-// Previous is a dummy implementation used when running in debug mode
-func (fc DebugClient) Previous() error {
+// debugSavedAlbum returns i-th album saved in fake library.
+func debugSavedAlbum(i int) spotify.FullAlbum {
+	album := spotify.FullAlbum{}
+	album.Name = fmt.Sprintf("Album Name %d", i)
+	album.ID = spotify.ID(fmt.Sprintf("savedalbum%d", i))
+	album.URI = spotify.URI(fmt.Sprintf("spotify:album:savedalbum%d", i))
+	album.Artists = []spotify.SimpleArtist{spotify.SimpleArtist{Name: fmt.Sprintf("Artist Name %d", (i-1)%40+1)}}
+	album.ReleaseDate = fmt.Sprintf("%d", 1950+i*7%70)
+	album.ReleaseDatePrecision = "year"
+	for track := 1; track <= i%8+4; track++ {
+		album.Tracks.Tracks = append(album.Tracks.Tracks, spotify.SimpleTrack{
+			ID:       spotify.ID(fmt.Sprintf("%strack%d", album.ID, track)),
+			URI:      spotify.URI(fmt.Sprintf("spotify:track:%strack%d", album.ID, track)),
+			Name:     fmt.Sprintf("Track %d", track),
+			Duration: 180000 + i*1000,
+		})
+	}
+	return album
+}
+
+// DebugTrackLibrary is a fake "Your Music" library used when running in debug mode,
+// it remembers which tracks were saved and removed.
+type DebugTrackLibrary struct {
+	tracks []spotify.SavedTrack
+	saved  map[spotify.ID]bool
+}
+
+// NewDebugTrackLibrary creates fake library with n saved tracks.
+func NewDebugTrackLibrary(n int) *DebugTrackLibrary {
+	library := &DebugTrackLibrary{
+		tracks: constructNSpotifySavedTracks(n),
+		saved:  map[spotify.ID]bool{},
+	}
+	for _, track := range library.tracks {
+		library.saved[track.ID] = true
+	}
+	return library
+}
+
+// CurrentUsersTracksOpt returns page of fake saved tracks
+func (dl *DebugTrackLibrary) CurrentUsersTracksOpt(opt *spotify.Options) (*spotify.SavedTrackPage, error) {
+	start, end := debugPageBounds(opt, len(dl.tracks))
+	page := &spotify.SavedTrackPage{Tracks: dl.tracks[start:end]}
+	page.Total = len(dl.tracks)
+	return page, nil
+}
+
+// UserHasTracks checks if tracks are saved in fake library
+func (dl *DebugTrackLibrary) UserHasTracks(ids ...spotify.ID) ([]bool, error) {
+	saved := make([]bool, 0, len(ids))
+	for _, id := range ids {
+		saved = append(saved, dl.saved[id])
+	}
+	return saved, nil
+}
+
+// AddTracksToLibrary marks tracks as saved in fake library
+func (dl *DebugTrackLibrary) AddTracksToLibrary(ids ...spotify.ID) error {
+	for _, id := range ids {
+		dl.saved[id] = true
+	}
 	return nil
 }
 
-// Pause is a dummy implementation used when running in debug mode
-func (fc DebugClient) Pause() error {
+// RemoveTracksFromLibrary marks tracks as not saved in fake library
+func (dl *DebugTrackLibrary) RemoveTracksFromLibrary(ids ...spotify.ID) error {
+	for _, id := range ids {
+		dl.saved[id] = false
+	}
 	return nil
 }
 
-// Next is a dummy implementation used when running in debug mode
-func (fc DebugClient) Next() error {
+func constructNSpotifySavedTracks(n int) []spotify.SavedTrack {
+	tracks := make([]spotify.SavedTrack, 0)
+	for i := 1; i <= n; i++ {
+		tracks = append(tracks, spotify.SavedTrack{FullTrack: debugSavedTrack(i)})
+	}
+	return tracks
+}
+
+// debugSavedTrack returns i-th track saved in fake library.
+func debugSavedTrack(i int) spotify.FullTrack {
+	track := spotify.FullTrack{}
+	track.ID = spotify.ID(fmt.Sprintf("savedtrack%d", i))
+	track.URI = spotify.URI(fmt.Sprintf("spotify:track:savedtrack%d", i))
+	track.Name = fmt.Sprintf("Song Name %d", i)
+	track.Artists = []spotify.SimpleArtist{{Name: fmt.Sprintf("Artist Name %d", i)}}
+	track.Album = spotify.SimpleAlbum{Name: fmt.Sprintf("Album Name %d", i)}
+	return track
+}
+
+// debugSavedSet remembers which items were saved in fake library.
+type debugSavedSet map[spotify.ID]bool
+
+func (set debugSavedSet) has(ids []spotify.ID) []bool {
+	saved := make([]bool, 0, len(ids))
+	for _, id := range ids {
+		saved = append(saved, set[id])
+	}
+	return saved
+}
+
+func (set debugSavedSet) set(ids []spotify.ID, saved bool) {
+	for _, id := range ids {
+		set[id] = saved
+	}
+}
+
+// debugPlaylistsTotal is the number of playlists in fake library.
+var debugPlaylistsTotal = 12
+
+// DebugPlaylistLibrary is a fake playlist library used when running in debug mode
+type DebugPlaylistLibrary struct{}
+
+// CurrentUsersPlaylistsOpt returns page of fake playlists
+func (dp DebugPlaylistLibrary) CurrentUsersPlaylistsOpt(opt *spotify.Options) (*spotify.SimplePlaylistPage, error) {
+	start, end := debugPageBounds(opt, debugPlaylistsTotal)
+	page := &spotify.SimplePlaylistPage{}
+	page.Total = debugPlaylistsTotal
+	for i := start + 1; i <= end; i++ {
+		playlist := spotify.SimplePlaylist{Name: fmt.Sprintf("Playlist %d", i)}
+		playlist.ID = spotify.ID(fmt.Sprintf("playlist%d", i))
+		playlist.URI = spotify.URI("spotify:playlist:" + playlist.ID)
+		playlist.Owner.DisplayName = "Debug User"
+		playlist.Tracks.Total = uint(i * 5)
+		page.Playlists = append(page.Playlists, playlist)
+	}
+	return page, nil
+}
+
+// DebugAlbumLibrary is a fake album library used when running in debug mode
+type DebugAlbumLibrary struct {
+	saved debugSavedSet
+}
+
+// UserHasAlbums checks if albums are saved in fake library
+func (dl *DebugAlbumLibrary) UserHasAlbums(ids ...spotify.ID) ([]bool, error) {
+	return dl.saved.has(ids), nil
+}
+
+// AddAlbumsToLibrary marks albums as saved in fake library
+func (dl *DebugAlbumLibrary) AddAlbumsToLibrary(ids ...spotify.ID) error {
+	dl.saved.set(ids, true)
+	return nil
+}
+
+// RemoveAlbumsFromLibrary marks albums as not saved in fake library
+func (dl *DebugAlbumLibrary) RemoveAlbumsFromLibrary(ids ...spotify.ID) error {
+	dl.saved.set(ids, false)
+	return nil
+}
+
+// DebugArtistFollower remembers which artists are followed when running in debug mode
+type DebugArtistFollower struct {
+	followed debugSavedSet
+}
+
+// CurrentUserFollows checks if artists are followed
+func (df *DebugArtistFollower) CurrentUserFollows(t string, ids ...spotify.ID) ([]bool, error) {
+	return df.followed.has(ids), nil
+}
+
+// FollowArtist marks artists as followed
+func (df *DebugArtistFollower) FollowArtist(ids ...spotify.ID) error {
+	df.followed.set(ids, true)
+	return nil
+}
+
+// UnfollowArtist marks artists as not followed
+func (df *DebugArtistFollower) UnfollowArtist(ids ...spotify.ID) error {
+	df.followed.set(ids, false)
 	return nil
 }
 
-// PlayerCurrentlyPlaying is a dummy implementation used when running in debug mode
+// debugTopItemsCount is a number of top artists and tracks in each time range in debug mode.
+var debugTopItemsCount = 30
+
+// DebugTopItemsFetcher returns the same top artists and tracks every time,
+// with names telling from which time range they are.
+type DebugTopItemsFetcher struct{}
+
+// CurrentUsersTopArtistsOpt returns page of fake top artists
+func (df DebugTopItemsFetcher) CurrentUsersTopArtistsOpt(opt *spotify.Options) (*spotify.FullArtistPage, error) {
+	timerange := debugTimerange(opt)
+	start, end := debugPageBounds(opt, debugTopItemsCount)
+	page := &spotify.FullArtistPage{Artists: []spotify.FullArtist{}}
+	for i := start + 1; i <= end; i++ {
+		artist := spotify.FullArtist{Genres: []string{"rock", "jazz"}}
+		artist.ID = spotify.ID(fmt.Sprintf("top%sartist%d", timerange, i))
+		artist.URI = spotify.URI(fmt.Sprintf("spotify:artist:top%sartist%d", timerange, i))
+		artist.Name = fmt.Sprintf("Top %s Term Artist %d", strings.Title(timerange), i)
+		page.Artists = append(page.Artists, artist)
+	}
+	page.Total = debugTopItemsCount
+	return page, nil
+}
+
+// CurrentUsersTopTracksOpt returns page of fake top tracks
+func (df DebugTopItemsFetcher) CurrentUsersTopTracksOpt(opt *spotify.Options) (*spotify.FullTrackPage, error) {
+	timerange := debugTimerange(opt)
+	start, end := debugPageBounds(opt, debugTopItemsCount)
+	page := &spotify.FullTrackPage{Tracks: []spotify.FullTrack{}}
+	for i := start + 1; i <= end; i++ {
+		track := spotify.FullTrack{Album: spotify.SimpleAlbum{Name: fmt.Sprintf("Album Name %d", i)}}
+		track.ID = spotify.ID(fmt.Sprintf("top%strack%d", timerange, i))
+		track.URI = spotify.URI(fmt.Sprintf("spotify:track:top%strack%d", timerange, i))
+		track.Name = fmt.Sprintf("Top %s Term Song %d", strings.Title(timerange), i)
+		track.Artists = []spotify.SimpleArtist{{Name: fmt.Sprintf("Artist Name %d", i)}}
+		page.Tracks = append(page.Tracks, track)
+	}
+	page.Total = debugTopItemsCount
+	return page, nil
+}
+
+// DebugRecommender recommends the same tracks for the same seeds every time.
+type DebugRecommender struct{}
+
+// GetRecommendations returns fake tracks named after the seeds
+func (dr DebugRecommender) GetRecommendations(seeds spotify.Seeds, trackAttributes *spotify.TrackAttributes, opt *spotify.Options) (*spotify.Recommendations, error) {
+	seedIDs := append(append([]spotify.ID{}, seeds.Tracks...), seeds.Artists...)
+	if len(seedIDs) == 0 {
+		return nil, fmt.Errorf("at least one seed is required")
+	}
+	_, end := debugPageBounds(opt, 20)
+	recommendations := &spotify.Recommendations{Tracks: []spotify.SimpleTrack{}}
+	for i := 1; i <= end; i++ {
+		track := spotify.SimpleTrack{
+			ID:      spotify.ID(fmt.Sprintf("%sradio%d", seedIDs[0], i)),
+			URI:     spotify.URI(fmt.Sprintf("spotify:track:%sradio%d", seedIDs[0], i)),
+			Name:    fmt.Sprintf("Recommended Song %d", i),
+			Artists: []spotify.SimpleArtist{{Name: fmt.Sprintf("Artist Name %d", i)}},
+		}
+		recommendations.Tracks = append(recommendations.Tracks, track)
+	}
+	return recommendations, nil
+}
+
+// GetAlbumTracksOpt returns page of fake album tracks
+func (dr DebugRecommender) GetAlbumTracksOpt(id spotify.ID, opt *spotify.Options) (*spotify.SimpleTrackPage, error) {
+	limit, offset := 20, 0
+	if opt != nil && opt.Limit != nil {
+		limit = *opt.Limit
+	}
+	if opt != nil && opt.Offset != nil {
+		offset = *opt.Offset
+	}
+	page := &spotify.SimpleTrackPage{Tracks: []spotify.SimpleTrack{}}
+	for i := offset + 1; i <= offset+limit && i <= 10; i++ {
+		page.Tracks = append(page.Tracks, spotify.SimpleTrack{
+			ID:   spotify.ID(fmt.Sprintf("%strack%d", id, i)),
+			URI:  spotify.URI(fmt.Sprintf("spotify:track:%strack%d", id, i)),
+			Name: fmt.Sprintf("Album Song %d", i),
+		})
+	}
+	page.Total = 10
+	return page, nil
+}
+
+var (
+	debugShowsCount    = 5
+	debugEpisodesCount = 12
+	debugEpisodeLength = 45 * time.Minute
+)
+
+// DebugPodcastLibrary is a fake podcast library used when running in debug mode,
+// in every show first episodes are fully played and the next one is started.
+type DebugPodcastLibrary struct{}
+
+// CurrentUsersShows returns page of fake saved shows
+func (dp DebugPodcastLibrary) CurrentUsersShows(limit, offset int) (*SavedShowPage, error) {
+	page := &SavedShowPage{Items: []SavedShow{}, Total: debugShowsCount}
+	start, end := debugPageBounds(&spotify.Options{Limit: &limit, Offset: &offset}, debugShowsCount)
+	for i := start + 1; i <= end; i++ {
+		page.Items = append(page.Items, SavedShow{Show: debugShow(i)})
+	}
+	return page, nil
+}
+
+func debugShow(i int) Show {
+	return Show{
+		ID:            spotify.ID(fmt.Sprintf("show%d", i)),
+		URI:           spotify.URI(fmt.Sprintf("spotify:show:show%d", i)),
+		Name:          fmt.Sprintf("Show Name %d", i),
+		Publisher:     fmt.Sprintf("Publisher %d", i),
+		Description:   fmt.Sprintf("Description of show %d", i),
+		TotalEpisodes: debugEpisodesCount,
+	}
+}
+
+// GetShowEpisodes returns page of fake episodes of the show
+func (dp DebugPodcastLibrary) GetShowEpisodes(id spotify.ID, limit, offset int) (*EpisodePage, error) {
+	page := &EpisodePage{Items: []Episode{}, Total: debugEpisodesCount}
+	start, end := debugPageBounds(&spotify.Options{Limit: &limit, Offset: &offset}, debugEpisodesCount)
+	for i := start + 1; i <= end; i++ {
+		page.Items = append(page.Items, debugEpisode(id, i))
+	}
+	return page, nil
+}
+
+// debugEpisode returns i-th episode of the show, episodes are ordered
+// from the newest and the two oldest are already played.
+func debugEpisode(showID spotify.ID, i int) Episode {
+	resumePoint := &ResumePoint{}
+	switch {
+	case i > debugEpisodesCount-2:
+		resumePoint.FullyPlayed = true
+	case i == debugEpisodesCount-2:
+		resumePoint.ResumePositionMs = int(debugEpisodeLength/time.Millisecond) / 2
+	}
+	return Episode{
+		ID:          spotify.ID(fmt.Sprintf("%sepisode%d", showID, i)),
+		URI:         spotify.URI(fmt.Sprintf("spotify:episode:%sepisode%d", showID, i)),
+		Name:        fmt.Sprintf("Episode Name %d", i),
+		Description: fmt.Sprintf("Description of episode %d", i),
+		DurationMs:  int(debugEpisodeLength / time.Millisecond),
+		ReleaseDate: fmt.Sprintf("2019-01-%02d", debugEpisodesCount+1-i),
+		ResumePoint: resumePoint,
+	}
+}
+
+// SearchPodcasts returns fake shows and episodes
+func (dp DebugPodcastLibrary) SearchPodcasts(query string, limit, offset int) (*PodcastSearchResult, error) {
+	result := &PodcastSearchResult{}
+	result.Shows.Total = 3
+	result.Episodes.Total = 3
+	for i := offset + 1; i <= 3 && i <= offset+limit; i++ {
+		show := debugShow(i)
+		episode := debugEpisode(show.ID, i)
+		episode.Show = &show
+		result.Shows.Items = append(result.Shows.Items, show)
+		result.Episodes.Items = append(result.Episodes.Items, episode)
+	}
+	return result, nil
+}
+
+// PlayEpisode is a dummy implementation used when running in debug mode
+func (dp DebugPodcastLibrary) PlayEpisode(uri spotify.URI, positionMs int) error {
+	return nil
+}
+
+// PlayerCurrentlyPlayingEpisode is a dummy implementation used when running in debug mode,
+// it tells that track is playing.
+func (dp DebugPodcastLibrary) PlayerCurrentlyPlayingEpisode() (*Episode, error) {
+	return nil, nil
+}
+
+func debugTimerange(opt *spotify.Options) string {
+	if opt == nil || opt.Timerange == nil {
+		return "medium" // default of Spotify Web API
+	}
+	return *opt.Timerange
+}
+
+// debugPageBounds returns indexes of first and last item of page requested with options.
+func debugPageBounds(opt *spotify.Options, total int) (int, int) {
+	start, end := 0, total
+	if opt != nil && opt.Offset != nil {
+		start = *opt.Offset
+	}
+	if opt != nil && opt.Limit != nil && start+*opt.Limit < end {
+		end = start + *opt.Limit
+	}
+	if start > end {
+		start = end
+	}
+	return start, end
+}
+
+// Previous skips to the previous track on simulated device
+func (fc DebugClient) Previous() error {
+	if fc.Device == nil {
+		return nil
+	}
+	return fc.Device.Previous()
+}
+
+// Pause pauses simulated device
+func (fc DebugClient) Pause() error {
+	if fc.Device == nil {
+		return nil
+	}
+	return fc.Device.Pause()
+}
+
+// Next skips to the next track on simulated device
+func (fc DebugClient) Next() error {
+	if fc.Device == nil {
+		return nil
+	}
+	return fc.Device.Next()
+}
+
+// PlayerCurrentlyPlaying returns track played on simulated device, or the same
+// song every time when there is no device.
 func (fc DebugClient) PlayerCurrentlyPlaying() (*spotify.CurrentlyPlaying, error) {
+	if fc.Device != nil {
+		return fc.Device.PlayerCurrentlyPlaying()
+	}
 	return &spotify.CurrentlyPlaying{Item: &spotify.FullTrack{SimpleTrack: spotify.SimpleTrack{
 		Name:    "Currently Playing Song",
 		Artists: []spotify.SimpleArtist{{Name: "Currently Playing Artist"}}},
@@ -88,19 +577,39 @@ func (fc DebugClient) PlayerCurrentlyPlaying() (*spotify.CurrentlyPlaying, error
 	}, nil
 }
 
-// PlayerDevices is a dummy implementation used when running in debug mode
-func (fc DebugClient) PlayerDevices() ([]spotify.PlayerDevice, error) {
+// PlayerRecentlyPlayedOpt is a dummy implementation used when running in debug mode,
+// it returns tracks played every few minutes during the last hour.
+func (fc DebugClient) PlayerRecentlyPlayedOpt(opt *spotify.RecentlyPlayedOptions) ([]spotify.RecentlyPlayedItem, error) {
+	items := make([]spotify.RecentlyPlayedItem, 0)
+	now := time.Now()
+	for i := 1; i <= 20; i++ {
+		item := spotify.RecentlyPlayedItem{PlayedAt: now.Add(-time.Duration(i*3) * time.Minute)}
+		item.Track.Name = fmt.Sprintf("Played Song %d", i)
+		item.Track.URI = spotify.URI(fmt.Sprintf("spotify:track:played%d", i))
+		item.Track.Artists = []spotify.SimpleArtist{{Name: fmt.Sprintf("Artist Name %d", i)}}
+		item.PlaybackContext = spotify.PlaybackContext{Type: "album", URI: "spotify:album:savedalbum1"}
+		items = append(items, item)
+	}
+	if opt != nil && opt.Limit != 0 && opt.Limit < len(items) {
+		items = items[:opt.Limit]
+	}
+	return items, nil
+}
 
-	return []spotify.PlayerDevice{
-		{Name: "iPad", Type: "Tablet"},
-		{Name: "iPhone", Type: "Smarthphone"},
-		{Name: "Mac", Type: "App Player"},
-	}, nil
+// PlayerDevices returns simulated devices
+func (fc DebugClient) PlayerDevices() ([]spotify.PlayerDevice, error) {
+	if fc.Device == nil {
+		return []spotify.PlayerDevice{}, nil
+	}
+	return fc.Device.PlayerDevices()
 }
 
-// TransferPlayback is a dummy implementation used when running in debug mode
+// TransferPlayback makes simulated device the active one
 func (fc DebugClient) TransferPlayback(id spotify.ID, play bool) error {
-	return nil
+	if fc.Device == nil {
+		return nil
+	}
+	return fc.Device.TransferPlayback(id, play)
 }
 
 // CurrentUser is a dummy implementation used when running in debug mode
@@ -49,7 +49,7 @@ func TestFixturesForDebugMode(t *testing.T) {
 	}
 
 	devices, err := debugClient.PlayerDevices()
-	expectedDevicesCount := 3
+	expectedDevicesCount := 4
 	if len(devices) != expectedDevicesCount {
 		t.Errorf("Expected to have %d fake devices, have %d", expectedDevicesCount, len(devices))
 	}
@@ -57,7 +57,7 @@ func TestFixturesForDebugMode(t *testing.T) {
 		t.Errorf("Expected not to return error, but got %v", err)
 	}
 
-	err = debugClient.TransferPlayback("id", true)
+	err = debugClient.TransferPlayback("ipad", true)
 	if err != nil {
 		t.Errorf("Expected not to return error, but got %v", err)
 	}
@@ -0,0 +1,129 @@
+//go:build ignore
+// +build ignore
+
+// gen_connected_client generates methods of ConnectedClient, which forward
+// methods of SpotifyClient to the wrapped client while Spotify is reachable.
+// It is run with go generate, after SpotifyClient changes.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+const output = "connected_client.go"
+
+func main() {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "interfaces.go", nil, 0)
+	if err != nil {
+		log.Fatalf("could not parse interfaces: %v", err)
+	}
+	interfaces := map[string]*ast.InterfaceType{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		if spec, ok := node.(*ast.TypeSpec); ok {
+			if iface, ok := spec.Type.(*ast.InterfaceType); ok {
+				interfaces[spec.Name.Name] = iface
+			}
+		}
+		return true
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gen_connected_client.go; DO NOT EDIT.\n\n")
+	buf.WriteString("package player\n\nimport \"github.com/zmb3/spotify\"\n")
+	for _, method := range methods(interfaces, "SpotifyClient") {
+		writeMethod(&buf, fset, method)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("could not format generated code: %v\n%s", err, buf.String())
+	}
+	if err := ioutil.WriteFile(output, src, 0644); err != nil {
+		log.Fatalf("could not write %s: %v", output, err)
+	}
+}
+
+// methods returns methods of the interface with given name, together with
+// methods of interfaces embedded in it, in order in which they are declared.
+func methods(interfaces map[string]*ast.InterfaceType, name string) []*ast.Field {
+	iface, ok := interfaces[name]
+	if !ok {
+		log.Fatalf("interface %s is not declared in interfaces.go", name)
+	}
+	var all []*ast.Field
+	for _, field := range iface.Methods.List {
+		if embedded, ok := field.Type.(*ast.Ident); ok {
+			all = append(all, methods(interfaces, embedded.Name)...)
+			continue
+		}
+		all = append(all, field)
+	}
+	return all
+}
+
+// writeMethod writes method which returns ErrOffline while Spotify is not
+// reachable, and otherwise calls the wrapped client and observes its error.
+func writeMethod(buf *bytes.Buffer, fset *token.FileSet, method *ast.Field) {
+	name := method.Names[0].Name
+	signature := method.Type.(*ast.FuncType)
+
+	var params, args []string
+	for _, param := range signature.Params.List {
+		if len(param.Names) == 0 {
+			log.Fatalf("parameters of %s have to be named", name)
+		}
+		for _, paramName := range param.Names {
+			params = append(params, paramName.Name+" "+expr(fset, param.Type))
+			arg := paramName.Name
+			if _, variadic := param.Type.(*ast.Ellipsis); variadic {
+				arg += "..."
+			}
+			args = append(args, arg)
+		}
+	}
+	call := fmt.Sprintf("client.%s(%s)", name, strings.Join(args, ", "))
+
+	var results []string
+	if signature.Results != nil {
+		for _, result := range signature.Results.List {
+			results = append(results, expr(fset, result.Type))
+		}
+	}
+	if len(results) == 0 || len(results) > 2 || results[len(results)-1] != "error" {
+		log.Fatalf("%s has to return error, optionally with one other result", name)
+	}
+	if len(results) == 2 {
+		switch signature.Results.List[0].Type.(type) {
+		case *ast.StarExpr, *ast.ArrayType, *ast.MapType:
+		default:
+			log.Fatalf("%s has to return nil when Spotify is not reachable", name)
+		}
+	}
+
+	fmt.Fprintf(buf, "\n// %s calls %s of the wrapped client, see ConnectedClient.\n", name, name)
+	if len(results) == 1 {
+		fmt.Fprintf(buf, "func (c *ConnectedClient) %s(%s) error {\n", name, strings.Join(params, ", "))
+		buf.WriteString("\tclient, err := c.current()\n\tif err != nil {\n\t\treturn err\n\t}\n")
+		fmt.Fprintf(buf, "\treturn c.observe(%s)\n}\n", call)
+		return
+	}
+	fmt.Fprintf(buf, "func (c *ConnectedClient) %s(%s) (%s, error) {\n", name, strings.Join(params, ", "), results[0])
+	buf.WriteString("\tclient, err := c.current()\n\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	fmt.Fprintf(buf, "\tresult, err := %s\n\treturn result, c.observe(err)\n}\n", call)
+}
+
+func expr(fset *token.FileSet, node ast.Expr) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, node); err != nil {
+		log.Fatalf("could not print type: %v", err)
+	}
+	return buf.String()
+}
@@ -0,0 +1,314 @@
+package player
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jedruniu/spotify-cli/pkg/web"
+	tui "github.com/marcusolsson/tui-go"
+	"github.com/zmb3/spotify"
+)
+
+var (
+	recentlyPlayedLimit = 50
+	// historyDedupeWindow is a time in which track played in this session and
+	// track reported by Spotify as recently played are treated as the same play.
+	historyDedupeWindow = 10 * time.Minute
+)
+
+type historyEntry struct {
+	playedAt time.Time
+	track    string
+	artist   string
+	trackURI spotify.URI
+	context  spotify.URI
+}
+
+// History represents view with listening history. It merges tracks which
+// Spotify reports as recently played with tracks played in this session,
+// which Spotify reports with a delay.
+type History struct {
+	client SpotifyClient
+	table  *virtualTable
+	box    *tui.Box
+
+	mu             sync.Mutex
+	recentlyPlayed []historyEntry
+	session        []historyEntry
+	entries        []historyEntry
+	// offline tells that recently played tracks could not be fetched,
+	// because Spotify was not reachable, see ConnectionChanged.
+	offline bool
+
+	// playerStateChanges are followed when app runs, see Start.
+	playerStateChanges <-chan *web.WebPlaybackState
+	*errorReporter
+}
+
+// NewHistory creates History view with recently played tracks, tracks played
+// in this session are captured from player state changes after it starts.
+func NewHistory(client SpotifyClient, playerStateChanges <-chan *web.WebPlaybackState) (*History, error) {
+	history := &History{
+		client: client,
+
+		playerStateChanges: playerStateChanges,
+		errorReporter:      &errorReporter{},
+	}
+	table := newVirtualTable(history, viewRows)
+	table.SetColumnStretch(0, 2)
+	table.SetColumnStretch(1, 6)
+	table.SetColumnStretch(2, 4)
+	table.SetColumnStretch(3, 2)
+	table.setHeader(
+		tui.NewLabel("Played at"),
+		tui.NewLabel("Track"),
+		tui.NewLabel("Artist"),
+		tui.NewLabel("Context"),
+	)
+
+	box := tui.NewVBox(table, tui.NewSpacer())
+	box.SetSizePolicy(tui.Expanding, tui.Expanding)
+	history.table, history.box = table, box
+
+	table.OnItemActivated(history.onItemActivated())
+	table.onKey("r", func(*tui.Table) {
+		if err := history.fetchRecentlyPlayed(); err != nil {
+			log.Printf("Could not refresh history: %s", err)
+		}
+	})
+
+	err := history.fetchRecentlyPlayed()
+	if isConnectionError(err) {
+		log.Printf("Could not fetch history: %s", err)
+		history.offline = true
+		box.SetTitle("Offline, history is loaded when connection returns")
+		return history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// ConnectionChanged fetches recently played tracks in background, when they
+// could not be fetched while Spotify was not reachable. History is redrawn
+// with update.
+func (h *History) ConnectionChanged(online bool, update func(func())) {
+	if !online || !h.offline {
+		return
+	}
+	h.offline = false
+	go func() {
+		recentlyPlayed, err := h.recentlyPlayedEntries()
+		update(func() {
+			if err != nil {
+				h.offline = isConnectionError(err)
+				h.reportError("Could not fetch history: %s", err)
+				return
+			}
+			h.setRecentlyPlayed(recentlyPlayed)
+		})
+	}()
+}
+
+// Start captures tracks played in this session, history is redrawn with
+// update, which applies changes in UI goroutine.
+func (h *History) Start(update func(func())) {
+	go func() {
+		for state := range h.playerStateChanges {
+			if h.record(state, time.Now()) {
+				update(h.redraw)
+			}
+		}
+	}()
+}
+
+// Title returns name of the view.
+func (h *History) Title() string {
+	return "History"
+}
+
+// Widget returns widget in which view is displayed.
+func (h *History) Widget() tui.Widget {
+	return h.box
+}
+
+// Focusables returns widgets of the view which can be focused.
+func (h *History) Focusables() []tui.Widget {
+	return []tui.Widget{h.table}
+}
+
+func (h *History) fetchRecentlyPlayed() error {
+	recentlyPlayed, err := h.recentlyPlayedEntries()
+	if err != nil {
+		return err
+	}
+	h.setRecentlyPlayed(recentlyPlayed)
+	return nil
+}
+
+// recentlyPlayedEntries fetches tracks which Spotify reports as recently
+// played, it does not change the history.
+func (h *History) recentlyPlayedEntries() ([]historyEntry, error) {
+	items, err := h.client.PlayerRecentlyPlayedOpt(&spotify.RecentlyPlayedOptions{Limit: recentlyPlayedLimit})
+	if err != nil {
+		return nil, wrapError(err, "could not fetch recently played tracks: %v", err)
+	}
+	recentlyPlayed := make([]historyEntry, 0, len(items))
+	for _, item := range items {
+		recentlyPlayed = append(recentlyPlayed, historyEntry{
+			playedAt: item.PlayedAt,
+			track:    item.Track.Name,
+			artist:   artistName(item.Track.Artists),
+			trackURI: item.Track.URI,
+			context:  item.PlaybackContext.URI,
+		})
+	}
+	return recentlyPlayed, nil
+}
+
+func (h *History) setRecentlyPlayed(recentlyPlayed []historyEntry) {
+	h.mu.Lock()
+	h.recentlyPlayed = recentlyPlayed
+	h.mu.Unlock()
+	h.refresh()
+}
+
+// record adds track from player state to the history, unless it is
+// the same track which was recorded last time. It tells whether track was
+// added, history has to be redrawn then.
+func (h *History) record(state *web.WebPlaybackState, at time.Time) bool {
+	if state == nil || state.CurrentTrackName == "" {
+		return false
+	}
+	entry := historyEntry{
+		playedAt: at,
+		track:    state.CurrentTrackName,
+		artist:   state.CurrentArtistName,
+		trackURI: spotify.URI(state.CurrentTrackURI),
+		context:  spotify.URI(state.ContextURI),
+	}
+	h.mu.Lock()
+	if last := len(h.session) - 1; last >= 0 && h.session[last].track == entry.track && h.session[last].trackURI == entry.trackURI {
+		h.mu.Unlock()
+		return false
+	}
+	h.session = append(h.session, entry)
+	h.entries = mergeHistory(h.recentlyPlayed, h.session)
+	h.mu.Unlock()
+	return true
+}
+
+func (h *History) refresh() {
+	h.mu.Lock()
+	h.entries = mergeHistory(h.recentlyPlayed, h.session)
+	h.mu.Unlock()
+	h.redraw()
+}
+
+// redraw displays history again, selected row stays selected.
+func (h *History) redraw() {
+	h.table.refresh()
+	h.mu.Lock()
+	count := len(h.entries)
+	h.mu.Unlock()
+	h.box.SetTitle(fmt.Sprintf("%d tracks", count))
+}
+
+func (h *History) rowCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries)
+}
+
+func (h *History) row(idx int) []tui.Widget {
+	h.mu.Lock()
+	entry := h.entries[idx]
+	h.mu.Unlock()
+	return []tui.Widget{
+		tui.NewLabel(entry.playedAt.Local().Format("Jan 2 15:04")),
+		tui.NewLabel(trimWithCommasIfTooLong(entry.track, uiColumnWidth)),
+		tui.NewLabel(trimWithCommasIfTooLong(entry.artist, uiColumnWidth)),
+		tui.NewLabel(contextType(entry.context)),
+	}
+}
+
+// mergeHistory merges tracks reported by Spotify with tracks played in this
+// session, newest first. Session tracks which were already reported by
+// Spotify are skipped.
+func mergeHistory(recentlyPlayed, session []historyEntry) []historyEntry {
+	merged := append([]historyEntry{}, recentlyPlayed...)
+	for _, entry := range session {
+		duplicate := false
+		for _, reported := range recentlyPlayed {
+			diff := entry.playedAt.Sub(reported.playedAt)
+			if diff < 0 {
+				diff = -diff
+			}
+			if reported.trackURI == entry.trackURI && diff < historyDedupeWindow {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			merged = append(merged, entry)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].playedAt.After(merged[j].playedAt)
+	})
+	return merged
+}
+
+func (h *History) onItemActivated() func(*tui.Table) {
+	return func(*tui.Table) {
+		idx := h.table.selectedRow()
+		h.mu.Lock()
+		if idx < 0 || idx >= len(h.entries) {
+			h.mu.Unlock()
+			return
+		}
+		opt := replayOptions(h.entries, idx)
+		uri := h.entries[idx].trackURI
+		h.mu.Unlock()
+
+		err := h.client.PlayOpt(opt)
+		if err != nil {
+			h.reportError("Could not replay history from %s: %s", uri, err)
+		}
+	}
+}
+
+// replayOptions returns options which play history starting with entry
+// with given index. When track was played from album or playlist, playback
+// continues in it, otherwise all tracks played after it are played.
+func replayOptions(entries []historyEntry, idx int) *spotify.PlayOptions {
+	entry := entries[idx]
+	if entry.context != "" {
+		context := entry.context
+		return &spotify.PlayOptions{
+			PlaybackContext: &context,
+			PlaybackOffset:  &spotify.PlaybackOffset{URI: entry.trackURI},
+		}
+	}
+	uris := make([]spotify.URI, 0, idx+1)
+	for i := idx; i >= 0; i-- { // entries are ordered from the newest
+		if entries[i].trackURI != "" {
+			uris = append(uris, entries[i].trackURI)
+		}
+	}
+	return &spotify.PlayOptions{URIs: uris}
+}
+
+// contextType returns type of the context, i.e. "album" for spotify:album:ID.
+func contextType(uri spotify.URI) string {
+	parts := strings.Split(string(uri), ":")
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[len(parts)-2]
+}
@@ -0,0 +1,124 @@
+package player
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jedruniu/spotify-cli/pkg/web"
+	"github.com/marcusolsson/tui-go"
+	"github.com/zmb3/spotify"
+)
+
+func TestNewHistoryFetchesRecentlyPlayed(t *testing.T) {
+	history, err := NewHistory(NewDebugClient(), make(chan *web.WebPlaybackState))
+	if err != nil {
+		t.Fatalf("Did not expect to fail, but it did with %s", err)
+	}
+	if len(history.entries) != 20 {
+		t.Fatalf("Expected to have 20 history entries, have %d", len(history.entries))
+	}
+}
+
+func TestHistoryRecordsOnlyTrackChanges(t *testing.T) {
+	history := &History{box: tui.NewVBox()}
+	history.table = newVirtualTable(history, viewRows)
+	now := time.Now()
+	states := []*web.WebPlaybackState{
+		{CurrentTrackName: "First", CurrentTrackURI: "spotify:track:1"},
+		{CurrentTrackName: "First", CurrentTrackURI: "spotify:track:1"}, // i.e. paused
+		nil,
+		{CurrentTrackName: "Second", CurrentTrackURI: "spotify:track:2"},
+		{CurrentTrackName: "First", CurrentTrackURI: "spotify:track:1"},
+	}
+	for i, state := range states {
+		history.record(state, now.Add(time.Duration(i)*time.Minute))
+	}
+	if len(history.session) != 3 {
+		t.Fatalf("Expected to record 3 tracks, recorded %d", len(history.session))
+	}
+	if history.entries[0].track != "First" || history.entries[1].track != "Second" {
+		t.Fatalf("Expected entries to be ordered from the newest")
+	}
+}
+
+func TestHistoryIsRedrawnWithUpdate(t *testing.T) {
+	states := make(chan *web.WebPlaybackState)
+	history := &History{box: tui.NewVBox(), playerStateChanges: states}
+	history.table = newVirtualTable(history, viewRows)
+	updates := make(chan func())
+	history.Start(func(fn func()) { updates <- fn })
+
+	states <- &web.WebPlaybackState{CurrentTrackName: "First", CurrentTrackURI: "spotify:track:1"}
+	select {
+	case fn := <-updates:
+		if history.table.selectedRow() == 0 {
+			t.Fatalf("Expected history not to be redrawn outside of update")
+		}
+		fn()
+	case <-time.After(time.Second):
+		t.Fatalf("Expected history to be redrawn with update")
+	}
+	if history.table.selectedRow() != 0 {
+		t.Fatalf("Expected recorded track to be displayed, selected row is %d", history.table.selectedRow())
+	}
+	close(states)
+}
+
+func TestMergeHistory(t *testing.T) {
+	now := time.Now()
+	recentlyPlayed := []historyEntry{
+		{playedAt: now.Add(-5 * time.Minute), trackURI: "a"},
+		{playedAt: now.Add(-60 * time.Minute), trackURI: "b"},
+	}
+	session := []historyEntry{
+		{playedAt: now.Add(-61 * time.Minute), trackURI: "b"}, // already reported by Spotify
+		{playedAt: now.Add(-2 * time.Minute), trackURI: "c"},
+		{playedAt: now.Add(-30 * time.Minute), trackURI: "a"}, // played again
+	}
+	merged := mergeHistory(recentlyPlayed, session)
+
+	uris := []spotify.URI{}
+	for _, entry := range merged {
+		uris = append(uris, entry.trackURI)
+	}
+	expected := []spotify.URI{"c", "a", "a", "b"}
+	if !reflect.DeepEqual(uris, expected) {
+		t.Fatalf("Expected merged history to be %v, got %v", expected, uris)
+	}
+}
+
+func TestReplayOptions(t *testing.T) {
+	entries := []historyEntry{
+		{trackURI: "newest"},
+		{trackURI: "middle", context: "spotify:album:1"},
+		{trackURI: "oldest"},
+	}
+
+	opt := replayOptions(entries, 1)
+	if opt.PlaybackContext == nil || *opt.PlaybackContext != "spotify:album:1" || opt.PlaybackOffset.URI != "middle" {
+		t.Fatalf("Expected to replay album starting from the track, got %#v", opt)
+	}
+
+	opt = replayOptions(entries, 2)
+	expected := []spotify.URI{"oldest", "middle", "newest"}
+	if opt.PlaybackContext != nil || !reflect.DeepEqual(opt.URIs, expected) {
+		t.Fatalf("Expected to replay %v, got %#v", expected, opt)
+	}
+}
+
+func TestContextType(t *testing.T) {
+	cases := []struct {
+		uri          spotify.URI
+		expectedType string
+	}{
+		{"spotify:album:1", "album"},
+		{"spotify:user:someone:playlist:1", "playlist"},
+		{"", ""},
+	}
+	for _, c := range cases {
+		if got := contextType(c.uri); got != c.expectedType {
+			t.Errorf("Expected type of %s to be %s, got %s", c.uri, c.expectedType, got)
+		}
+	}
+}
@@ -8,12 +8,20 @@ type SpotifyClient interface {
 	UserAlbumFetcher
 	Player
 	Searcher
+	TrackLibrary
+	AlbumLibrary
+	PlaylistLibrary
+	ArtistFollower
+	TopItemsFetcher
+	Recommender
+	PodcastLibrary
 	Pause() error
 	Previous() error
 	Next() error
 	PlayerCurrentlyPlaying() (*spotify.CurrentlyPlaying, error)
+	PlayerRecentlyPlayedOpt(opt *spotify.RecentlyPlayedOptions) ([]spotify.RecentlyPlayedItem, error)
 	PlayerDevices() ([]spotify.PlayerDevice, error)
-	TransferPlayback(spotify.ID, bool) error
+	TransferPlayback(deviceID spotify.ID, play bool) error
 }
 
 type Player interface {
@@ -22,9 +30,62 @@ type Player interface {
 }
 
 type Searcher interface {
-	Search(string, spotify.SearchType) (*spotify.SearchResult, error)
+	Search(query string, t spotify.SearchType) (*spotify.SearchResult, error)
+	SearchOpt(query string, t spotify.SearchType, opt *spotify.Options) (*spotify.SearchResult, error)
+	// GetAlbums fetches details of found albums, which are not a part of search results.
+	GetAlbums(ids ...spotify.ID) ([]*spotify.FullAlbum, error)
 }
 
 type UserAlbumFetcher interface {
 	CurrentUsersAlbumsOpt(opt *spotify.Options) (*spotify.SavedAlbumPage, error)
 }
+
+// TrackLibrary gives access to tracks saved in user's "Your Music" library.
+type TrackLibrary interface {
+	CurrentUsersTracksOpt(opt *spotify.Options) (*spotify.SavedTrackPage, error)
+	UserHasTracks(ids ...spotify.ID) ([]bool, error)
+	AddTracksToLibrary(ids ...spotify.ID) error
+	RemoveTracksFromLibrary(ids ...spotify.ID) error
+}
+
+// AlbumLibrary allows to change which albums are saved in user's library.
+type AlbumLibrary interface {
+	UserHasAlbums(ids ...spotify.ID) ([]bool, error)
+	AddAlbumsToLibrary(ids ...spotify.ID) error
+	RemoveAlbumsFromLibrary(ids ...spotify.ID) error
+}
+
+// PlaylistLibrary gives access to playlists which user owns or follows.
+type PlaylistLibrary interface {
+	CurrentUsersPlaylistsOpt(opt *spotify.Options) (*spotify.SimplePlaylistPage, error)
+}
+
+// ArtistFollower allows to change which artists are followed by user.
+type ArtistFollower interface {
+	CurrentUserFollows(t string, ids ...spotify.ID) ([]bool, error)
+	FollowArtist(ids ...spotify.ID) error
+	UnfollowArtist(ids ...spotify.ID) error
+}
+
+// TopItemsFetcher gives access to user's top artists and tracks,
+// Options.Timerange selects "short", "medium" or "long" term.
+type TopItemsFetcher interface {
+	CurrentUsersTopArtistsOpt(opt *spotify.Options) (*spotify.FullArtistPage, error)
+	CurrentUsersTopTracksOpt(opt *spotify.Options) (*spotify.FullTrackPage, error)
+}
+
+// Recommender gives access to tracks recommended for seed tracks and artists.
+type Recommender interface {
+	GetRecommendations(seeds spotify.Seeds, trackAttributes *spotify.TrackAttributes, opt *spotify.Options) (*spotify.Recommendations, error)
+	GetAlbumTracksOpt(id spotify.ID, opt *spotify.Options) (*spotify.SimpleTrackPage, error)
+}
+
+// PodcastLibrary gives access to shows and episodes, which are not
+// supported by spotify library.
+type PodcastLibrary interface {
+	CurrentUsersShows(limit, offset int) (*SavedShowPage, error)
+	GetShowEpisodes(id spotify.ID, limit, offset int) (*EpisodePage, error)
+	SearchPodcasts(query string, limit, offset int) (*PodcastSearchResult, error)
+	PlayEpisode(uri spotify.URI, positionMs int) error
+	PlayerCurrentlyPlayingEpisode() (*Episode, error)
+}
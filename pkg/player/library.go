@@ -0,0 +1,298 @@
+package player
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/zmb3/spotify"
+)
+
+// spotifyContainsBatchSize is the maximum number of IDs which Spotify
+// accepts in single "contains" request.
+var spotifyContainsBatchSize = 50
+
+// Library keeps saved state of items from user's library. It is shared
+// between views, so that saving track in one of them is reflected in others.
+type Library struct {
+	tracks  *savedItems
+	albums  *savedItems
+	artists *savedItems
+
+	// cache keeps saved albums and liked songs between runs, it is nil
+	// when library is not cached.
+	cache   *LibraryCache
+	pending *pendingChanges
+
+	*errorReporter
+}
+
+// NewLibrary creates empty Library, saved state of items is looked up lazily.
+func NewLibrary(client SpotifyClient) *Library {
+	library := &Library{
+		tracks: newSavedItems("track", client.UserHasTracks, client.AddTracksToLibrary, client.RemoveTracksFromLibrary),
+		albums: newSavedItems("album", client.UserHasAlbums, client.AddAlbumsToLibrary, client.RemoveAlbumsFromLibrary),
+		artists: newSavedItems(
+			"artist",
+			func(ids ...spotify.ID) ([]bool, error) { return client.CurrentUserFollows("artist", ids...) },
+			client.FollowArtist,
+			client.UnfollowArtist,
+		),
+		pending:       &pendingChanges{},
+		errorReporter: &errorReporter{},
+	}
+	for _, items := range []*savedItems{library.tracks, library.albums, library.artists} {
+		items.pending = library.pending
+		items.reporter = library.errorReporter
+	}
+	return library
+}
+
+// SetUpdater sets function which applies results of changes of the library
+// made in background in UI goroutine, i.e. ui.Update.
+func (l *Library) SetUpdater(update func(func())) {
+	for _, items := range []*savedItems{l.tracks, l.albums, l.artists} {
+		items.update = update
+	}
+}
+
+// SetCache makes views display items of the library from the cache when they
+// are created, and keep the cache up to date. It has to be called before
+// views are created.
+func (l *Library) SetCache(cache *LibraryCache) {
+	l.cache = cache
+	l.pending.load(cache)
+	for _, change := range cache.pendingChanges() {
+		if items, ok := l.itemsOfKind(change.Kind); ok {
+			items.mu.Lock()
+			items.saved[change.ID] = change.Saved
+			items.mu.Unlock()
+		}
+	}
+}
+
+func (l *Library) itemsOfKind(kind string) (*savedItems, bool) {
+	items, ok := map[string]*savedItems{"track": l.tracks, "album": l.albums, "artist": l.artists}[kind]
+	return items, ok
+}
+
+// libraryItem describes item which saved state changes, so that views
+// displaying saved items can add it without fetching it again.
+type libraryItem struct {
+	id     spotify.ID
+	uri    spotify.URI
+	name   string
+	artist string
+}
+
+// savedItems keeps saved state of one kind of items: tracks, albums or artists
+// (for which being saved means being followed).
+type savedItems struct {
+	kind   string
+	has    func(ids ...spotify.ID) ([]bool, error)
+	add    func(ids ...spotify.ID) error
+	remove func(ids ...spotify.ID) error
+
+	mu        sync.Mutex
+	saved     map[spotify.ID]bool
+	listeners []func(libraryItem, bool)
+	// pending keeps changes which could not be made because Spotify was
+	// not reachable, they are not kept when it is nil.
+	pending *pendingChanges
+	// reporter tells user about changes which are kept until Spotify is
+	// reachable, they are only logged when it is nil.
+	reporter *errorReporter
+	// update applies results of changes made in background in UI
+	// goroutine, see Library.SetUpdater.
+	update func(func())
+}
+
+func newSavedItems(kind string, has func(...spotify.ID) ([]bool, error), add, remove func(...spotify.ID) error) *savedItems {
+	return &savedItems{
+		kind:   kind,
+		has:    has,
+		add:    add,
+		remove: remove,
+		saved:  map[spotify.ID]bool{},
+		update: func(fn func()) { fn() },
+	}
+}
+
+// onChange registers function which is called every time saved state
+// of an item changes.
+func (items *savedItems) onChange(fn func(libraryItem, bool)) {
+	items.mu.Lock()
+	defer items.mu.Unlock()
+	items.listeners = append(items.listeners, fn)
+}
+
+// lookup asks Spotify whether given items are saved in user's library.
+// Items which state is already known are skipped, remaining ones are looked
+// up in batches.
+func (items *savedItems) lookup(ids []spotify.ID) error {
+	items.mu.Lock()
+	unknown := make([]spotify.ID, 0)
+	seen := map[spotify.ID]bool{}
+	for _, id := range ids {
+		if _, known := items.saved[id]; known || id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unknown = append(unknown, id)
+	}
+	items.mu.Unlock()
+
+	for start := 0; start < len(unknown); start += spotifyContainsBatchSize {
+		end := start + spotifyContainsBatchSize
+		if end > len(unknown) {
+			end = len(unknown)
+		}
+		batch := unknown[start:end]
+		saved, err := items.has(batch...)
+		if err != nil {
+			return fmt.Errorf("could not check if %ss are saved: %v", items.kind, err)
+		}
+		if len(saved) != len(batch) {
+			return fmt.Errorf("asked about %d %ss, got answer about %d", len(batch), items.kind, len(saved))
+		}
+		items.mu.Lock()
+		for i, id := range batch {
+			items.saved[id] = saved[i]
+		}
+		items.mu.Unlock()
+	}
+	return nil
+}
+
+// markSaved records that items are saved, i.e. because they were
+// fetched from user's library.
+func (items *savedItems) markSaved(ids ...spotify.ID) {
+	items.mu.Lock()
+	defer items.mu.Unlock()
+	for _, id := range ids {
+		items.saved[id] = true
+	}
+}
+
+func (items *savedItems) isSaved(id spotify.ID) bool {
+	items.mu.Lock()
+	defer items.mu.Unlock()
+	return items.saved[id]
+}
+
+// set saves or removes item. Listeners are notified about the change before
+// Spotify is asked to make it in background, so UI is updated immediately,
+// and once again with the previous state when Spotify fails, so UI can be
+// rolled back. When Spotify is not reachable, change is kept and made when
+// connection returns. done is called with error of the change, rollback and
+// done are applied with update.
+func (items *savedItems) set(item libraryItem, saved bool, done func(error)) {
+	items.mu.Lock()
+	previous, known := items.saved[item.id]
+	items.saved[item.id] = saved
+	items.mu.Unlock()
+	items.notify(item, saved)
+
+	update := items.update
+	go func() {
+		err := items.apply(item.id, saved)
+		update(func() {
+			if isConnectionError(err) && items.pending != nil {
+				items.pending.add(newPendingChange(items.kind, item, saved))
+				items.reporter.reportError("Spotify is not reachable, %s %s will be changed when connection returns", items.kind, item.name)
+				done(nil)
+				return
+			}
+			if err != nil {
+				items.mu.Lock()
+				if known {
+					items.saved[item.id] = previous
+				} else {
+					delete(items.saved, item.id)
+				}
+				items.mu.Unlock()
+				items.notify(item, previous)
+				done(fmt.Errorf("could not change saved state of %s %s: %v", items.kind, item.id, err))
+				return
+			}
+			done(nil)
+		})
+	}()
+}
+
+// apply asks Spotify to save or remove item.
+func (items *savedItems) apply(id spotify.ID, saved bool) error {
+	if saved {
+		return items.add(id)
+	}
+	return items.remove(id)
+}
+
+// revert brings back saved state of item from before the change, which
+// Spotify refused.
+func (items *savedItems) revert(item libraryItem, saved bool) {
+	items.mu.Lock()
+	items.saved[item.id] = !saved
+	items.mu.Unlock()
+	items.notify(item, !saved)
+}
+
+// toggle saves item when it is not saved and removes it otherwise, like set.
+// Saved state of the item is looked up in background first, when it is not
+// known. done is called with saved state of the item after the change, and
+// error of the change.
+func (items *savedItems) toggle(item libraryItem, done func(bool, error)) {
+	items.mu.Lock()
+	saved, known := items.saved[item.id]
+	items.mu.Unlock()
+	if !known {
+		update := items.update
+		go func() {
+			err := items.lookup([]spotify.ID{item.id})
+			update(func() {
+				if err != nil {
+					done(false, err)
+					return
+				}
+				items.toggle(item, done)
+			})
+		}()
+		return
+	}
+	items.set(item, !saved, func(err error) {
+		if err != nil {
+			done(saved, err)
+			return
+		}
+		done(!saved, nil)
+	})
+}
+
+func (items *savedItems) notify(item libraryItem, saved bool) {
+	items.mu.Lock()
+	listeners := append([]func(libraryItem, bool){}, items.listeners...)
+	items.mu.Unlock()
+	for _, listener := range listeners {
+		listener(item, saved)
+	}
+}
+
+func heart(saved bool) string {
+	if saved {
+		return "♥"
+	}
+	return "♡"
+}
+
+func followMark(followed bool) string {
+	if followed {
+		return "✓"
+	}
+	return "+"
+}
+
+// idFromURI extracts ID from URI like spotify:track:6rqhFgbbKwnb9MLmUQDhG6.
+func idFromURI(uri spotify.URI) spotify.ID {
+	parts := strings.Split(string(uri), ":")
+	return spotify.ID(parts[len(parts)-1])
+}
@@ -0,0 +1,152 @@
+package player
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/zmb3/spotify"
+)
+
+type FakeTrackLibrary struct {
+	*DebugTrackLibrary
+	hasTracksCalls [][]spotify.ID
+	modifyError    bool
+}
+
+func (fake *FakeTrackLibrary) UserHasTracks(ids ...spotify.ID) ([]bool, error) {
+	fake.hasTracksCalls = append(fake.hasTracksCalls, ids)
+	return fake.DebugTrackLibrary.UserHasTracks(ids...)
+}
+
+func (fake *FakeTrackLibrary) AddTracksToLibrary(ids ...spotify.ID) error {
+	if fake.modifyError {
+		return fmt.Errorf("error")
+	}
+	return fake.DebugTrackLibrary.AddTracksToLibrary(ids...)
+}
+
+func (fake *FakeTrackLibrary) RemoveTracksFromLibrary(ids ...spotify.ID) error {
+	if fake.modifyError {
+		return fmt.Errorf("error")
+	}
+	return fake.DebugTrackLibrary.RemoveTracksFromLibrary(ids...)
+}
+
+func TestLookupTracksAsksInBatches(t *testing.T) {
+	fakeLibrary := &FakeTrackLibrary{DebugTrackLibrary: NewDebugTrackLibrary(10)}
+	client := &DebugClient{TrackLibrary: fakeLibrary}
+	library := NewLibrary(client)
+
+	ids := make([]spotify.ID, 0)
+	for i := 0; i < 120; i++ {
+		ids = append(ids, spotify.ID(fmt.Sprintf("savedtrack%d", i)))
+	}
+	ids = append(ids, ids[0]) // duplicates are asked about once
+
+	err := library.tracks.lookup(ids)
+	if err != nil {
+		t.Fatalf("Did not expect to fail, but it did with %s", err)
+	}
+	if len(fakeLibrary.hasTracksCalls) != 3 {
+		t.Fatalf("Expected UserHasTracks() to be called 3 times, it was called %d times", len(fakeLibrary.hasTracksCalls))
+	}
+	for i, expectedLen := range []int{50, 50, 20} {
+		if len(fakeLibrary.hasTracksCalls[i]) != expectedLen {
+			t.Errorf("Expected call %d to ask about %d tracks, it asked about %d", i, expectedLen, len(fakeLibrary.hasTracksCalls[i]))
+		}
+	}
+	if !library.tracks.isSaved("savedtrack1") || library.tracks.isSaved("savedtrack11") {
+		t.Errorf("Expected only first 10 tracks to be saved")
+	}
+
+	err = library.tracks.lookup(ids)
+	if err != nil {
+		t.Fatalf("Did not expect to fail, but it did with %s", err)
+	}
+	if len(fakeLibrary.hasTracksCalls) != 3 {
+		t.Fatalf("Expected known tracks not to be asked about again, but UserHasTracks() was called")
+	}
+}
+
+func TestToggleTrack(t *testing.T) {
+	cases := []struct {
+		id            spotify.ID
+		modifyError   bool
+		expectedSaved bool
+		expectedError bool
+	}{
+		{id: "savedtrack1", expectedSaved: false},
+		{id: "notsaved", expectedSaved: true},
+		{id: "savedtrack1", modifyError: true, expectedSaved: true, expectedError: true},
+	}
+	for _, c := range cases {
+		fakeLibrary := &FakeTrackLibrary{DebugTrackLibrary: NewDebugTrackLibrary(1), modifyError: c.modifyError}
+		library := NewLibrary(&DebugClient{TrackLibrary: fakeLibrary})
+
+		var saved bool
+		var err error
+		toggled := false
+		updates := make(chan func())
+		library.SetUpdater(func(fn func()) { updates <- fn })
+		library.tracks.toggle(libraryItem{id: c.id}, func(s bool, e error) {
+			saved, err, toggled = s, e, true
+		})
+		waitForUpdates(t, updates, func() bool { return toggled })
+		if (err != nil) != c.expectedError {
+			t.Fatalf("Expected error to be %v, got %v", c.expectedError, err)
+		}
+		if saved != c.expectedSaved || library.tracks.isSaved(c.id) != c.expectedSaved {
+			t.Errorf("Expected track %s to be saved: %v, but it was not", c.id, c.expectedSaved)
+		}
+	}
+}
+
+func TestIDFromURI(t *testing.T) {
+	cases := []struct {
+		uri spotify.URI
+		id  spotify.ID
+	}{
+		{"spotify:track:6rqhFgbbKwnb9MLmUQDhG6", "6rqhFgbbKwnb9MLmUQDhG6"},
+		{"6rqhFgbbKwnb9MLmUQDhG6", "6rqhFgbbKwnb9MLmUQDhG6"},
+		{"", ""},
+	}
+	for _, c := range cases {
+		if id := idFromURI(c.uri); id != c.id {
+			t.Errorf("Expected ID of %s to be %s, got %s", c.uri, c.id, id)
+		}
+	}
+}
+
+func TestSetNotifiesListenersAndRollsBack(t *testing.T) {
+	cases := []struct {
+		modifyError          bool
+		expectedNotification []bool
+	}{
+		{modifyError: false, expectedNotification: []bool{true}},
+		{modifyError: true, expectedNotification: []bool{true, false}},
+	}
+	for _, c := range cases {
+		fakeLibrary := &FakeTrackLibrary{DebugTrackLibrary: NewDebugTrackLibrary(0), modifyError: c.modifyError}
+		library := NewLibrary(&DebugClient{TrackLibrary: fakeLibrary})
+		notifications := []bool{}
+		library.tracks.onChange(func(item libraryItem, saved bool) {
+			notifications = append(notifications, saved)
+		})
+
+		updates := make(chan func())
+		library.SetUpdater(func(fn func()) { updates <- fn })
+		done := false
+		library.tracks.set(libraryItem{id: "track"}, true, func(error) { done = true })
+		if len(notifications) != 1 {
+			t.Errorf("Expected listeners to be notified before Spotify is asked, got %v", notifications)
+		}
+		waitForUpdates(t, updates, func() bool { return done })
+		if !reflect.DeepEqual(notifications, c.expectedNotification) {
+			t.Errorf("Expected listeners to be notified with %v, got %v", c.expectedNotification, notifications)
+		}
+		if library.tracks.isSaved("track") != !c.modifyError {
+			t.Errorf("Expected saved state to be %v", !c.modifyError)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package player
+
+import (
+	"fmt"
+	"log"
+
+	tui "github.com/marcusolsson/tui-go"
+	"github.com/zmb3/spotify"
+)
+
+var likedSongsPageSize = 20
+
+// trackDescription holds what is displayed about a liked song, and what is
+// needed to play it, so that only that is cached.
+type trackDescription struct {
+	id     spotify.ID
+	uri    spotify.URI
+	name   string
+	artist string
+	album  string
+}
+
+func savedTrackDescription(track spotify.SavedTrack) trackDescription {
+	return trackDescription{
+		id:     track.ID,
+		uri:    track.URI,
+		name:   track.Name,
+		artist: artistName(track.Artists),
+		album:  track.Album.Name,
+	}
+}
+
+func savedTrackDescriptions(tracks []spotify.SavedTrack) []trackDescription {
+	descriptions := make([]trackDescription, 0, len(tracks))
+	for _, track := range tracks {
+		descriptions = append(descriptions, savedTrackDescription(track))
+	}
+	return descriptions
+}
+
+// LikedSongs represents view with tracks saved in user's library.
+// Tracks are fetched from Spotify page by page, when user scrolls to them.
+type LikedSongs struct {
+	client  SpotifyClient
+	library *Library
+	table   *virtualTable
+	box     *tui.Box
+
+	tracks []trackDescription
+	total  int
+	// cached tells that tracks were read from the cache, and they are not
+	// synchronized with Spotify yet.
+	cached bool
+	// loading tells that tracks which are about to be displayed are fetched
+	// in background, they are displayed with update.
+	loading bool
+	update  func(func())
+
+	*radioStarter
+	*errorReporter
+}
+
+// NewLikedSongs creates Liked Songs view with cached saved tracks, or with
+// the first page of them. Cached tracks, or tracks which could not be fetched
+// because Spotify was not reachable, are synchronized with Sync.
+func NewLikedSongs(client SpotifyClient, library *Library) (*LikedSongs, error) {
+	likedSongs := &LikedSongs{
+		client:  client,
+		library: library,
+		tracks:  []trackDescription{},
+		update:  func(fn func()) { fn() },
+
+		radioStarter:  &radioStarter{},
+		errorReporter: &errorReporter{},
+	}
+	if tracks, total, ok := library.cache.tracks(); ok {
+		likedSongs.tracks, likedSongs.total, likedSongs.cached = tracks, total, true
+		for _, track := range tracks {
+			library.tracks.markSaved(track.id)
+		}
+	}
+	err := likedSongs.fetchUntil(likedSongsPageSize)
+	if err != nil && !isConnectionError(err) {
+		return nil, err
+	}
+
+	table := newVirtualTable(likedSongs, likedSongsPageSize)
+	table.SetColumnStretch(0, 1)
+	table.SetColumnStretch(1, 6)
+	table.SetColumnStretch(2, 4)
+	table.SetColumnStretch(3, 4)
+	table.OnItemActivated(likedSongs.onItemActivated())
+	table.onKey("l", likedSongs.onToggleSaved())
+	table.onKey("r", func(*tui.Table) {
+		if track, _, ok := likedSongs.selectedTrack(); ok {
+			likedSongs.startRadio(RadioSeed{Type: "track", ID: track.id, Name: track.name})
+		}
+	})
+	box := tui.NewVBox(table, tui.NewSpacer())
+	box.SetSizePolicy(tui.Expanding, tui.Expanding)
+	likedSongs.table, likedSongs.box = table, box
+
+	table.setHeader(
+		tui.NewLabel(""),
+		tui.NewLabel("Title"),
+		tui.NewLabel("Artist"),
+		tui.NewLabel("Album"),
+	)
+	table.reset()
+	likedSongs.renderTitle()
+	// hearts are displayed again when track is liked anywhere in the application
+	library.tracks.onChange(func(libraryItem, bool) { table.refresh() })
+	if err != nil {
+		log.Printf("Could not fetch liked songs: %s", err)
+		// tracks are fetched with Sync when connection returns
+		likedSongs.cached = true
+		box.SetTitle("Offline, liked songs are loaded when connection returns")
+	}
+	return likedSongs, nil
+}
+
+// Title returns name of the view.
+func (ls *LikedSongs) Title() string {
+	return "Liked Songs"
+}
+
+// Widget returns widget in which view is displayed.
+func (ls *LikedSongs) Widget() tui.Widget {
+	return ls.box
+}
+
+// Focusables returns widgets of the view which can be focused.
+func (ls *LikedSongs) Focusables() []tui.Widget {
+	return []tui.Widget{ls.table}
+}
+
+// fetchUntil fetches pages of saved tracks until there are at least n of them
+// or there is nothing more to fetch.
+func (ls *LikedSongs) fetchUntil(n int) error {
+	tracks, total, err := ls.fetchTracks(len(ls.tracks), n)
+	if total >= 0 {
+		ls.appendTracks(tracks, total)
+	}
+	return err
+}
+
+// fetchTracks fetches pages of saved tracks from offset until tracks up to
+// end are fetched or there is nothing more to fetch. It returns fetched
+// tracks and the number of all saved tracks, which is -1 when no page was
+// fetched. Liked songs are not changed, so it can be run in background.
+func (ls *LikedSongs) fetchTracks(offset, end int) ([]trackDescription, int, error) {
+	tracks := []trackDescription{}
+	total := -1
+	for offset+len(tracks) < end && (total < 0 || offset+len(tracks) < total) {
+		pageOffset := offset + len(tracks)
+		page, err := ls.client.CurrentUsersTracksOpt(&spotify.Options{Limit: &likedSongsPageSize, Offset: &pageOffset})
+		if err != nil {
+			return tracks, total, wrapError(err, "could not fetch saved tracks: %v", err)
+		}
+		total = page.Total
+		tracks = append(tracks, savedTrackDescriptions(page.Tracks)...)
+		if len(page.Tracks) == 0 {
+			break
+		}
+	}
+	return tracks, total, nil
+}
+
+// appendTracks adds fetched tracks after the ones which are already
+// fetched, and caches them.
+func (ls *LikedSongs) appendTracks(tracks []trackDescription, total int) {
+	ls.total = total
+	if len(tracks) == 0 {
+		// there are no more tracks, even if Spotify counts more of them
+		ls.total = len(ls.tracks)
+		return
+	}
+	ls.tracks = append(ls.tracks, tracks...)
+	for _, track := range tracks {
+		ls.library.tracks.markSaved(track.id)
+	}
+	ls.saveCache()
+}
+
+// Sync synchronizes cached tracks with Spotify in background. When the only
+// change since tracks were cached is that some were added, only they are
+// added to cached ones, otherwise tracks are fetched again from the first
+// page. Tracks are displayed with update, which applies them in UI
+// goroutine, i.e. ui.Update.
+func (ls *LikedSongs) Sync(update func(func())) {
+	if !ls.cached {
+		return
+	}
+	cached, cachedTotal := ls.tracks, ls.total
+	go func() {
+		offset := 0
+		page, err := ls.client.CurrentUsersTracksOpt(&spotify.Options{Limit: &likedSongsPageSize, Offset: &offset})
+		if isConnectionError(err) {
+			log.Printf("Liked songs are synchronized when connection returns: %s", err)
+			return
+		}
+		if err != nil {
+			update(func() { ls.reportError("Could not synchronize liked songs: %s", err) })
+			return
+		}
+		tracks := savedTrackDescriptions(page.Tracks)
+		if added, ok := addedSince(trackURIs(cached), trackURIs(tracks), cachedTotal, page.Total); ok {
+			tracks = append(tracks[:added:added], cached...)
+		}
+		update(func() { ls.setTracks(tracks, page.Total) })
+	}()
+}
+
+// Start synchronizes cached tracks when app runs, see Sync. Tracks which
+// are fetched when user scrolls to them are displayed with update too.
+func (ls *LikedSongs) Start(update func(func())) {
+	ls.update = update
+	ls.Sync(update)
+}
+
+// ConnectionChanged synchronizes cached tracks when Spotify is reachable
+// again, see Sync.
+func (ls *LikedSongs) ConnectionChanged(online bool, update func(func())) {
+	if online {
+		ls.Sync(update)
+	}
+}
+
+// setTracks displays synchronized tracks and caches them, selected row
+// stays selected.
+func (ls *LikedSongs) setTracks(tracks []trackDescription, total int) {
+	ls.tracks, ls.total, ls.cached = tracks, total, false
+	for _, track := range tracks {
+		ls.library.tracks.markSaved(track.id)
+	}
+	ls.saveCache()
+	ls.table.refresh()
+	ls.renderTitle()
+}
+
+func (ls *LikedSongs) saveCache() {
+	if err := ls.library.cache.setTracks(ls.tracks, ls.total); err != nil {
+		log.Printf("Could not cache liked songs: %s", err)
+	}
+}
+
+func trackURIs(tracks []trackDescription) []spotify.URI {
+	uris := make([]spotify.URI, 0, len(tracks))
+	for _, track := range tracks {
+		uris = append(uris, track.uri)
+	}
+	return uris
+}
+
+// rowCount returns number of all liked songs, also the ones which are not
+// fetched yet, so that liked songs are a source of rows of their table.
+func (ls *LikedSongs) rowCount() int {
+	return ls.total
+}
+
+// loadRows fetches liked songs which are about to be displayed in
+// background, they are displayed as placeholders until they are fetched.
+func (ls *LikedSongs) loadRows(end int) {
+	if ls.loading || end <= len(ls.tracks) || len(ls.tracks) >= ls.total {
+		return
+	}
+	ls.loading = true
+	offset := len(ls.tracks)
+	go func() {
+		tracks, total, err := ls.fetchTracks(offset, end)
+		ls.update(func() {
+			ls.loading = false
+			if err != nil {
+				log.Printf("Could not fetch liked songs up to %d: %s", end, err)
+			}
+			if total < 0 || len(ls.tracks) != offset {
+				return // tracks were synchronized meanwhile
+			}
+			ls.appendTracks(tracks, total)
+			ls.table.refresh()
+			ls.renderTitle()
+		})
+	}()
+}
+
+// row returns cells of liked song with given index, songs which could not
+// be fetched are displayed as empty rows.
+func (ls *LikedSongs) row(idx int) []tui.Widget {
+	if idx >= len(ls.tracks) {
+		return []tui.Widget{tui.NewLabel(""), tui.NewLabel("…"), tui.NewLabel(""), tui.NewLabel("")}
+	}
+	track := ls.tracks[idx]
+	return []tui.Widget{
+		tui.NewLabel(heart(ls.library.tracks.isSaved(track.id))),
+		tui.NewLabel(trimWithCommasIfTooLong(track.name, uiColumnWidth)),
+		tui.NewLabel(trimWithCommasIfTooLong(track.artist, uiColumnWidth)),
+		tui.NewLabel(trimWithCommasIfTooLong(track.album, uiColumnWidth)),
+	}
+}
+
+func (ls *LikedSongs) renderTitle() {
+	ls.box.SetTitle(fmt.Sprintf("%d tracks", ls.total))
+}
+
+// selectedTrack returns selected saved track and its index, it returns
+// false when there is no such track or it is not fetched yet.
+func (ls *LikedSongs) selectedTrack() (trackDescription, int, bool) {
+	idx := ls.table.selectedRow()
+	if idx < 0 || idx >= len(ls.tracks) {
+		return trackDescription{}, 0, false
+	}
+	return ls.tracks[idx], idx, true
+}
+
+func (ls *LikedSongs) onItemActivated() func(*tui.Table) {
+	return func(*tui.Table) {
+		track, idx, ok := ls.selectedTrack()
+		if !ok {
+			return
+		}
+		uris := trackURIs(ls.tracks[idx:])
+		err := ls.client.PlayOpt(&spotify.PlayOptions{URIs: uris})
+		if err != nil {
+			ls.reportError("Could not play liked song: %s", track.uri)
+		}
+	}
+}
+
+func (ls *LikedSongs) onToggleSaved() func(*tui.Table) {
+	return func(*tui.Table) {
+		track, _, ok := ls.selectedTrack()
+		if !ok {
+			return
+		}
+		ls.library.tracks.toggle(libraryItem{id: track.id, uri: track.uri, name: track.name}, func(_ bool, err error) {
+			if err != nil {
+				ls.reportError("Could not toggle liked song: %s", err)
+			}
+		})
+	}
+}
+
+func artistName(artists []spotify.SimpleArtist) string {
+	if len(artists) == 0 {
+		return ""
+	}
+	return artists[0].Name
+}
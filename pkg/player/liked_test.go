@@ -0,0 +1,191 @@
+package player
+
+import (
+	"testing"
+	"time"
+
+	tui "github.com/marcusolsson/tui-go"
+	"github.com/zmb3/spotify"
+)
+
+type FakeTracksPlayer struct {
+	FakePlayer
+	givenOptions *spotify.PlayOptions
+}
+
+func (fake *FakeTracksPlayer) PlayOpt(opt *spotify.PlayOptions) error {
+	fake.givenOptions = opt
+	return fake.FakePlayer.PlayOpt(opt)
+}
+
+func TestNewLikedSongsFetchesOnlyFirstPage(t *testing.T) {
+	client := &DebugClient{TrackLibrary: NewDebugTrackLibrary(45)}
+	likedSongs, err := NewLikedSongs(client, NewLibrary(client))
+	if err != nil {
+		t.Fatalf("Did not expect to fail, but it did with %s", err)
+	}
+	if len(likedSongs.tracks) != likedSongsPageSize {
+		t.Fatalf("Expected to fetch %d tracks, fetched %d", likedSongsPageSize, len(likedSongs.tracks))
+	}
+	if likedSongs.rowCount() != 45 {
+		t.Fatalf("Expected to have 45 rows, have %d", likedSongs.rowCount())
+	}
+}
+
+func TestLikedSongsAreFetchedWhenScrolled(t *testing.T) {
+	client := &DebugClient{TrackLibrary: NewDebugTrackLibrary(45)}
+	likedSongs, _ := NewLikedSongs(client, NewLibrary(client))
+	updates := make(chan func())
+	likedSongs.Start(func(fn func()) { updates <- fn })
+
+	cases := []struct {
+		row                 int
+		expectedRow         int
+		expectedTracksCount int
+	}{
+		{row: 44, expectedRow: 44, expectedTracksCount: 45},
+		{row: 45, expectedRow: 44, expectedTracksCount: 45}, // there is no such row
+		{row: 0, expectedRow: 0, expectedTracksCount: 45},   // already fetched
+		{row: -1, expectedRow: 0, expectedTracksCount: 45},
+	}
+	for _, c := range cases {
+		likedSongs.table.selectRow(c.row)
+		waitForUpdates(t, updates, func() bool { return len(likedSongs.tracks) == c.expectedTracksCount })
+		if likedSongs.table.selectedRow() != c.expectedRow {
+			t.Errorf("Expected row %d to be selected, selected %d", c.expectedRow, likedSongs.table.selectedRow())
+		}
+		if len(likedSongs.tracks) != c.expectedTracksCount {
+			t.Errorf("Expected to have %d tracks fetched, have %d", c.expectedTracksCount, len(likedSongs.tracks))
+		}
+	}
+}
+
+func TestLikedSongsOnItemActivatedPlaysFromSelectedTrack(t *testing.T) {
+	fakePlayer := &FakeTracksPlayer{}
+	client := &DebugClient{TrackLibrary: NewDebugTrackLibrary(45), Player: fakePlayer}
+	likedSongs, _ := NewLikedSongs(client, NewLibrary(client))
+	updates := make(chan func())
+	likedSongs.Start(func(fn func()) { updates <- fn })
+	likedSongs.table.selectRow(22) // scrolled to, so the second page is fetched
+	waitForUpdates(t, updates, func() bool { return len(likedSongs.tracks) == 40 })
+	likedSongs.onItemActivated()(likedSongs.table.Table)
+
+	if fakePlayer.playOptCalls != 1 {
+		t.Fatalf("Expected PlayOpt() to be called once, it was called %d times", fakePlayer.playOptCalls)
+	}
+	uris := fakePlayer.givenOptions.URIs
+	if len(uris) != 40-22 || uris[0] != "spotify:track:savedtrack23" {
+		t.Fatalf("Expected to play 18 loaded tracks starting from 23rd, got %v", uris)
+	}
+}
+
+func TestLikedSongsToggleSaved(t *testing.T) {
+	client := &DebugClient{TrackLibrary: NewDebugTrackLibrary(5)}
+	library := NewLibrary(client)
+	likedSongs, _ := NewLikedSongs(client, library)
+	updates, toggled := make(chan func()), 0
+	library.SetUpdater(func(fn func()) { updates <- func() { fn(); toggled++ } })
+
+	likedSongs.table.selectRow(0)
+	likedSongs.onToggleSaved()(likedSongs.table.Table)
+	if library.tracks.isSaved("savedtrack1") {
+		t.Fatalf("Expected track to be removed from library, but it was not")
+	}
+	waitForUpdates(t, updates, func() bool { return toggled == 1 })
+	likedSongs.onToggleSaved()(likedSongs.table.Table)
+	waitForUpdates(t, updates, func() bool { return toggled == 2 })
+	if !library.tracks.isSaved("savedtrack1") {
+		t.Fatalf("Expected track to be saved again, but it was not")
+	}
+}
+
+func TestLikedSongsAreSynchronizedWithCache(t *testing.T) {
+	tracks := savedTrackDescriptions(constructNSpotifySavedTracks(45))
+	cases := []struct {
+		name                string
+		cached              []trackDescription
+		cachedTotal         int
+		expectedTracksCount int
+	}{
+		{name: "added", cached: tracks[2:22], cachedTotal: 43, expectedTracksCount: 22},
+		{name: "removed", cached: tracks[:20], cachedTotal: 46, expectedTracksCount: likedSongsPageSize},
+	}
+	for _, c := range cases {
+		cache, cleanup := newTempCache(t)
+		cache.setTracks(c.cached, c.cachedTotal)
+		client := &DebugClient{TrackLibrary: NewDebugTrackLibrary(45)}
+		library := NewLibrary(client)
+		library.SetCache(cache)
+		likedSongs, err := NewLikedSongs(client, library)
+		if err != nil {
+			t.Fatalf("Did not expect to fail, but it did with %s", err)
+		}
+		if likedSongs.total != c.cachedTotal || likedSongs.tracks[0].uri != c.cached[0].uri {
+			t.Errorf("%s: expected cached tracks to be displayed, got %d tracks", c.name, likedSongs.total)
+		}
+
+		updates := make(chan func())
+		likedSongs.Sync(func(fn func()) { updates <- fn })
+		select {
+		case fn := <-updates:
+			fn()
+		case <-time.After(time.Second):
+			t.Fatalf("%s: expected liked songs to be synchronized", c.name)
+		}
+		if likedSongs.total != 45 || len(likedSongs.tracks) != c.expectedTracksCount || likedSongs.tracks[0].uri != "spotify:track:savedtrack1" {
+			t.Errorf("%s: expected %d of 45 tracks, got %d of %d", c.name, c.expectedTracksCount, len(likedSongs.tracks), likedSongs.total)
+		}
+		if cached, total, _ := cache.tracks(); len(cached) != c.expectedTracksCount || total != 45 {
+			t.Errorf("%s: expected synchronized tracks to be cached, got %d of %d", c.name, len(cached), total)
+		}
+		cleanup()
+	}
+}
+
+func TestLikedSongsAreLoadedAfterReconnect(t *testing.T) {
+	connection := NewConnection(false)
+	client := NewConnectedClient(&DebugClient{TrackLibrary: NewDebugTrackLibrary(45)}, connection)
+	likedSongs, err := NewLikedSongs(client, NewLibrary(client))
+	if err != nil {
+		t.Fatalf("Expected liked songs to be created offline, got %s", err)
+	}
+	if len(likedSongs.tracks) != 0 {
+		t.Fatalf("Expected no tracks to be fetched offline, got %d", len(likedSongs.tracks))
+	}
+
+	connection.set(true)
+	updates := make(chan func())
+	likedSongs.Sync(func(fn func()) { updates <- fn })
+	select {
+	case fn := <-updates:
+		fn()
+	case <-time.After(time.Second):
+		t.Fatalf("Expected liked songs to be loaded")
+	}
+	if len(likedSongs.tracks) != likedSongsPageSize || likedSongs.total != 45 {
+		t.Errorf("Expected the first page of 45 tracks to be loaded, got %d of %d", len(likedSongs.tracks), likedSongs.total)
+	}
+}
+
+func TestLikedSongsArePlaceholdersUntilFetched(t *testing.T) {
+	client := &DebugClient{TrackLibrary: NewDebugTrackLibrary(45)}
+	likedSongs, _ := NewLikedSongs(client, NewLibrary(client))
+	updates := make(chan func())
+	likedSongs.Start(func(fn func()) { updates <- fn })
+
+	likedSongs.table.selectRow(44)
+	if label := likedSongs.row(44)[1].(*tui.Label); label.Text() != "…" {
+		t.Fatalf("Expected placeholder to be displayed until track is fetched, got %q", label.Text())
+	}
+	// tracks which are being fetched are not fetched again
+	likedSongs.table.selectRow(43)
+	select {
+	case fn := <-updates:
+		fn()
+	case <-time.After(time.Second):
+		t.Fatalf("Expected tracks to be fetched")
+	}
+	if label := likedSongs.row(44)[1].(*tui.Label); label.Text() == "…" || likedSongs.loading {
+		t.Errorf("Expected fetched track to be displayed, got %q", label.Text())
+	}
+}
@@ -0,0 +1,321 @@
+package player
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/zmb3/spotify"
+)
+
+// ErrOffline is returned instead of calling Spotify when it is not reachable.
+var ErrOffline = errors.New("Spotify is not reachable")
+
+// reachabilityTimeout is how long checking whether Spotify is reachable waits
+// for an answer.
+var reachabilityTimeout = 5 * time.Second
+
+// isConnectionError tells whether err means that Spotify could not be
+// reached, rather than that it refused the request. Failures of Spotify,
+// i.e. 5xx responses, are retried by RetryTransport and they do not mean
+// that the app is offline.
+func isConnectionError(err error) bool {
+	switch e := err.(type) {
+	case nil:
+		return false
+	case *url.Error:
+		// i.e. token which could not be refreshed is not a connection error
+		return isConnectionError(e.Err)
+	case net.Error:
+		return true
+	case connectionError:
+		return true
+	}
+	return err == ErrOffline
+}
+
+// connectionError describes error which means that Spotify could not
+// be reached.
+type connectionError struct {
+	error
+}
+
+// wrapError describes err like fmt.Errorf does, but it is still a connection
+// error when err is.
+func wrapError(err error, format string, args ...interface{}) error {
+	wrapped := fmt.Errorf(format, args...)
+	if isConnectionError(err) {
+		return connectionError{wrapped}
+	}
+	return wrapped
+}
+
+// CheckSpotifyReachable checks whether Spotify Web API answers, even
+// unauthorized requests, so that it can be checked before user logs in.
+func CheckSpotifyReachable() error {
+	return checkReachable(&http.Client{Timeout: reachabilityTimeout}, spotifyAPIBaseURL)
+}
+
+func checkReachable(client *http.Client, url string) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return spotify.Error{Status: resp.StatusCode, Message: resp.Status}
+	}
+	return nil
+}
+
+// Connection tells whether Spotify is reachable. While it is not, the app
+// works offline: library is displayed from the cache, playback is unavailable
+// and changes of the library are queued until connection returns.
+type Connection struct {
+	mu        sync.Mutex
+	online    bool
+	listeners []func(bool)
+}
+
+// NewConnection creates connection which is initially online or offline.
+func NewConnection(online bool) *Connection {
+	return &Connection{online: online}
+}
+
+// Online tells whether Spotify is reachable.
+func (c *Connection) Online() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.online
+}
+
+// OnChange registers function which is called when connection is lost or
+// returns. It is called in its own goroutine, as connection changes while
+// Spotify is called, also from UI goroutine.
+func (c *Connection) OnChange(fn func(online bool)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, fn)
+}
+
+func (c *Connection) set(online bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.online == online {
+		return
+	}
+	c.online = online
+	log.Printf("Connection with Spotify changed, online: %t", online)
+	for _, listener := range c.listeners {
+		go listener(online)
+	}
+}
+
+// Watch tries to reconnect every interval while connection is offline,
+// connection is online again when reconnect succeeds. Watching stops when
+// stop is closed.
+func (c *Connection) Watch(interval time.Duration, reconnect func() error, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if c.Online() {
+			continue
+		}
+		if err := reconnect(); err != nil {
+			log.Printf("Could not reconnect with Spotify: %s", err)
+			continue
+		}
+		c.set(true)
+	}
+}
+
+// ConnectedClient is a SpotifyClient which tracks connection with Spotify.
+// Connection is lost when a call fails because Spotify is not reachable,
+// afterwards calls fail at once with ErrOffline until connection returns.
+// Wrapped client may be set later, i.e. when app starts offline and user
+// logs in when connection returns. Its methods which call Spotify are
+// generated from SpotifyClient.
+//
+//go:generate go run gen_connected_client.go
+type ConnectedClient struct {
+	connection *Connection
+
+	mu     sync.Mutex
+	client SpotifyClient
+}
+
+// NewConnectedClient wraps client, which may be nil until SetClient is called.
+func NewConnectedClient(client SpotifyClient, connection *Connection) *ConnectedClient {
+	return &ConnectedClient{client: client, connection: connection}
+}
+
+// SetClient replaces wrapped client, i.e. with newly authenticated one.
+func (c *ConnectedClient) SetClient(client SpotifyClient) {
+	c.mu.Lock()
+	c.client = client
+	c.mu.Unlock()
+}
+
+// HasClient tells whether client was set.
+func (c *ConnectedClient) HasClient() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client != nil
+}
+
+// WithContext returns client whose requests are cancelled when ctx is done,
+// it tracks the same connection.
+func (c *ConnectedClient) WithContext(ctx context.Context) SpotifyClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client == nil {
+		return c
+	}
+	return NewConnectedClient(withContext(ctx, c.client), c.connection)
+}
+
+func (c *ConnectedClient) current() (SpotifyClient, error) {
+	c.mu.Lock()
+	client := c.client
+	c.mu.Unlock()
+	if client == nil || !c.connection.Online() {
+		return nil, ErrOffline
+	}
+	return client, nil
+}
+
+// observe marks connection as lost when err means that Spotify could not
+// be reached.
+func (c *ConnectedClient) observe(err error) error {
+	if isConnectionError(err) {
+		c.connection.set(false)
+	}
+	return err
+}
+
+var _ SpotifyClient = &ConnectedClient{}
+
+// pendingChange is a change of saved state of an item of the library, which
+// is made when connection with Spotify returns.
+type pendingChange struct {
+	Kind   string      `json:"kind"`
+	ID     spotify.ID  `json:"id"`
+	URI    spotify.URI `json:"uri"`
+	Name   string      `json:"name"`
+	Artist string      `json:"artist"`
+	Saved  bool        `json:"saved"`
+}
+
+func newPendingChange(kind string, item libraryItem, saved bool) pendingChange {
+	return pendingChange{Kind: kind, ID: item.id, URI: item.uri, Name: item.name, Artist: item.artist, Saved: saved}
+}
+
+func (change pendingChange) item() libraryItem {
+	return libraryItem{id: change.ID, uri: change.URI, name: change.Name, artist: change.Artist}
+}
+
+// pendingChanges are changes of the library in order in which they were
+// made, they are kept in the cache so that they are not lost when the app
+// is closed before connection returns.
+type pendingChanges struct {
+	mu      sync.Mutex
+	changes []pendingChange
+	cache   *LibraryCache
+}
+
+func (p *pendingChanges) load(cache *LibraryCache) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cache = cache
+	p.changes = cache.pendingChanges()
+}
+
+// add queues the change, previous change of the same item is replaced.
+func (p *pendingChanges) add(change pendingChange) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.changes = append(p.without(change), change)
+	p.save()
+}
+
+// first returns the oldest change, it returns false when there are none.
+func (p *pendingChanges) first() (pendingChange, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.changes) == 0 {
+		return pendingChange{}, false
+	}
+	return p.changes[0], true
+}
+
+// done removes the change after it was made, unless item changed again.
+func (p *pendingChanges) done(change pendingChange) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, c := range p.changes {
+		if c == change {
+			p.changes = append(p.changes[:i:i], p.changes[i+1:]...)
+			break
+		}
+	}
+	p.save()
+}
+
+func (p *pendingChanges) without(change pendingChange) []pendingChange {
+	changes := []pendingChange{}
+	for _, c := range p.changes {
+		if c.Kind != change.Kind || c.ID != change.ID {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+func (p *pendingChanges) save() {
+	if err := p.cache.setPendingChanges(p.changes); err != nil {
+		log.Printf("Could not keep changes of library: %s", err)
+	}
+}
+
+// SyncPending makes changes of the library which were made while Spotify
+// was not reachable, in background. Changes which Spotify refuses are
+// rolled back with update, which applies them in UI goroutine, i.e. ui.Update.
+func (l *Library) SyncPending(update func(func())) {
+	go l.syncPending(update)
+}
+
+func (l *Library) syncPending(update func(func())) {
+	for {
+		change, ok := l.pending.first()
+		if !ok {
+			return
+		}
+		items, known := l.itemsOfKind(change.Kind)
+		if !known {
+			l.pending.done(change)
+			continue
+		}
+		err := items.apply(change.ID, change.Saved)
+		if isConnectionError(err) {
+			return // change is made when connection returns again
+		}
+		l.pending.done(change)
+		if err != nil {
+			update(func() {
+				items.revert(change.item(), change.Saved)
+				l.reportError("Could not change saved state of %s %s: %s", change.Kind, change.Name, err)
+			})
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package player
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	tui "github.com/marcusolsson/tui-go"
+	"github.com/zmb3/spotify"
+)
+
+var errUnreachable = &url.Error{Op: "Put", URL: spotifyAPIBaseURL, Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+
+// FakeUnreachableTrackLibrary fails to change library while Spotify is
+// unreachable, and refuses to change given track.
+type FakeUnreachableTrackLibrary struct {
+	*DebugTrackLibrary
+	unreachable bool
+	refused     spotify.ID
+	calls       int
+}
+
+func (fake *FakeUnreachableTrackLibrary) change(id spotify.ID, change func(...spotify.ID) error) error {
+	fake.calls++
+	switch {
+	case fake.unreachable:
+		return errUnreachable
+	case id == fake.refused:
+		return spotify.Error{Status: http.StatusForbidden, Message: "refused"}
+	}
+	return change(id)
+}
+
+func (fake *FakeUnreachableTrackLibrary) AddTracksToLibrary(ids ...spotify.ID) error {
+	return fake.change(ids[0], fake.DebugTrackLibrary.AddTracksToLibrary)
+}
+
+func (fake *FakeUnreachableTrackLibrary) RemoveTracksFromLibrary(ids ...spotify.ID) error {
+	return fake.change(ids[0], fake.DebugTrackLibrary.RemoveTracksFromLibrary)
+}
+
+func TestIsConnectionError(t *testing.T) {
+	cases := []struct {
+		err      error
+		expected bool
+	}{
+		{err: nil},
+		{err: fmt.Errorf("error")},
+		{err: errUnreachable, expected: true},
+		{err: &url.Error{Op: "Get", URL: spotifyAPIBaseURL, Err: errors.New("oauth2: token expired")}},
+		{err: spotify.Error{Status: http.StatusServiceUnavailable}},
+		{err: spotify.Error{Status: http.StatusUnauthorized}},
+		{err: ErrOffline, expected: true},
+		{err: wrapError(errUnreachable, "could not fetch: %v", errUnreachable), expected: true},
+		{err: wrapError(fmt.Errorf("error"), "could not fetch: %v", "error")},
+	}
+	for _, c := range cases {
+		if isConnectionError(c.err) != c.expected {
+			t.Errorf("Expected %v to be a connection error: %t", c.err, c.expected)
+		}
+	}
+	if err := wrapError(errUnreachable, "could not fetch: %v", errUnreachable); !strings.HasPrefix(err.Error(), "could not fetch: Put") {
+		t.Errorf("Expected wrapped error to be described, got %s", err)
+	}
+}
+
+func TestCheckReachable(t *testing.T) {
+	status := http.StatusUnauthorized
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	if err := checkReachable(server.Client(), server.URL); err != nil {
+		t.Errorf("Expected Spotify answering unauthorized request to be reachable, got %s", err)
+	}
+	status = http.StatusBadGateway
+	if err := checkReachable(server.Client(), server.URL); err == nil {
+		t.Errorf("Expected Spotify failing to be unreachable, got %v", err)
+	}
+	server.Close()
+	if err := checkReachable(server.Client(), server.URL); !isConnectionError(err) {
+		t.Errorf("Expected closed server to be unreachable, got %v", err)
+	}
+}
+
+func TestConnectedClientTracksConnection(t *testing.T) {
+	fake := &FakeUnreachableTrackLibrary{DebugTrackLibrary: NewDebugTrackLibrary(5), unreachable: true}
+	connection := NewConnection(true)
+	changes := make(chan bool, 2)
+	connection.OnChange(func(online bool) { changes <- online })
+	client := NewConnectedClient(&DebugClient{TrackLibrary: fake}, connection)
+
+	if err := client.AddTracksToLibrary("savedtrack1"); err != errUnreachable {
+		t.Fatalf("Expected error of the call, got %v", err)
+	}
+	if connection.Online() || <-changes {
+		t.Fatalf("Expected connection to be lost")
+	}
+	if err := client.AddTracksToLibrary("savedtrack1"); err != ErrOffline || fake.calls != 1 {
+		t.Errorf("Expected offline client to fail without calling Spotify, got %v after %d calls", err, fake.calls)
+	}
+
+	fake.unreachable = false
+	attempts := 0
+	stop := make(chan struct{})
+	defer close(stop)
+	go connection.Watch(time.Millisecond, func() error {
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("not yet")
+		}
+		return nil
+	}, stop)
+	select {
+	case online := <-changes:
+		if !online {
+			t.Fatalf("Expected connection to return")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected connection to return")
+	}
+	if err := client.AddTracksToLibrary("savedtrack1"); err != nil || fake.calls != 2 {
+		t.Errorf("Expected client to call Spotify again, got %v after %d calls", err, fake.calls)
+	}
+}
+
+func TestOfflineLibraryChangesAreQueued(t *testing.T) {
+	var str bytes.Buffer
+	log.SetOutput(&str)
+
+	cache, cleanup := newTempCache(t)
+	defer cleanup()
+	fake := &FakeUnreachableTrackLibrary{DebugTrackLibrary: NewDebugTrackLibrary(10), unreachable: true, refused: "savedtrack7"}
+	connection := NewConnection(true)
+	client := NewConnectedClient(&DebugClient{TrackLibrary: fake}, connection)
+	library := NewLibrary(client)
+	library.SetCache(cache)
+
+	changes := []struct {
+		id    spotify.ID
+		saved bool
+	}{
+		{id: "savedtrack1", saved: false},
+		{id: "savedtrack7", saved: true},
+		{id: "savedtrack7", saved: false}, // replaces the previous change
+		{id: "savedtrack11", saved: true},
+	}
+	for _, c := range changes {
+		item := libraryItem{id: c.id, uri: spotify.URI("spotify:track:" + c.id), name: string(c.id)}
+		errs := make(chan error)
+		library.tracks.set(item, c.saved, func(err error) { errs <- err })
+		if err := <-errs; err != nil {
+			t.Fatalf("Expected change to be queued, got %s", err)
+		}
+	}
+	if connection.Online() || !strings.Contains(str.String(), "Spotify is not reachable, track savedtrack1 will be changed when connection returns") {
+		t.Errorf("Expected user to be told that change is queued, log was %s", str.String())
+	}
+
+	loaded, _ := LoadLibraryCache(cache.path)
+	restarted := NewLibrary(client)
+	restarted.SetCache(loaded)
+	if len(loaded.pendingChanges()) != 3 || restarted.tracks.isSaved("savedtrack1") || !restarted.tracks.isSaved("savedtrack11") {
+		t.Fatalf("Expected changes to be kept after restart, got %v", loaded.pendingChanges())
+	}
+
+	fake.unreachable = false
+	connection.set(true)
+	restarted.syncPending(func(fn func()) { fn() })
+	if saved, _ := fake.UserHasTracks("savedtrack1", "savedtrack11"); saved[0] || !saved[1] {
+		t.Errorf("Expected queued changes to be made, got %v", saved)
+	}
+	if !restarted.tracks.isSaved("savedtrack7") || !strings.Contains(str.String(), "Could not change saved state of track savedtrack7") {
+		t.Errorf("Expected refused change to be rolled back and reported, log was %s", str.String())
+	}
+	if pending := loaded.pendingChanges(); len(pending) != 0 {
+		t.Errorf("Expected no changes to be pending, got %v", pending)
+	}
+}
+
+func TestAlbumsAreLoadedAfterReconnect(t *testing.T) {
+	fetcher := &FakePagedAlbumFetcher{total: 60, failOffset: -1}
+	connection := NewConnection(false)
+	client := NewConnectedClient(&DebugClient{UserAlbumFetcher: fetcher}, connection)
+	sideBar, err := NewSideBar(client, NewLibrary(client))
+	if err != nil {
+		t.Fatalf("Expected sidebar to be created offline, got %s", err)
+	}
+	albumList := sideBar.AlbumList
+	sideBar.LoadAlbums(func(fn func()) { fn() })
+	if len(albumList.albumsDescriptions) != 0 || albumList.title() != "User albums (offline)" {
+		t.Fatalf("Expected albums not to be loaded offline, got %d albums and title %q", len(albumList.albumsDescriptions), albumList.title())
+	}
+
+	connection.set(true)
+	updates := make(chan func())
+	sideBar.Reconnect(func(fn func()) { updates <- fn })
+	if albumList.title() != "User albums (syncing)" {
+		t.Errorf("Expected albums to be synchronized, got title %q", albumList.title())
+	}
+	select {
+	case fn := <-updates:
+		fn()
+	case <-time.After(time.Second):
+		t.Fatalf("Expected albums to be loaded")
+	}
+	if len(albumList.albumsDescriptions) != 60 || albumList.title() != "User albums" {
+		t.Errorf("Expected all albums to be loaded, got %d albums and title %q", len(albumList.albumsDescriptions), albumList.title())
+	}
+}
+
+func TestPlaybackIsUnavailableOffline(t *testing.T) {
+	connection := NewConnection(false)
+	client := NewConnectedClient(NewDebugClient(), connection)
+	playback := NewPlayback(client, NewLibrary(client), nil, "")
+	status := NewStatusLine()
+	playback.SetStatusLine(status)
+	updates := make(chan func(), 1)
+	update := func(fn func()) { updates <- fn }
+
+	playback.ConnectionChanged(false, update)
+	if playback.label.Text() != "Offline" || len(playback.Devices.devices) != 0 {
+		t.Errorf("Expected playback to be unavailable, got %q and %d devices", playback.label.Text(), len(playback.Devices.devices))
+	}
+	playback.Playback.Play.SetFocused(true)
+	playback.Playback.Play.OnKeyEvent(tui.KeyEvent{Key: tui.KeyEnter})
+	if !strings.Contains(status.Label.Text(), "unavailable") {
+		t.Errorf("Expected unavailable playback to be reported, got %q", status.Label.Text())
+	}
+
+	connection.set(true)
+	playback.ConnectionChanged(true, update)
+	select {
+	case fn := <-updates:
+		fn()
+	case <-time.After(time.Second):
+		t.Fatalf("Expected playback to be refreshed")
+	}
+	if playback.label.Text() == "Offline" || len(playback.Devices.devices) == 0 {
+		t.Errorf("Expected playback to be available, got %q and %d devices", playback.label.Text(), len(playback.Devices.devices))
+	}
+}
+
+// connectionListener is a view which is loaded when connection returns.
+type connectionListener interface {
+	ConnectionChanged(online bool, update func(func()))
+}
+
+func TestViewsAreLoadedWhenConnectionReturns(t *testing.T) {
+	cases := []struct {
+		name   string
+		create func(SpotifyClient) (connectionListener, error)
+		loaded func(connectionListener) bool
+	}{
+		{
+			name:   "history",
+			create: func(client SpotifyClient) (connectionListener, error) { return NewHistory(client, nil) },
+			loaded: func(view connectionListener) bool { return len(view.(*History).entries) > 0 },
+		},
+		{
+			name:   "top",
+			create: func(client SpotifyClient) (connectionListener, error) { return NewTop(client, "") },
+			loaded: func(view connectionListener) bool { return len(view.(*Top).currentItems()) > 0 },
+		},
+		{
+			name:   "podcasts",
+			create: func(client SpotifyClient) (connectionListener, error) { return NewPodcasts(client) },
+			loaded: func(view connectionListener) bool {
+				return len(view.(*Podcasts).savedShows) > 0 && len(view.(*Podcasts).showEpisodes) > 0
+			},
+		},
+	}
+	for _, c := range cases {
+		connection := NewConnection(false)
+		view, err := c.create(NewConnectedClient(NewDebugClient(), connection))
+		if err != nil {
+			t.Fatalf("Expected %s to be created offline, got %s", c.name, err)
+		}
+		if c.loaded(view) {
+			t.Fatalf("Expected %s to be empty offline", c.name)
+		}
+
+		connection.set(true)
+		updates := make(chan func(), 1)
+		view.ConnectionChanged(true, func(fn func()) { updates <- fn })
+		select {
+		case fn := <-updates:
+			fn()
+		case <-time.After(time.Second):
+			t.Fatalf("Expected %s to be loaded", c.name)
+		}
+		if !c.loaded(view) {
+			t.Errorf("Expected %s to be loaded when connection returns", c.name)
+		}
+	}
+}
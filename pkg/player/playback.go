@@ -11,157 +11,401 @@ import (
 	"github.com/zmb3/spotify"
 )
 
+// DevicesTable displays devices on which tracks can be played, playback
+// is transferred to the device which is activated.
 type DevicesTable struct {
 	Table *tui.Table
 	box   *tui.Box
+
+	client      SpotifyClient
+	webPlayerID spotify.ID
+	devices     []spotify.PlayerDevice
 }
 
-type currentlyPlaying struct {
+// CurrentlyPlaying represents box with currently played track, devices on
+// which it can be played and playback buttons.
+type CurrentlyPlaying struct {
 	Box      tui.Widget
-	song     string
-	Devices  DevicesTable
+	Devices  *DevicesTable
 	Playback Playback
+
+	client SpotifyClient
+	box    *tui.Box
+	label  *tui.Label
+	// online tells whether Spotify is reachable, playback can not be
+	// controlled while it is not.
+	online bool
+	// playerStateChanges are displayed when app runs, see Start.
+	playerStateChanges <-chan *web.WebPlaybackState
 }
 
+// playbackTitle is the title of playback box, which tells when playback
+// is unavailable.
+const playbackTitle = "Currently playing"
+
 type Playback struct {
-	Previous *tui.Button
-	Next     *tui.Button
-	Stop     *tui.Button
-	Play     *tui.Button
-	Box      *tui.Box
+	Previous  *tui.Button
+	Next      *tui.Button
+	Stop      *tui.Button
+	Play      *tui.Button
+	Like      *tui.Button
+	SaveAlbum *tui.Button
+	Radio     *tui.Button
+	Box       *tui.Box
+	*radioStarter
+	*errorReporter
 }
 
 // NewPlayback creates data structure representing current spotify playback.
-func NewPlayback(client SpotifyClient, playerStateChanges chan *web.WebPlaybackState, webPlayerID spotify.ID) currentlyPlaying {
+func NewPlayback(client SpotifyClient, library *Library, playerStateChanges chan *web.WebPlaybackState, webPlayerID spotify.ID) *CurrentlyPlaying {
 	currentlyPlayingLabel := tui.NewLabel("")
-	go func() {
-		for {
-			currentState := <-playerStateChanges
-			labelText := fmt.Sprintf(
-				"%s\n%s\n%s",
-				currentState.CurrentTrackName,
-				currentState.CurrentAlbumName,
-				currentState.CurrentArtistName,
-			)
-			currentlyPlayingLabel.SetText(labelText)
-		}
-	}()
-
 	updateCurrentlyPlayingLabel(client, currentlyPlayingLabel)
+	cp := &CurrentlyPlaying{
+		client: client,
+		label:  currentlyPlayingLabel,
+		online: true,
+
+		playerStateChanges: playerStateChanges,
+	}
+	cp.Playback = createPlaybackButtons(client, library, currentlyPlayingLabel, cp.available)
 
 	// TODO handle error
 	_ = transferPlaybackToDevice(client, webPlayerID)
-	availableDevicesTable, err := createAvailableDevicesTable(client, webPlayerID)
-	if err != nil {
-		log.Fatalf("err occured: %v", err)
+	cp.Devices = createAvailableDevicesTable(client, webPlayerID, cp.available, cp.Playback.errorReporter)
+	if err := cp.Devices.refresh(); err != nil {
+		log.Printf("could not fetch available devices, %s", err)
 	}
 
-	playbackButtons := createPlaybackButtons(client, currentlyPlayingLabel)
-
-	currentlyPlayingBox := tui.NewHBox(currentlyPlayingLabel, availableDevicesTable.box, playbackButtons.Box)
+	currentlyPlayingBox := tui.NewHBox(currentlyPlayingLabel, cp.Devices.box, cp.Playback.Box)
 	currentlyPlayingBox.SetBorder(true)
-	currentlyPlayingBox.SetTitle("Currently playing")
-	return currentlyPlaying{
-		Box:      currentlyPlayingBox,
-		Devices:  *availableDevicesTable,
-		Playback: playbackButtons,
+	currentlyPlayingBox.SetTitle(playbackTitle)
+	cp.Box, cp.box = currentlyPlayingBox, currentlyPlayingBox
+	return cp
+}
+
+// Start displays what web player plays, its states are applied with update
+// in UI goroutine.
+func (cp *CurrentlyPlaying) Start(update func(func())) {
+	go func() {
+		for state := range cp.playerStateChanges {
+			state := state
+			update(func() { cp.label.SetText(getStateRepr(state)) })
+		}
+	}()
+}
+
+// available tells whether playback can be controlled, user is told why it
+// can not be.
+func (cp *CurrentlyPlaying) available() bool {
+	if !cp.online {
+		cp.Playback.reportError("Playback is unavailable while Spotify is not reachable")
+	}
+	return cp.online
+}
+
+// SetWebPlayer makes web player, which is ready after app started, the
+// device on which tracks are played. Playback is transferred in background
+// and devices are displayed with update, which applies them in UI
+// goroutine.
+func (cp *CurrentlyPlaying) SetWebPlayer(id spotify.ID, update func(func())) {
+	go func() {
+		if err := transferPlaybackToDevice(cp.client, id); err != nil {
+			log.Printf("could not transfer playback to web player, %s", err)
+		}
+		devices, err := cp.client.PlayerDevices()
+		update(func() {
+			cp.Devices.webPlayerID = id
+			if err != nil {
+				log.Printf("could not fetch available devices, %s", err)
+				return
+			}
+			cp.Devices.setDevices(devices)
+		})
+	}()
+}
+
+// Focusables returns playback buttons and devices table.
+func (cp *CurrentlyPlaying) Focusables() []tui.Widget {
+	p := cp.Playback
+	return []tui.Widget{p.Previous, p.Play, p.Stop, p.Next, p.Like, p.SaveAlbum, p.Radio, cp.Devices.Table}
+}
+
+// OnStartRadio sets function which is called when user starts radio from
+// the current track.
+func (cp *CurrentlyPlaying) OnStartRadio(fn func(RadioSeed)) {
+	cp.Playback.OnStartRadio(fn)
+}
+
+// SetStatusLine sets status line to which errors of playback buttons are
+// reported.
+func (cp *CurrentlyPlaying) SetStatusLine(status *StatusLine) {
+	cp.Playback.SetStatusLine(status)
+}
+
+// ConnectionChanged makes playback available only while Spotify is
+// reachable. When it is again, currently playing track and devices are
+// fetched in background and displayed with update.
+func (cp *CurrentlyPlaying) ConnectionChanged(online bool, update func(func())) {
+	cp.online = online
+	if !online {
+		cp.box.SetTitle(playbackTitle + " (offline, playback unavailable)")
+		cp.label.SetText("Offline")
+		return
 	}
+	cp.box.SetTitle(playbackTitle)
+	go func() {
+		text := currentlyPlayingText(cp.client)
+		devices, err := cp.client.PlayerDevices()
+		update(func() {
+			cp.label.SetText(text)
+			if err != nil {
+				log.Printf("could not fetch available devices, %s", err)
+				return
+			}
+			cp.Devices.setDevices(devices)
+		})
+	}()
 }
 
 func updateCurrentlyPlayingLabel(client SpotifyClient, label *tui.Label) {
+	label.SetText(currentlyPlayingText(client))
+}
+
+// currentlyPlayingText fetches and describes currently played track or
+// episode.
+func currentlyPlayingText(client SpotifyClient) string {
 	currentlyPlaying, err := client.PlayerCurrentlyPlaying()
-	var currentSongName string
 	if err != nil {
 		log.Printf("could not fetch currently playing track - fallback to None, %s", err)
-		currentSongName = "None"
-	} else {
-		currentSongName = getTrackRepr(currentlyPlaying.Item)
+		return "None"
 	}
-	label.SetText(currentSongName)
+	if currentlyPlaying == nil || currentlyPlaying.Item == nil {
+		// spotify library does not decode episodes, they are fetched separately
+		return getEpisodeRepr(currentlyPlayingEpisode(client))
+	}
+	return getTrackRepr(currentlyPlaying.Item)
 }
 
-func createPlaybackButtons(client SpotifyClient, currentlyPlayingLabel *tui.Label) Playback {
+// createPlaybackButtons creates buttons which control playback when it is
+// available, and library actions on currently playing track.
+func createPlaybackButtons(client SpotifyClient, library *Library, currentlyPlayingLabel *tui.Label, available func() bool) Playback {
 	playButton := tui.NewButton("[ ▷ Play]")
 	stopButton := tui.NewButton("[ ■ Stop]")
 	previousButton := tui.NewButton("[ |◄ Previous ]")
 	nextButton := tui.NewButton("[ ►| Next ]")
+	likeButton := tui.NewButton("[ Like ]")
+	likedLabel := tui.NewLabel(heart(false))
+	updateLikedLabel(client, library, likedLabel)
+	saveAlbumButton := tui.NewButton("[ Save album ]")
+	radioButton := tui.NewButton("[ Radio ]")
+	radio := &radioStarter{}
+	reporter := &errorReporter{}
+
+	// control runs action which controls playback, when it is available,
+	// and reports its failure
+	control := func(description string, action func() error) func(*tui.Button) {
+		return func(*tui.Button) {
+			if !available() {
+				return
+			}
+			if err := action(); err != nil {
+				reporter.reportError("Could not %s: %s", description, err)
+			}
+		}
+	}
 
-	playButton.OnActivated(func(btn *tui.Button) {
-		client.Play()
+	playButton.OnActivated(control("play", func() error {
+		if err := client.Play(); err != nil {
+			return err
+		}
 		time.Sleep(time.Millisecond * 500)
 		updateCurrentlyPlayingLabel(client, currentlyPlayingLabel)
+		updateLikedLabel(client, library, likedLabel)
+		return nil
+	}))
+
+	likeButton.OnActivated(func(btn *tui.Button) {
+		track, ok := currentlyPlayingTrack(client)
+		if !ok {
+			return
+		}
+		library.tracks.toggle(libraryItem{id: track.ID, uri: track.URI, name: track.Name}, func(saved bool, err error) {
+			if err != nil {
+				reporter.reportError("Could not toggle saved state of currently playing track: %s", err)
+			}
+			likedLabel.SetText(heart(saved))
+		})
 	})
 
-	stopButton.OnActivated(func(*tui.Button) {
-		client.Pause()
+	saveAlbumButton.OnActivated(func(btn *tui.Button) {
+		track, ok := currentlyPlayingTrack(client)
+		if !ok {
+			return
+		}
+		album := libraryItem{id: track.Album.ID, uri: track.Album.URI, name: track.Album.Name, artist: artistName(track.Album.Artists)}
+		err := library.albums.lookup([]spotify.ID{album.id})
+		if err != nil {
+			log.Printf("Could not check if currently playing album is saved: %s", err)
+			return
+		}
+		if library.albums.isSaved(album.id) {
+			return
+		}
+		library.albums.set(album, true, func(err error) {
+			if err != nil {
+				reporter.reportError("Could not save currently playing album: %s", err)
+			}
+		})
 	})
 
-	previousButton.OnActivated(func(*tui.Button) {
-		client.Previous()
+	radioButton.OnActivated(func(*tui.Button) {
+		track, ok := currentlyPlayingTrack(client)
+		if !ok {
+			return
+		}
+		radio.startRadio(RadioSeed{Type: "track", ID: track.ID, Name: track.Name})
+	})
+
+	stopButton.OnActivated(control("pause", client.Pause))
+
+	previousButton.OnActivated(control("skip to previous track", func() error {
+		if err := client.Previous(); err != nil {
+			return err
+		}
 		time.Sleep(time.Millisecond * 500)
 		updateCurrentlyPlayingLabel(client, currentlyPlayingLabel)
-	})
+		updateLikedLabel(client, library, likedLabel)
+		return nil
+	}))
 
-	nextButton.OnActivated(func(*tui.Button) {
-		client.Next()
+	nextButton.OnActivated(control("skip to next track", func() error {
+		if err := client.Next(); err != nil {
+			return err
+		}
 		time.Sleep(time.Millisecond * 500)
 		updateCurrentlyPlayingLabel(client, currentlyPlayingLabel)
-	})
+		updateLikedLabel(client, library, likedLabel)
+		return nil
+	}))
 
-	buttons := tui.NewHBox(
+	// library actions are in the second row, so that labels of all
+	// buttons fit in the box
+	controls := tui.NewHBox(
 		tui.NewSpacer(),
 		tui.NewPadder(1, 0, previousButton),
 		tui.NewPadder(1, 0, playButton),
 		tui.NewPadder(1, 0, stopButton),
 		tui.NewPadder(1, 0, nextButton),
 	)
+	actions := tui.NewHBox(
+		tui.NewSpacer(),
+		tui.NewPadder(1, 0, likeButton),
+		tui.NewPadder(1, 0, likedLabel),
+		tui.NewPadder(1, 0, saveAlbumButton),
+		tui.NewPadder(1, 0, radioButton),
+	)
+	buttons := tui.NewVBox(controls, actions)
 	buttons.SetBorder(true)
 
 	return Playback{
-		Play:     playButton,
-		Stop:     stopButton,
-		Previous: previousButton,
-		Next:     nextButton,
-		Box:      buttons,
+		Play:          playButton,
+		Stop:          stopButton,
+		Previous:      previousButton,
+		Next:          nextButton,
+		Like:          likeButton,
+		SaveAlbum:     saveAlbumButton,
+		Radio:         radioButton,
+		Box:           buttons,
+		radioStarter:  radio,
+		errorReporter: reporter,
+	}
+}
+
+// updateLikedLabel sets heart in the label according to saved state
+// of currently playing track.
+func updateLikedLabel(client SpotifyClient, library *Library, label *tui.Label) {
+	track, ok := currentlyPlayingTrack(client)
+	if !ok {
+		return
+	}
+	err := library.tracks.lookup([]spotify.ID{track.ID})
+	if err != nil {
+		log.Printf("Could not check if currently playing track is saved: %s", err)
+		return
+	}
+	label.SetText(heart(library.tracks.isSaved(track.ID)))
+}
+
+func currentlyPlayingTrack(client SpotifyClient) (*spotify.FullTrack, bool) {
+	currentlyPlaying, err := client.PlayerCurrentlyPlaying()
+	if err != nil || currentlyPlaying == nil || currentlyPlaying.Item == nil {
+		log.Printf("could not fetch currently playing track, %v", err)
+		return nil, false
 	}
+	return currentlyPlaying.Item, true
 }
 
-func createAvailableDevicesTable(client SpotifyClient, webPlayerID spotify.ID) (*DevicesTable, error) {
+// createAvailableDevicesTable creates table of devices, playback is
+// transferred to activated device when it is available, failures are
+// reported to reporter.
+func createAvailableDevicesTable(client SpotifyClient, webPlayerID spotify.ID, available func() bool, reporter *errorReporter) *DevicesTable {
 	table := tui.NewTable(0, 0)
 	tableBox := tui.NewHBox(table)
 	tableBox.SetTitle("Devices")
 	tableBox.SetBorder(true)
 
-	avalaibleDevices, err := client.PlayerDevices()
+	table.AppendRow(
+		tui.NewLabel("Name"),
+		tui.NewLabel("Type"),
+	)
+	devices := &DevicesTable{box: tableBox, Table: table, client: client, webPlayerID: webPlayerID}
+	table.OnItemActivated(func(t *tui.Table) {
+		selctedRow := t.Selected()
+		if selctedRow < 1 || selctedRow > len(devices.devices) {
+			return // Selecting table header
+		}
+		if !available() {
+			return
+		}
+		device := devices.devices[selctedRow-1]
+		if err := transferPlaybackToDevice(client, device.ID); err != nil {
+			reporter.reportError("Could not transfer playback to %s: %s", device.Name, err)
+		}
+	})
+	return devices
+}
+
+// refresh fetches available devices and displays them, devices are kept
+// when they can not be fetched.
+func (d *DevicesTable) refresh() error {
+	avalaibleDevices, err := d.client.PlayerDevices()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	table.AppendRow(
+	d.setDevices(avalaibleDevices)
+	return nil
+}
+
+// setDevices displays devices, web player is selected.
+func (d *DevicesTable) setDevices(avalaibleDevices []spotify.PlayerDevice) {
+	d.devices = avalaibleDevices
+	d.Table.RemoveRows()
+	d.Table.AppendRow(
 		tui.NewLabel("Name"),
 		tui.NewLabel("Type"),
 	)
 	for i, device := range avalaibleDevices {
-		table.AppendRow(
+		d.Table.AppendRow(
 			tui.NewLabel(device.Name),
 			tui.NewLabel(device.Type),
 		)
 		// we forced our web player to be the active one, but spotify backend
 		// has delays thus, instead of highlighting active device (which might be
 		// out of date), we highlight just our web player.
-		if device.ID == webPlayerID {
-			table.SetSelected(i + 1)
+		if device.ID == d.webPlayerID {
+			d.Table.SetSelected(i + 1)
 		}
 	}
-
-	table.OnItemActivated(func(t *tui.Table) {
-		selctedRow := t.Selected()
-		if selctedRow == 0 {
-			return // Selecting table header
-		}
-		transferPlaybackToDevice(client, avalaibleDevices[selctedRow-1].ID)
-	})
-
-	return &DevicesTable{box: tableBox, Table: table}, nil
 }
 
 func transferPlaybackToDevice(client SpotifyClient, id spotify.ID) error {
@@ -169,10 +413,48 @@ func transferPlaybackToDevice(client SpotifyClient, id spotify.ID) error {
 }
 
 func getTrackRepr(track *spotify.FullTrack) string {
+	if track == nil {
+		return "None"
+	}
 	return fmt.Sprintf(
 		"%s\n%s\n%s",
 		track.Name,
 		track.Album.Name,
-		track.Artists[0].Name,
+		artistName(track.Artists),
+	)
+}
+
+func currentlyPlayingEpisode(client SpotifyClient) *Episode {
+	episode, err := client.PlayerCurrentlyPlayingEpisode()
+	if err != nil {
+		log.Printf("could not fetch currently playing episode, %v", err)
+		return nil
+	}
+	return episode
+}
+
+func getEpisodeRepr(episode *Episode) string {
+	if episode == nil {
+		return "None"
+	}
+	if episode.Show == nil {
+		return episode.Name
+	}
+	return fmt.Sprintf("%s\n%s\n%s", episode.Name, episode.Show.Name, episode.Show.Publisher)
+}
+
+// getStateRepr describes what web player plays, for episodes album is the show.
+func getStateRepr(state *web.WebPlaybackState) string {
+	if state == nil {
+		return "None"
+	}
+	if state.CurrentItemType == "episode" {
+		return fmt.Sprintf("%s\n%s", state.CurrentTrackName, state.CurrentAlbumName)
+	}
+	return fmt.Sprintf(
+		"%s\n%s\n%s",
+		state.CurrentTrackName,
+		state.CurrentAlbumName,
+		state.CurrentArtistName,
 	)
 }
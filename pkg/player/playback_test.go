@@ -2,7 +2,10 @@ package player
 
 import (
 	"testing"
+	"time"
 
+	"github.com/jedruniu/spotify-cli/pkg/web"
+	"github.com/marcusolsson/tui-go"
 	"github.com/zmb3/spotify"
 )
 
@@ -20,6 +23,8 @@ func TestGetTrackRepr(t *testing.T) {
 				spotify.SimpleAlbum{Name: "alb"},
 				nil,
 				0,
+				nil,
+				nil,
 			}, "Name\nalb\nart1",
 		},
 		{
@@ -31,8 +36,17 @@ func TestGetTrackRepr(t *testing.T) {
 				spotify.SimpleAlbum{Name: "alb"},
 				nil,
 				0,
+				nil,
+				nil,
 			}, "Name\nalb\nart",
 		},
+		{
+			&spotify.FullTrack{
+				SimpleTrack: spotify.SimpleTrack{Name: "Name"},
+				Album:       spotify.SimpleAlbum{Name: "alb"},
+			}, "Name\nalb\n",
+		},
+		{nil, "None"},
 	}
 	for _, test := range tests {
 		got := getTrackRepr(test.track)
@@ -41,3 +55,94 @@ func TestGetTrackRepr(t *testing.T) {
 		}
 	}
 }
+
+func TestGetEpisodeRepr(t *testing.T) {
+	var tests = []struct {
+		episode *Episode
+		repr    string
+	}{
+		{&Episode{Name: "Episode", Show: &Show{Name: "Show", Publisher: "Publisher"}}, "Episode\nShow\nPublisher"},
+		{&Episode{Name: "Episode"}, "Episode"},
+		{nil, "None"},
+	}
+	for _, test := range tests {
+		got := getEpisodeRepr(test.episode)
+		if got != test.repr {
+			t.Errorf("Got: %v, want: %v", got, test.repr)
+		}
+	}
+}
+
+func TestGetStateRepr(t *testing.T) {
+	var tests = []struct {
+		state *web.WebPlaybackState
+		repr  string
+	}{
+		{&web.WebPlaybackState{CurrentTrackName: "Name", CurrentAlbumName: "alb", CurrentArtistName: "art", CurrentItemType: "track"}, "Name\nalb\nart"},
+		{&web.WebPlaybackState{CurrentTrackName: "Episode", CurrentAlbumName: "Show", CurrentItemType: "episode"}, "Episode\nShow"},
+		{nil, "None"},
+	}
+	for _, test := range tests {
+		got := getStateRepr(test.state)
+		if got != test.repr {
+			t.Errorf("Got: %v, want: %v", got, test.repr)
+		}
+	}
+}
+
+type FakeEpisodePlayer struct {
+	DebugPodcastLibrary
+}
+
+func (fake FakeEpisodePlayer) PlayerCurrentlyPlayingEpisode() (*Episode, error) {
+	return &Episode{Name: "Episode", Show: &Show{Name: "Show", Publisher: "Publisher"}}, nil
+}
+
+type FakeEpisodeClient struct {
+	DebugClient
+}
+
+func (fake FakeEpisodeClient) PlayerCurrentlyPlaying() (*spotify.CurrentlyPlaying, error) {
+	return &spotify.CurrentlyPlaying{}, nil // spotify library does not decode episodes
+}
+
+func TestUpdateCurrentlyPlayingLabelHandlesEpisodes(t *testing.T) {
+	client := FakeEpisodeClient{DebugClient{PodcastLibrary: FakeEpisodePlayer{}}}
+	label := tui.NewLabel("")
+	updateCurrentlyPlayingLabel(client, label)
+	if label.Text() != "Episode\nShow\nPublisher" {
+		t.Fatalf("Expected label to describe episode, got %q", label.Text())
+	}
+}
+
+func TestCurrentlyPlayingDisplaysStatesWithUpdate(t *testing.T) {
+	states := make(chan *web.WebPlaybackState)
+	playback := &CurrentlyPlaying{label: tui.NewLabel(""), playerStateChanges: states}
+	updates := make(chan func())
+	done := make(chan struct{})
+	go func() {
+		playback.Start(func(fn func()) { updates <- fn })
+		states <- &web.WebPlaybackState{CurrentTrackName: "Song", CurrentAlbumName: "Album", CurrentArtistName: "Artist"}
+		close(states)
+		close(done)
+	}()
+
+	select {
+	case fn := <-updates:
+		if playback.label.Text() != "" {
+			t.Fatalf("Expected label not to be changed outside of update")
+		}
+		fn()
+	case <-time.After(time.Second):
+		t.Fatalf("Expected state to be displayed with update")
+	}
+	if playback.label.Text() != "Song\nAlbum\nArtist" {
+		t.Fatalf("Expected state to be displayed, got %q", playback.label.Text())
+	}
+	<-done
+	select {
+	case <-updates:
+		t.Fatalf("Expected nothing to be displayed after states are closed")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,212 @@
+package player
+
+import (
+	"fmt"
+	"log"
+
+	tui "github.com/marcusolsson/tui-go"
+	"github.com/zmb3/spotify"
+)
+
+// playlistsPageSize is the number of playlists fetched at once, it is the
+// maximum which Spotify allows.
+var playlistsPageSize = 50
+
+type playlistDescription struct {
+	uri    spotify.URI
+	name   string
+	owner  string
+	tracks int
+}
+
+func simplePlaylistDescription(playlist spotify.SimplePlaylist) playlistDescription {
+	owner := playlist.Owner.DisplayName
+	if owner == "" {
+		owner = playlist.Owner.ID
+	}
+	return playlistDescription{
+		uri:    playlist.URI,
+		name:   playlist.Name,
+		owner:  owner,
+		tracks: int(playlist.Tracks.Total),
+	}
+}
+
+// Playlists represents view with playlists which user owns or follows.
+type Playlists struct {
+	client  SpotifyClient
+	library *Library
+	table   *virtualTable
+	box     *tui.Box
+
+	playlists []playlistDescription
+	// stale tells that playlists were read from the cache, or they could
+	// not be fetched, and they are not synchronized with Spotify yet.
+	stale bool
+
+	*errorReporter
+}
+
+// NewPlaylists creates Playlists view with cached playlists, or with all of
+// them fetched from Spotify. Cached playlists, or playlists which could not
+// be fetched because Spotify was not reachable, are synchronized with Sync.
+func NewPlaylists(client SpotifyClient, library *Library) (*Playlists, error) {
+	playlists := &Playlists{
+		client:  client,
+		library: library,
+
+		errorReporter: &errorReporter{},
+	}
+	table := newVirtualTable(playlists, viewRows)
+	table.SetColumnStretch(0, 6)
+	table.SetColumnStretch(1, 4)
+	table.SetColumnStretch(2, 1)
+	table.setHeader(
+		tui.NewLabel("Playlist"),
+		tui.NewLabel("Owner"),
+		tui.NewLabel("Tracks"),
+	)
+	table.OnItemActivated(playlists.onItemActivated())
+
+	box := tui.NewVBox(table, tui.NewSpacer())
+	box.SetSizePolicy(tui.Expanding, tui.Expanding)
+	playlists.table, playlists.box = table, box
+
+	if cached, ok := library.cache.playlists(); ok {
+		playlists.playlists, playlists.stale = cached, true
+		playlists.render()
+		return playlists, nil
+	}
+	fetched, err := fetchPlaylists(client)
+	if isConnectionError(err) {
+		// playlists are fetched with Sync when connection returns
+		playlists.stale = true
+		playlists.render()
+		box.SetTitle("Offline, playlists are loaded when connection returns")
+		return playlists, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	playlists.setPlaylists(fetched)
+	return playlists, nil
+}
+
+// Title returns name of the view.
+func (p *Playlists) Title() string {
+	return "Playlists"
+}
+
+// Widget returns widget in which view is displayed.
+func (p *Playlists) Widget() tui.Widget {
+	return p.box
+}
+
+// Focusables returns widgets of the view which can be focused.
+func (p *Playlists) Focusables() []tui.Widget {
+	return []tui.Widget{p.table}
+}
+
+// fetchPlaylists fetches all playlists of the user page by page.
+func fetchPlaylists(client SpotifyClient) ([]playlistDescription, error) {
+	playlists := []playlistDescription{}
+	for {
+		offset := len(playlists)
+		page, err := client.CurrentUsersPlaylistsOpt(&spotify.Options{Limit: &playlistsPageSize, Offset: &offset})
+		if err != nil {
+			return nil, wrapError(err, "could not fetch playlists: %v", err)
+		}
+		for _, playlist := range page.Playlists {
+			playlists = append(playlists, simplePlaylistDescription(playlist))
+		}
+		if len(page.Playlists) == 0 || len(playlists) >= page.Total {
+			return playlists, nil
+		}
+	}
+}
+
+// Sync synchronizes cached playlists with Spotify in background. Spotify
+// does not tell when playlists were added or changed, so all of them are
+// fetched again, cached ones are displayed until then. Playlists are
+// displayed with update, which applies them in UI goroutine, i.e. ui.Update.
+func (p *Playlists) Sync(update func(func())) {
+	if !p.stale {
+		return
+	}
+	go func() {
+		playlists, err := fetchPlaylists(p.client)
+		if isConnectionError(err) {
+			log.Printf("Playlists are synchronized when connection returns: %s", err)
+			return
+		}
+		if err != nil {
+			update(func() { p.reportError("Could not synchronize playlists: %s", err) })
+			return
+		}
+		update(func() { p.setPlaylists(playlists) })
+	}()
+}
+
+// Start synchronizes cached playlists when app runs, see Sync.
+func (p *Playlists) Start(update func(func())) {
+	p.Sync(update)
+}
+
+// ConnectionChanged synchronizes cached playlists when Spotify is reachable
+// again, see Sync.
+func (p *Playlists) ConnectionChanged(online bool, update func(func())) {
+	if online {
+		p.Sync(update)
+	}
+}
+
+// setPlaylists displays synchronized playlists and caches them.
+func (p *Playlists) setPlaylists(playlists []playlistDescription) {
+	p.playlists, p.stale = playlists, false
+	if err := p.library.cache.setPlaylists(playlists); err != nil {
+		log.Printf("Could not cache playlists: %s", err)
+	}
+	p.render()
+}
+
+// render displays playlists, selected row stays selected.
+func (p *Playlists) render() {
+	p.table.refresh()
+	p.box.SetTitle(fmt.Sprintf("%d playlists", len(p.playlists)))
+}
+
+func (p *Playlists) rowCount() int {
+	return len(p.playlists)
+}
+
+func (p *Playlists) row(idx int) []tui.Widget {
+	playlist := p.playlists[idx]
+	return []tui.Widget{
+		tui.NewLabel(trimWithCommasIfTooLong(playlist.name, uiColumnWidth*2)),
+		tui.NewLabel(trimWithCommasIfTooLong(playlist.owner, uiColumnWidth)),
+		tui.NewLabel(formatCount(playlist.tracks)),
+	}
+}
+
+// selectedPlaylist returns playlist from selected row, it returns false
+// when there are no playlists.
+func (p *Playlists) selectedPlaylist() (playlistDescription, bool) {
+	idx := p.table.selectedRow()
+	if idx < 0 || idx >= len(p.playlists) {
+		return playlistDescription{}, false
+	}
+	return p.playlists[idx], true
+}
+
+func (p *Playlists) onItemActivated() func(*tui.Table) {
+	return func(*tui.Table) {
+		playlist, ok := p.selectedPlaylist()
+		if !ok {
+			return
+		}
+		uri := playlist.uri
+		if err := p.client.PlayOpt(&spotify.PlayOptions{PlaybackContext: &uri}); err != nil {
+			p.reportError("Could not play playlist %s: %s", playlist.uri, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package player
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zmb3/spotify"
+)
+
+func TestNewPlaylistsFetchesAllPages(t *testing.T) {
+	defer func(size int) { playlistsPageSize = size }(playlistsPageSize)
+	playlistsPageSize = 5
+	client := &DebugClient{PlaylistLibrary: DebugPlaylistLibrary{}}
+	playlists, err := NewPlaylists(client, NewLibrary(client))
+	if err != nil {
+		t.Fatalf("Did not expect to fail, but it did with %s", err)
+	}
+	if len(playlists.playlists) != debugPlaylistsTotal || playlists.playlists[11].name != "Playlist 12" {
+		t.Fatalf("Expected to fetch %d playlists, fetched %v", debugPlaylistsTotal, playlists.playlists)
+	}
+}
+
+func TestPlaylistsOnItemActivatedPlaysSelectedPlaylist(t *testing.T) {
+	fakePlayer := &FakeTracksPlayer{}
+	client := &DebugClient{PlaylistLibrary: DebugPlaylistLibrary{}, Player: fakePlayer}
+	playlists, _ := NewPlaylists(client, NewLibrary(client))
+
+	playlists.table.selectRow(1)
+	playlists.onItemActivated()(playlists.table.Table)
+
+	if fakePlayer.playOptCalls != 1 {
+		t.Fatalf("Expected PlayOpt() to be called once, it was called %d times", fakePlayer.playOptCalls)
+	}
+	if context := fakePlayer.givenOptions.PlaybackContext; context == nil || *context != "spotify:playlist:playlist2" {
+		t.Fatalf("Expected to play the second playlist, got %v", context)
+	}
+}
+
+func TestPlaylistsAreSynchronizedWithCache(t *testing.T) {
+	cache, cleanup := newTempCache(t)
+	defer cleanup()
+	cache.setPlaylists([]playlistDescription{{uri: "spotify:playlist:removed", name: "Removed"}})
+	client := &DebugClient{PlaylistLibrary: DebugPlaylistLibrary{}}
+	library := NewLibrary(client)
+	library.SetCache(cache)
+	playlists, err := NewPlaylists(client, library)
+	if err != nil {
+		t.Fatalf("Did not expect to fail, but it did with %s", err)
+	}
+	if len(playlists.playlists) != 1 || playlists.playlists[0].name != "Removed" {
+		t.Fatalf("Expected cached playlists to be displayed, got %v", playlists.playlists)
+	}
+
+	updates := make(chan func())
+	playlists.Sync(func(fn func()) { updates <- fn })
+	select {
+	case fn := <-updates:
+		fn()
+	case <-time.After(time.Second):
+		t.Fatalf("Expected playlists to be synchronized")
+	}
+	if len(playlists.playlists) != debugPlaylistsTotal || playlists.playlists[0].uri != "spotify:playlist:playlist1" {
+		t.Errorf("Expected %d playlists to be synchronized, got %v", debugPlaylistsTotal, playlists.playlists)
+	}
+	if cached, _ := cache.playlists(); len(cached) != debugPlaylistsTotal {
+		t.Errorf("Expected synchronized playlists to be cached, got %d", len(cached))
+	}
+}
+
+func TestPlaylistsAreLoadedAfterReconnect(t *testing.T) {
+	connection := NewConnection(false)
+	client := NewConnectedClient(&DebugClient{PlaylistLibrary: DebugPlaylistLibrary{}}, connection)
+	playlists, err := NewPlaylists(client, NewLibrary(client))
+	if err != nil {
+		t.Fatalf("Expected playlists to be created offline, got %s", err)
+	}
+	if len(playlists.playlists) != 0 {
+		t.Fatalf("Expected no playlists to be fetched offline, got %d", len(playlists.playlists))
+	}
+
+	connection.set(true)
+	updates := make(chan func())
+	playlists.Sync(func(fn func()) { updates <- fn })
+	select {
+	case fn := <-updates:
+		fn()
+	case <-time.After(time.Second):
+		t.Fatalf("Expected playlists to be loaded")
+	}
+	if len(playlists.playlists) != debugPlaylistsTotal {
+		t.Errorf("Expected %d playlists to be loaded, got %d", debugPlaylistsTotal, len(playlists.playlists))
+	}
+}
+
+func TestSimplePlaylistDescription(t *testing.T) {
+	playlist := spotify.SimplePlaylist{Name: "Mix", URI: "spotify:playlist:mix"}
+	playlist.Owner.ID = "owner"
+	playlist.Tracks.Total = 7
+	expected := playlistDescription{uri: "spotify:playlist:mix", name: "Mix", owner: "owner", tracks: 7}
+	if description := simplePlaylistDescription(playlist); description != expected {
+		t.Errorf("Expected owner ID to be used when owner has no display name, got %+v", description)
+	}
+}
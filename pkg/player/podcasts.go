@@ -0,0 +1,301 @@
+package player
+
+import (
+	"fmt"
+	"time"
+
+	tui "github.com/marcusolsson/tui-go"
+	"github.com/zmb3/spotify"
+)
+
+var (
+	podcastsShowsLimit    = 50
+	podcastsEpisodesLimit = 50
+)
+
+// Show is a podcast, spotify library does not support them.
+type Show struct {
+	ID            spotify.ID  `json:"id"`
+	URI           spotify.URI `json:"uri"`
+	Name          string      `json:"name"`
+	Publisher     string      `json:"publisher"`
+	Description   string      `json:"description"`
+	TotalEpisodes int         `json:"total_episodes"`
+}
+
+// Episode is an episode of a podcast.
+type Episode struct {
+	ID          spotify.ID   `json:"id"`
+	URI         spotify.URI  `json:"uri"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	DurationMs  int          `json:"duration_ms"`
+	ReleaseDate string       `json:"release_date"`
+	ResumePoint *ResumePoint `json:"resume_point"`
+	// Show is set only when episode is not fetched as a part of the show.
+	Show *Show `json:"show"`
+}
+
+// ResumePoint tells where the current user stopped listening to episode.
+type ResumePoint struct {
+	FullyPlayed      bool `json:"fully_played"`
+	ResumePositionMs int  `json:"resume_position_ms"`
+}
+
+// SavedShow is a show saved in user's library.
+type SavedShow struct {
+	Show Show `json:"show"`
+}
+
+// SavedShowPage is a page of shows saved in user's library.
+type SavedShowPage struct {
+	Items []SavedShow `json:"items"`
+	Total int         `json:"total"`
+}
+
+// EpisodePage is a page of episodes of a show.
+type EpisodePage struct {
+	Items []Episode `json:"items"`
+	Total int       `json:"total"`
+}
+
+// PodcastSearchResult contains shows and episodes which were found.
+type PodcastSearchResult struct {
+	Shows struct {
+		Items []Show `json:"items"`
+		Total int    `json:"total"`
+	} `json:"shows"`
+	Episodes struct {
+		Items []Episode `json:"items"`
+		Total int       `json:"total"`
+	} `json:"episodes"`
+}
+
+// progress returns how much of the episode was listened to, i.e. "✓" for
+// fully played episode or "42%" for started one.
+func (e Episode) progress() string {
+	if e.ResumePoint == nil || e.DurationMs == 0 {
+		return ""
+	}
+	if e.ResumePoint.FullyPlayed {
+		return "✓"
+	}
+	if e.ResumePoint.ResumePositionMs == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d%%", e.ResumePoint.ResumePositionMs*100/e.DurationMs)
+}
+
+// resumePosition returns position from which episode should be played,
+// finished episodes are played from the beginning.
+func (e Episode) resumePosition() int {
+	if e.ResumePoint == nil || e.ResumePoint.FullyPlayed {
+		return 0
+	}
+	return e.ResumePoint.ResumePositionMs
+}
+
+func (e Episode) duration() string {
+	return fmt.Sprintf("%d min", time.Duration(e.DurationMs)*time.Millisecond/time.Minute)
+}
+
+// Podcasts represents view with shows saved in user's library, and episodes
+// of the selected show together with the description of selected episode.
+type Podcasts struct {
+	client      SpotifyClient
+	shows       *virtualTable
+	episodes    *virtualTable
+	description *tui.Label
+	episodesBox *tui.Box
+	box         *tui.Box
+
+	savedShows   []Show
+	showEpisodes []Episode
+	// offline tells that shows could not be fetched, because Spotify was not
+	// reachable, see ConnectionChanged.
+	offline bool
+
+	*errorReporter
+}
+
+// NewPodcasts creates Podcasts view with saved shows and episodes of the first of them.
+func NewPodcasts(client SpotifyClient) (*Podcasts, error) {
+	podcasts := &Podcasts{
+		client: client,
+
+		errorReporter: &errorReporter{},
+	}
+
+	shows := newVirtualTable(rowFuncs{count: podcasts.showsCount, build: podcasts.showRow}, viewRows)
+	shows.SetColumnStretch(0, 1)
+	showsBox := tui.NewVBox(shows, tui.NewSpacer())
+	showsBox.SetTitle("Shows")
+	showsBox.SetBorder(true)
+
+	episodes := newVirtualTable(rowFuncs{count: podcasts.episodesCount, build: podcasts.episodeRow}, viewRows)
+	episodes.SetColumnStretch(0, 1)
+	episodes.SetColumnStretch(1, 8)
+	episodes.SetColumnStretch(2, 2)
+	episodes.SetColumnStretch(3, 1)
+	episodes.setHeader(tui.NewLabel(""), tui.NewLabel("Episode"), tui.NewLabel("Released"), tui.NewLabel("Length"))
+	description := tui.NewLabel("")
+	description.SetWordWrap(true)
+	episodesBox := tui.NewVBox(episodes, tui.NewSpacer(), description)
+	episodesBox.SetBorder(true)
+	episodesBox.SetSizePolicy(tui.Expanding, tui.Expanding)
+
+	box := tui.NewHBox(showsBox, episodesBox)
+	box.SetSizePolicy(tui.Expanding, tui.Expanding)
+
+	podcasts.shows, podcasts.episodes = shows, episodes
+	podcasts.description, podcasts.episodesBox, podcasts.box = description, episodesBox, box
+	shows.OnItemActivated(func(*tui.Table) {
+		if err := podcasts.showEpisodesOf(shows.selectedRow()); err != nil {
+			podcasts.reportError("Could not show episodes: %s", err)
+		}
+	})
+	episodes.onRowSelected(func(idx int) {
+		description.SetText(podcasts.showEpisodes[idx].Description)
+	})
+	episodes.OnItemActivated(podcasts.onEpisodeActivated())
+
+	err := podcasts.fetchShows()
+	if err == nil && len(podcasts.savedShows) > 0 {
+		err = podcasts.showEpisodesOf(0)
+	}
+	if isConnectionError(err) {
+		podcasts.offline = true
+		episodesBox.SetTitle("Offline, podcasts are loaded when connection returns")
+		return podcasts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return podcasts, nil
+}
+
+// ConnectionChanged fetches saved shows and episodes of the first of them in
+// background, when they could not be fetched while Spotify was not
+// reachable. They are displayed with update.
+func (p *Podcasts) ConnectionChanged(online bool, update func(func())) {
+	if !online || !p.offline {
+		return
+	}
+	p.offline = false
+	go func() {
+		shows, err := p.client.CurrentUsersShows(podcastsShowsLimit, 0)
+		var episodes *EpisodePage
+		if err == nil && len(shows.Items) > 0 {
+			episodes, err = p.client.GetShowEpisodes(shows.Items[0].Show.ID, podcastsEpisodesLimit, 0)
+		}
+		update(func() {
+			if err != nil {
+				p.offline = isConnectionError(err)
+				p.reportError("Could not fetch podcasts: %s", err)
+				return
+			}
+			p.setShows(shows)
+			if episodes != nil {
+				p.setEpisodes(p.savedShows[0], episodes)
+			}
+		})
+	}()
+}
+
+// Title returns name of the view.
+func (p *Podcasts) Title() string {
+	return "Podcasts"
+}
+
+// Widget returns widget in which view is displayed.
+func (p *Podcasts) Widget() tui.Widget {
+	return p.box
+}
+
+// Focusables returns widgets of the view which can be focused.
+func (p *Podcasts) Focusables() []tui.Widget {
+	return []tui.Widget{p.shows, p.episodes}
+}
+
+func (p *Podcasts) fetchShows() error {
+	page, err := p.client.CurrentUsersShows(podcastsShowsLimit, 0)
+	if err != nil {
+		return wrapError(err, "could not fetch saved shows: %v", err)
+	}
+	p.setShows(page)
+	return nil
+}
+
+func (p *Podcasts) setShows(page *SavedShowPage) {
+	p.savedShows = p.savedShows[:0]
+	for _, item := range page.Items {
+		p.savedShows = append(p.savedShows, item.Show)
+	}
+	p.shows.reset()
+}
+
+func (p *Podcasts) showsCount() int {
+	return len(p.savedShows)
+}
+
+func (p *Podcasts) showRow(idx int) []tui.Widget {
+	return []tui.Widget{tui.NewLabel(trimWithCommasIfTooLong(p.savedShows[idx].Name, uiColumnWidth))}
+}
+
+// showEpisodesOf fetches and displays episodes of the show with given index.
+func (p *Podcasts) showEpisodesOf(idx int) error {
+	if idx < 0 || idx >= len(p.savedShows) {
+		return nil
+	}
+	show := p.savedShows[idx]
+	page, err := p.client.GetShowEpisodes(show.ID, podcastsEpisodesLimit, 0)
+	if err != nil {
+		return wrapError(err, "could not fetch episodes of %s: %v", show.Name, err)
+	}
+	p.setEpisodes(show, page)
+	return nil
+}
+
+func (p *Podcasts) setEpisodes(show Show, page *EpisodePage) {
+	p.showEpisodes = page.Items
+	p.description.SetText("")
+	p.episodes.reset() // selecting episode displays its description
+	p.episodesBox.SetTitle(fmt.Sprintf("%s, %d episodes", show.Name, page.Total))
+}
+
+func (p *Podcasts) episodesCount() int {
+	return len(p.showEpisodes)
+}
+
+func (p *Podcasts) episodeRow(idx int) []tui.Widget {
+	episode := p.showEpisodes[idx]
+	return []tui.Widget{
+		tui.NewLabel(episode.progress()),
+		tui.NewLabel(trimWithCommasIfTooLong(episode.Name, uiColumnWidth*2)),
+		tui.NewLabel(episode.ReleaseDate),
+		tui.NewLabel(episode.duration()),
+	}
+}
+
+// selectedEpisode returns episode from selected row, it returns false
+// when there are no episodes.
+func (p *Podcasts) selectedEpisode() (Episode, bool) {
+	idx := p.episodes.selectedRow()
+	if idx < 0 || idx >= len(p.showEpisodes) {
+		return Episode{}, false
+	}
+	return p.showEpisodes[idx], true
+}
+
+// onEpisodeActivated plays episode from the point where user stopped listening to it.
+func (p *Podcasts) onEpisodeActivated() func(*tui.Table) {
+	return func(*tui.Table) {
+		episode, ok := p.selectedEpisode()
+		if !ok {
+			return
+		}
+		if err := p.client.PlayEpisode(episode.URI, episode.resumePosition()); err != nil {
+			p.reportError("Could not play episode %s: %s", episode.URI, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package player
+
+import (
+	"testing"
+
+	"github.com/zmb3/spotify"
+)
+
+func TestNewPodcastsShowsEpisodesOfFirstShow(t *testing.T) {
+	podcasts, err := NewPodcasts(NewDebugClient())
+	if err != nil {
+		t.Fatalf("Did not expect to fail, but it did with %s", err)
+	}
+	if len(podcasts.savedShows) != debugShowsCount {
+		t.Fatalf("Expected to have %d shows, have %d", debugShowsCount, len(podcasts.savedShows))
+	}
+	if len(podcasts.showEpisodes) != debugEpisodesCount {
+		t.Fatalf("Expected to have %d episodes, have %d", debugEpisodesCount, len(podcasts.showEpisodes))
+	}
+	if podcasts.description.Text() != "Description of episode 1" {
+		t.Fatalf("Expected description of the first episode, got %q", podcasts.description.Text())
+	}
+	podcasts.episodes.selectRow(2)
+	if podcasts.description.Text() != "Description of episode 3" {
+		t.Fatalf("Expected description of the selected episode, got %q", podcasts.description.Text())
+	}
+
+	podcasts.showEpisodesOf(2)
+	if podcasts.showEpisodes[0].ID != "show3episode1" {
+		t.Fatalf("Expected to show episodes of the third show, got %s", podcasts.showEpisodes[0].ID)
+	}
+}
+
+func TestEpisodeProgress(t *testing.T) {
+	cases := []struct {
+		episode                Episode
+		expectedProgress       string
+		expectedResumePosition int
+	}{
+		{Episode{DurationMs: 1000}, "", 0},
+		{Episode{DurationMs: 1000, ResumePoint: &ResumePoint{}}, "", 0},
+		{Episode{DurationMs: 1000, ResumePoint: &ResumePoint{ResumePositionMs: 420}}, "42%", 420},
+		{Episode{DurationMs: 1000, ResumePoint: &ResumePoint{FullyPlayed: true, ResumePositionMs: 990}}, "✓", 0},
+	}
+	for _, c := range cases {
+		if got := c.episode.progress(); got != c.expectedProgress {
+			t.Errorf("Expected progress %q, got %q", c.expectedProgress, got)
+		}
+		if got := c.episode.resumePosition(); got != c.expectedResumePosition {
+			t.Errorf("Expected resume position %d, got %d", c.expectedResumePosition, got)
+		}
+	}
+}
+
+type FakePodcastLibrary struct {
+	DebugPodcastLibrary
+	playedURI      spotify.URI
+	playedPosition int
+}
+
+func (fake *FakePodcastLibrary) PlayEpisode(uri spotify.URI, positionMs int) error {
+	fake.playedURI = uri
+	fake.playedPosition = positionMs
+	return nil
+}
+
+func TestPodcastsOnEpisodeActivatedResumesEpisode(t *testing.T) {
+	library := &FakePodcastLibrary{}
+	podcasts, _ := NewPodcasts(&DebugClient{PodcastLibrary: library})
+
+	podcasts.episodes.selectRow(debugEpisodesCount - 3) // the one which was started
+	podcasts.onEpisodeActivated()(podcasts.episodes.Table)
+
+	expected := podcasts.showEpisodes[debugEpisodesCount-3]
+	if library.playedURI != expected.URI || library.playedPosition != expected.ResumePoint.ResumePositionMs {
+		t.Fatalf("Expected to resume %s from %d, played %s from %d",
+			expected.URI, expected.ResumePoint.ResumePositionMs, library.playedURI, library.playedPosition)
+	}
+	if library.playedPosition == 0 {
+		t.Fatalf("Expected episode to be resumed")
+	}
+}
@@ -0,0 +1,105 @@
+package player
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// DefaultProfile is a profile used when user does not choose one.
+const DefaultProfile = "default"
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9_.-]*$`)
+
+// Profile is a name under which state of the app is stored between runs,
+// so that i.e. different Spotify accounts have separate search histories.
+type Profile struct {
+	Name     string
+	dir      string
+	cacheDir string
+}
+
+// NewProfile creates profile which stores its files in the configuration
+// directory of the user, and data which can be fetched again in the cache
+// directory of the user.
+func NewProfile(name string) (*Profile, error) {
+	dir, err := xdgDir("XDG_CONFIG_HOME", ".config")
+	if err != nil {
+		return nil, err
+	}
+	cacheDir, err := xdgDir("XDG_CACHE_HOME", ".cache")
+	if err != nil {
+		return nil, err
+	}
+	return newProfileIn(name, filepath.Join(dir, "spotify-cli", "profiles"), filepath.Join(cacheDir, "spotify-cli", "profiles"))
+}
+
+func newProfileIn(name, dir, cacheDir string) (*Profile, error) {
+	if !profileNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("profile name %q may contain only letters, digits, dots, dashes and underscores", name)
+	}
+	return &Profile{Name: name, dir: filepath.Join(dir, name), cacheDir: filepath.Join(cacheDir, name)}, nil
+}
+
+// Path returns path of the file of the profile, directory of the profile
+// is created when it does not exist.
+func (p *Profile) Path(file string) (string, error) {
+	return p.pathIn(p.dir, file)
+}
+
+// CachePath returns path of the cache file of the profile, cache directory
+// of the profile is created when it does not exist.
+func (p *Profile) CachePath(file string) (string, error) {
+	return p.pathIn(p.cacheDir, file)
+}
+
+func (p *Profile) pathIn(dir, file string) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("could not create directory of profile %s: %v", p.Name, err)
+	}
+	return filepath.Join(dir, file), nil
+}
+
+// DefaultExportDir returns directory into which files exported by user are
+// written, when user does not choose one. It is in the data directory of
+// the user.
+func DefaultExportDir() (string, error) {
+	dir, err := xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "spotify-cli", "exports"), nil
+}
+
+// xdgDir returns directory of the user defined by environment variable, as
+// described by XDG Base Directory Specification, or its default in home.
+func xdgDir(env, defaultInHome string) (string, error) {
+	if dir := os.Getenv(env); dir != "" {
+		return dir, nil
+	}
+	home := os.Getenv("HOME")
+	if home == "" {
+		return "", fmt.Errorf("neither $%s nor $HOME is defined", env)
+	}
+	return filepath.Join(home, defaultInHome), nil
+}
+
+// writeFileAtomically writes data to a temporary file first, and replaces
+// the file with it, so that the file is not lost when writing fails.
+func writeFileAtomically(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
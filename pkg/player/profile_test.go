@@ -0,0 +1,79 @@
+package player
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	defer os.Setenv("XDG_CACHE_HOME", os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	os.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	cases := []struct {
+		name          string
+		expectedError bool
+	}{
+		{name: "default"},
+		{name: "work-account_2"},
+		{name: "me.old"},
+		{name: "", expectedError: true},
+		{name: "..", expectedError: true},
+		{name: ".hidden", expectedError: true},
+		{name: "a/b", expectedError: true},
+		{name: "a b", expectedError: true},
+	}
+	for _, c := range cases {
+		profile, err := NewProfile(c.name)
+		if c.expectedError {
+			if err == nil {
+				t.Errorf("Expected error for profile %q", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Did not expect error for profile %q, got %s", c.name, err)
+			continue
+		}
+		path, err := profile.Path("file.json")
+		if err != nil {
+			t.Errorf("Did not expect error, got %s", err)
+		}
+		if expected := filepath.Join(dir, "config", "spotify-cli", "profiles", c.name, "file.json"); path != expected {
+			t.Errorf("Expected path %s, got %s", expected, path)
+		}
+		if _, err := os.Stat(filepath.Dir(path)); err != nil {
+			t.Errorf("Expected directory of profile to be created, got %s", err)
+		}
+		cachePath, err := profile.CachePath("cache.json")
+		if err != nil {
+			t.Errorf("Did not expect error, got %s", err)
+		}
+		if expected := filepath.Join(dir, "cache", "spotify-cli", "profiles", c.name, "cache.json"); cachePath != expected {
+			t.Errorf("Expected cache path %s, got %s", expected, cachePath)
+		}
+	}
+}
+
+func TestDefaultExportDir(t *testing.T) {
+	defer os.Setenv("XDG_DATA_HOME", os.Getenv("XDG_DATA_HOME"))
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+
+	os.Setenv("XDG_DATA_HOME", "/data")
+	if dir, err := DefaultExportDir(); err != nil || dir != filepath.Join("/data", "spotify-cli", "exports") {
+		t.Errorf("Expected exports in $XDG_DATA_HOME, got %s and %v", dir, err)
+	}
+	os.Setenv("XDG_DATA_HOME", "")
+	os.Setenv("HOME", "/home/jan")
+	if dir, err := DefaultExportDir(); err != nil || dir != filepath.Join("/home/jan", ".local", "share", "spotify-cli", "exports") {
+		t.Errorf("Expected exports in data directory in home, got %s and %v", dir, err)
+	}
+}
@@ -0,0 +1,232 @@
+package player
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zmb3/spotify"
+)
+
+// searchFields are filters supported by Spotify search. All of them but
+// market are part of the query, market is passed as an option.
+var searchFields = []string{"album", "artist", "genre", "isrc", "market", "tag", "track", "upc", "year"}
+
+var (
+	yearPattern   = regexp.MustCompile(`^(\d{4})(?:-(\d{4}))?$`)
+	isrcPattern   = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{3}\d{7}$`)
+	upcPattern    = regexp.MustCompile(`^\d{12,13}$`)
+	marketPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// queryFilter limits search results to items with field of given value,
+// i.e. artist:"Miles Davis".
+type queryFilter struct {
+	field string
+	value string
+}
+
+func (f queryFilter) String() string {
+	value := f.value
+	if strings.ContainsAny(value, " \t") {
+		value = `"` + value + `"`
+	}
+	return f.field + ":" + value
+}
+
+// searchQuery is a parsed search query with free text terms and validated filters.
+type searchQuery struct {
+	terms   []string
+	filters []queryFilter
+	market  string
+}
+
+// parseSearchQuery splits input into terms and filters, it fails when
+// one of the filters has invalid value. Words which look like filters,
+// but do not name one of the searchFields are treated as terms.
+func parseSearchQuery(input string) (searchQuery, error) {
+	query := searchQuery{}
+	tokens, err := splitQuery(input)
+	if err != nil {
+		return query, err
+	}
+	for _, token := range tokens {
+		colon := strings.Index(token, ":")
+		if colon < 0 || !isSearchField(strings.ToLower(token[:colon])) {
+			query.terms = append(query.terms, token)
+			continue
+		}
+		field := strings.ToLower(token[:colon])
+		value, err := normalizeFilter(field, strings.Trim(token[colon+1:], `"`))
+		if err != nil {
+			return searchQuery{}, err
+		}
+		if field == "market" {
+			if query.market != "" && query.market != value {
+				return searchQuery{}, fmt.Errorf("market can be given only once")
+			}
+			query.market = value
+			continue
+		}
+		query.filters = append(query.filters, queryFilter{field: field, value: value})
+	}
+	if len(query.terms) == 0 && len(query.filters) == 0 {
+		return searchQuery{}, fmt.Errorf("query is empty")
+	}
+	return query, nil
+}
+
+// splitQuery splits input on whitespace which is not inside of quotes.
+func splitQuery(input string) ([]string, error) {
+	tokens := []string{}
+	token := strings.Builder{}
+	quoted := false
+	for _, r := range input {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			token.WriteRune(r)
+		case (r == ' ' || r == '\t') && !quoted:
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+		default:
+			token.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("quote is not closed")
+	}
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+	return tokens, nil
+}
+
+func isSearchField(name string) bool {
+	for _, field := range searchFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeFilter validates value of the filter and returns it in the form
+// expected by Spotify, i.e. with upper case ISRC code.
+func normalizeFilter(field, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("%s: needs a value", field)
+	}
+	switch field {
+	case "year":
+		years := yearPattern.FindStringSubmatch(value)
+		if years == nil {
+			return "", fmt.Errorf("year: %q is neither a year nor a range like 1990-1999", value)
+		}
+		if years[2] != "" {
+			from, _ := strconv.Atoi(years[1])
+			to, _ := strconv.Atoi(years[2])
+			if from > to {
+				return "", fmt.Errorf("year: range %q ends before it starts", value)
+			}
+		}
+	case "tag":
+		value = strings.ToLower(value)
+		if value != "new" && value != "hipster" {
+			return "", fmt.Errorf("tag: is either new or hipster, got %q", value)
+		}
+	case "isrc":
+		value = strings.ToUpper(value)
+		if !isrcPattern.MatchString(value) {
+			return "", fmt.Errorf("isrc: %q is not an ISRC code", value)
+		}
+	case "upc":
+		if !upcPattern.MatchString(value) {
+			return "", fmt.Errorf("upc: %q is not an UPC code", value)
+		}
+	case "market":
+		if strings.ToLower(value) == "from_token" {
+			return "from_token", nil
+		}
+		value = strings.ToUpper(value)
+		if !marketPattern.MatchString(value) {
+			return "", fmt.Errorf("market: %q is not a country code like PL", value)
+		}
+	case "genre":
+		value = strings.ToLower(value)
+	}
+	return value, nil
+}
+
+// String returns query in the form expected by Spotify, terms come first and
+// are followed by filters.
+func (q searchQuery) String() string {
+	parts := append([]string{}, q.terms...)
+	for _, filter := range q.filters {
+		parts = append(parts, filter.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// options returns search options with market of the query.
+func (q searchQuery) options() *spotify.Options {
+	opt := &spotify.Options{}
+	if q.market != "" {
+		market := q.market
+		opt.Country = &market
+	}
+	return opt
+}
+
+// chips renders filters of the query, i.e. "[artist: Miles Davis] [market: PL]".
+func (q searchQuery) chips() string {
+	chips := []string{}
+	for _, filter := range q.filters {
+		chips = append(chips, fmt.Sprintf("[%s: %s]", filter.field, filter.value))
+	}
+	if q.market != "" {
+		chips = append(chips, fmt.Sprintf("[market: %s]", q.market))
+	}
+	return strings.Join(chips, " ")
+}
+
+// completeSearchField completes name of the filter which is typed at the end
+// of input. Input is completed only when there is one matching field,
+// otherwise it is returned as it is together with all matching fields.
+func completeSearchField(input string) (string, []string) {
+	start := strings.LastIndexAny(input, " \t") + 1
+	prefix := strings.ToLower(input[start:])
+	if prefix == "" || strings.ContainsAny(prefix, `:"`) {
+		return input, nil
+	}
+	matching := []string{}
+	for _, field := range searchFields {
+		if strings.HasPrefix(field, prefix) {
+			matching = append(matching, field)
+		}
+	}
+	if len(matching) == 1 {
+		return input[:start] + matching[0] + ":", matching
+	}
+	return input, matching
+}
+
+// queryHint describes input while it is typed: it shows filters which are
+// recognized, the first problem with them or fields which can be completed.
+func queryHint(input string) string {
+	if strings.TrimSpace(input) == "" {
+		return ""
+	}
+	if _, matching := completeSearchField(input); len(matching) > 0 {
+		return fmt.Sprintf("Filters: %s: (Ctrl+Space)", strings.Join(matching, ": "))
+	}
+	query, err := parseSearchQuery(input)
+	if err != nil {
+		return fmt.Sprintf("Invalid query, %s", err)
+	}
+	return query.chips()
+}
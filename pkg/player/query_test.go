@@ -0,0 +1,203 @@
+package player
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/marcusolsson/tui-go"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	cases := []struct {
+		input           string
+		expectedQuery   string
+		expectedMarket  string
+		expectedFilters []queryFilter
+	}{
+		{input: "kind of blue", expectedQuery: "kind of blue"},
+		{input: "  kind   of\tblue ", expectedQuery: "kind of blue"},
+		{input: `"kind of blue"`, expectedQuery: `"kind of blue"`},
+		{
+			input:           "blue artist:miles",
+			expectedQuery:   "blue artist:miles",
+			expectedFilters: []queryFilter{{"artist", "miles"}},
+		},
+		{
+			input:           `blue artist:"Miles Davis"`,
+			expectedQuery:   `blue artist:"Miles Davis"`,
+			expectedFilters: []queryFilter{{"artist", "Miles Davis"}},
+		},
+		{
+			input:           `Artist:"Miles Davis" blue`,
+			expectedQuery:   `blue artist:"Miles Davis"`,
+			expectedFilters: []queryFilter{{"artist", "Miles Davis"}},
+		},
+		{
+			input:           `album:"Kind of Blue" track:So`,
+			expectedQuery:   `album:"Kind of Blue" track:So`,
+			expectedFilters: []queryFilter{{"album", "Kind of Blue"}, {"track", "So"}},
+		},
+		{input: "year:1959", expectedQuery: "year:1959", expectedFilters: []queryFilter{{"year", "1959"}}},
+		{input: "year:1990-1999", expectedQuery: "year:1990-1999", expectedFilters: []queryFilter{{"year", "1990-1999"}}},
+		{input: "year:1990-1990", expectedQuery: "year:1990-1990", expectedFilters: []queryFilter{{"year", "1990-1990"}}},
+		{input: "genre:Jazz", expectedQuery: "genre:jazz", expectedFilters: []queryFilter{{"genre", "jazz"}}},
+		{input: `genre:"Hip Hop"`, expectedQuery: `genre:"hip hop"`, expectedFilters: []queryFilter{{"genre", "hip hop"}}},
+		{input: "tag:NEW", expectedQuery: "tag:new", expectedFilters: []queryFilter{{"tag", "new"}}},
+		{input: "tag:hipster", expectedQuery: "tag:hipster", expectedFilters: []queryFilter{{"tag", "hipster"}}},
+		{
+			input:           "tag:new tag:hipster",
+			expectedQuery:   "tag:new tag:hipster",
+			expectedFilters: []queryFilter{{"tag", "new"}, {"tag", "hipster"}},
+		},
+		{input: "isrc:usum71703861", expectedQuery: "isrc:USUM71703861", expectedFilters: []queryFilter{{"isrc", "USUM71703861"}}},
+		{input: "upc:886443927087", expectedQuery: "upc:886443927087", expectedFilters: []queryFilter{{"upc", "886443927087"}}},
+		{input: "upc:0886443927087", expectedQuery: "upc:0886443927087", expectedFilters: []queryFilter{{"upc", "0886443927087"}}},
+		{input: "blue market:pl", expectedQuery: "blue", expectedMarket: "PL"},
+		{input: "blue market:PL market:pl", expectedQuery: "blue", expectedMarket: "PL"},
+		{input: "blue market:FROM_TOKEN", expectedQuery: "blue", expectedMarket: "from_token"},
+		{input: "blue market:us artist:miles", expectedQuery: "blue artist:miles", expectedMarket: "US", expectedFilters: []queryFilter{{"artist", "miles"}}},
+		{input: "re:invent", expectedQuery: "re:invent"},       // not a filter
+		{input: "http://x.com", expectedQuery: "http://x.com"}, // not a filter
+		{input: `"artist:miles"`, expectedQuery: `"artist:miles"`},
+	}
+	for _, c := range cases {
+		query, err := parseSearchQuery(c.input)
+		if err != nil {
+			t.Errorf("Did not expect error for %q, got %s", c.input, err)
+			continue
+		}
+		if query.String() != c.expectedQuery {
+			t.Errorf("Expected %q to be normalized to %q, got %q", c.input, c.expectedQuery, query.String())
+		}
+		if query.market != c.expectedMarket {
+			t.Errorf("Expected market of %q to be %q, got %q", c.input, c.expectedMarket, query.market)
+		}
+		if len(query.filters) != len(c.expectedFilters) || (len(c.expectedFilters) > 0 && !reflect.DeepEqual(query.filters, c.expectedFilters)) {
+			t.Errorf("Expected filters of %q to be %v, got %v", c.input, c.expectedFilters, query.filters)
+		}
+	}
+}
+
+func TestParseInvalidSearchQuery(t *testing.T) {
+	cases := []string{
+		"",
+		"   ",
+		"market:PL",
+		`artist:"Miles Davis`,
+		`"kind of blue`,
+		"artist:",
+		`artist:""`,
+		"year:59",
+		"year:nineties",
+		"year:1999-1990",
+		"year:1990-",
+		"year:1990-99",
+		"tag:old",
+		"tag:",
+		"isrc:123",
+		"isrc:USUM7170386X",
+		"upc:12345",
+		"upc:88644392708a",
+		"market:Poland",
+		"market:P1",
+		"blue market:PL market:US",
+	}
+	for _, input := range cases {
+		if query, err := parseSearchQuery(input); err == nil {
+			t.Errorf("Expected error for %q, got query %q", input, query)
+		}
+	}
+}
+
+func TestSearchQueryOptions(t *testing.T) {
+	query, _ := parseSearchQuery("blue")
+	if opt := query.options(); opt.Country != nil {
+		t.Errorf("Expected no market, got %s", *opt.Country)
+	}
+	query, _ = parseSearchQuery("blue market:se")
+	if opt := query.options(); opt.Country == nil || *opt.Country != "SE" {
+		t.Errorf("Expected market SE, got %v", opt.Country)
+	}
+}
+
+func TestSearchQueryChips(t *testing.T) {
+	cases := []struct {
+		input         string
+		expectedChips string
+	}{
+		{input: "blue", expectedChips: ""},
+		{input: `blue artist:"Miles Davis" year:1959`, expectedChips: "[artist: Miles Davis] [year: 1959]"},
+		{input: "market:pl blue tag:new", expectedChips: "[tag: new] [market: PL]"},
+	}
+	for _, c := range cases {
+		query, err := parseSearchQuery(c.input)
+		if err != nil {
+			t.Fatalf("Did not expect error for %q, got %s", c.input, err)
+		}
+		if query.chips() != c.expectedChips {
+			t.Errorf("Expected chips of %q to be %q, got %q", c.input, c.expectedChips, query.chips())
+		}
+	}
+}
+
+func TestCompleteSearchField(t *testing.T) {
+	cases := []struct {
+		input            string
+		expectedInput    string
+		expectedMatching []string
+	}{
+		{input: "", expectedInput: ""},
+		{input: "blue ", expectedInput: "blue "},
+		{input: "blue ar", expectedInput: "blue artist:", expectedMatching: []string{"artist"}},
+		{input: "blue Ye", expectedInput: "blue year:", expectedMatching: []string{"year"}},
+		{input: "blue a", expectedInput: "blue a", expectedMatching: []string{"album", "artist"}},
+		{input: "t", expectedInput: "t", expectedMatching: []string{"tag", "track"}},
+		{input: "blue artist:", expectedInput: "blue artist:"},
+		{input: "blue artist:m", expectedInput: "blue artist:m"},
+		{input: `"blue`, expectedInput: `"blue`},
+		{input: "blue xyz", expectedInput: "blue xyz", expectedMatching: []string{}},
+	}
+	for _, c := range cases {
+		input, matching := completeSearchField(c.input)
+		if input != c.expectedInput {
+			t.Errorf("Expected %q to be completed to %q, got %q", c.input, c.expectedInput, input)
+		}
+		if len(matching) != len(c.expectedMatching) || (len(matching) > 0 && !reflect.DeepEqual(matching, c.expectedMatching)) {
+			t.Errorf("Expected %q to match %v, got %v", c.input, c.expectedMatching, matching)
+		}
+	}
+}
+
+func TestQueryHint(t *testing.T) {
+	cases := []struct {
+		input        string
+		expectedHint string
+	}{
+		{input: "", expectedHint: ""},
+		{input: "blue a", expectedHint: "Filters: album: artist: (Ctrl+Space)"},
+		{input: "blue year:1990-", expectedHint: `Invalid query, year: "1990-" is neither a year nor a range like 1990-1999`},
+		{input: "blue genre:jazz", expectedHint: "[genre: jazz]"},
+	}
+	for _, c := range cases {
+		if hint := queryHint(c.input); hint != c.expectedHint {
+			t.Errorf("Expected hint of %q to be %q, got %q", c.input, c.expectedHint, hint)
+		}
+	}
+}
+
+func TestSearchOnSubmitWithInvalidQuery(t *testing.T) {
+	client := NewDebugClient()
+	search, err := NewSearch(client, NewLibrary(client), DefaultSearchCategories)
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	entry := tui.NewEntry()
+	entry.SetText("blue tag:old")
+	search.onSubmit(client)(entry)
+	if search.query != "" {
+		t.Errorf("Expected invalid query not to be searched for, got %q", search.query)
+	}
+	if search.hint.Text() != `Invalid query, tag: is either new or hipster, got "old"` {
+		t.Errorf("Expected hint to describe invalid query, got %q", search.hint.Text())
+	}
+}
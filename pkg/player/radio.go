@@ -0,0 +1,245 @@
+package player
+
+import (
+	"fmt"
+
+	tui "github.com/marcusolsson/tui-go"
+	"github.com/zmb3/spotify"
+)
+
+var (
+	radioLimit = 50
+	// radioAlbumSeedTracks is a number of album's tracks which are used as
+	// seeds, because Spotify does not accept albums as recommendation seeds.
+	radioAlbumSeedTracks = 5
+)
+
+// radioSeedTypes are types of items from which radio can be started.
+var radioSeedTypes = map[string]bool{"track": true, "album": true, "artist": true}
+
+// RadioSeed is an item from which radio is started: track, album or artist.
+type RadioSeed struct {
+	Type string
+	ID   spotify.ID
+	Name string
+}
+
+// radioSeedFromURI creates seed from URI like spotify:album:ID.
+func radioSeedFromURI(uri spotify.URI, name string) RadioSeed {
+	return RadioSeed{Type: contextType(uri), ID: idFromURI(uri), Name: name}
+}
+
+// radioStarter is embedded by widgets from which radio can be started.
+type radioStarter struct {
+	start func(RadioSeed)
+}
+
+// OnStartRadio sets function which is called when user starts radio
+// from one of the items.
+func (rs *radioStarter) OnStartRadio(fn func(RadioSeed)) {
+	rs.start = fn
+}
+
+// startRadio starts radio from seed, seeds of other types than the ones
+// from which radio can be started are ignored.
+func (rs *radioStarter) startRadio(seed RadioSeed) {
+	if rs.start == nil || seed.ID == "" || !radioSeedTypes[seed.Type] {
+		return
+	}
+	rs.start(seed)
+}
+
+// radioRange is a range of track attribute values, zero range means any value.
+type radioRange struct {
+	name     string
+	min, max float64
+}
+
+var (
+	radioEnergyRanges = []radioRange{{"any", 0, 0}, {"low", 0, 0.4}, {"medium", 0.3, 0.7}, {"high", 0.6, 1}}
+	radioTempoRanges  = []radioRange{{"any", 0, 0}, {"slow", 0, 100}, {"medium", 90, 130}, {"fast", 120, 250}}
+)
+
+// Radio represents view with tracks recommended by Spotify for the seed,
+// which are played as an ad-hoc queue. Recommendations can be tuned with
+// energy and tempo ranges.
+type Radio struct {
+	client SpotifyClient
+	table  *virtualTable
+	box    *tui.Box
+	tuning *tui.Label
+
+	seed   RadioSeed
+	seeds  spotify.Seeds
+	energy int
+	tempo  int
+	tracks []spotify.SimpleTrack
+
+	*errorReporter
+}
+
+// NewRadio creates empty Radio view, recommendations are fetched once radio is started.
+func NewRadio(client SpotifyClient) *Radio {
+	radio := &Radio{
+		client: client,
+
+		errorReporter: &errorReporter{},
+	}
+	table := newVirtualTable(radio, viewRows)
+	table.SetColumnStretch(0, 6)
+	table.SetColumnStretch(1, 4)
+	table.setHeader(tui.NewLabel("Title"), tui.NewLabel("Artist"))
+
+	tuning := tui.NewLabel("")
+	box := tui.NewVBox(tuning, table, tui.NewSpacer())
+	box.SetSizePolicy(tui.Expanding, tui.Expanding)
+	box.SetTitle("Press r on track, album or artist to start radio")
+	radio.table, radio.box, radio.tuning = table, box, tuning
+
+	table.OnItemActivated(radio.onItemActivated())
+	table.onKey("e", func(*tui.Table) { radio.tune((radio.energy+1)%len(radioEnergyRanges), radio.tempo) })
+	table.onKey("t", func(*tui.Table) { radio.tune(radio.energy, (radio.tempo+1)%len(radioTempoRanges)) })
+	table.onKey("r", func(*tui.Table) {
+		idx := radio.table.selectedRow()
+		if idx < 0 || idx >= len(radio.tracks) {
+			return
+		}
+		track := radio.tracks[idx]
+		radio.TryStart(RadioSeed{Type: "track", ID: track.ID, Name: track.Name})
+	})
+	tuning.SetText(radio.tuningText())
+	return radio
+}
+
+// Title returns name of the view.
+func (r *Radio) Title() string {
+	return "Radio"
+}
+
+// Widget returns widget in which view is displayed.
+func (r *Radio) Widget() tui.Widget {
+	return r.box
+}
+
+// Focusables returns widgets of the view which can be focused.
+func (r *Radio) Focusables() []tui.Widget {
+	return []tui.Widget{r.table}
+}
+
+// Start fetches tracks recommended for the seed and displays them.
+func (r *Radio) Start(seed RadioSeed) error {
+	seeds, err := r.radioSeeds(seed)
+	if err != nil {
+		return err
+	}
+	r.seed = seed
+	r.seeds = seeds
+	return r.refresh()
+}
+
+// TryStart starts radio from the seed like Start, failure is reported in
+// the status line. It returns whether radio was started.
+func (r *Radio) TryStart(seed RadioSeed) bool {
+	if err := r.Start(seed); err != nil {
+		r.reportError("Could not start radio from %s %s: %s", seed.Type, seed.Name, err)
+		return false
+	}
+	return true
+}
+
+func (r *Radio) radioSeeds(seed RadioSeed) (spotify.Seeds, error) {
+	switch seed.Type {
+	case "track":
+		return spotify.Seeds{Tracks: []spotify.ID{seed.ID}}, nil
+	case "artist":
+		return spotify.Seeds{Artists: []spotify.ID{seed.ID}}, nil
+	case "album":
+		limit := radioAlbumSeedTracks
+		page, err := r.client.GetAlbumTracksOpt(seed.ID, &spotify.Options{Limit: &limit})
+		if err != nil {
+			return spotify.Seeds{}, fmt.Errorf("could not fetch tracks of album %s: %v", seed.ID, err)
+		}
+		seeds := spotify.Seeds{}
+		for _, track := range page.Tracks {
+			seeds.Tracks = append(seeds.Tracks, track.ID)
+		}
+		return seeds, nil
+	}
+	return spotify.Seeds{}, fmt.Errorf("radio can not be started from %s", seed.Type)
+}
+
+// tune changes energy and tempo ranges and fetches recommendations again.
+func (r *Radio) tune(energy, tempo int) {
+	r.energy = energy
+	r.tempo = tempo
+	r.tuning.SetText(r.tuningText())
+	if r.seed.ID == "" {
+		return
+	}
+	if err := r.refresh(); err != nil {
+		r.reportError("Could not tune radio: %s", err)
+	}
+}
+
+func (r *Radio) refresh() error {
+	attributes := trackAttributes(radioEnergyRanges[r.energy], radioTempoRanges[r.tempo])
+	recommendations, err := r.client.GetRecommendations(r.seeds, attributes, &spotify.Options{Limit: &radioLimit})
+	if err != nil {
+		return fmt.Errorf("could not fetch recommendations for %s %s: %v", r.seed.Type, r.seed.ID, err)
+	}
+	r.tracks = recommendations.Tracks
+
+	r.table.reset()
+	r.box.SetTitle(fmt.Sprintf("Radio from %s %s, %d tracks", r.seed.Type, r.seed.Name, len(r.tracks)))
+	return nil
+}
+
+func (r *Radio) rowCount() int {
+	return len(r.tracks)
+}
+
+func (r *Radio) row(idx int) []tui.Widget {
+	track := r.tracks[idx]
+	return []tui.Widget{
+		tui.NewLabel(trimWithCommasIfTooLong(track.Name, uiColumnWidth)),
+		tui.NewLabel(trimWithCommasIfTooLong(artistName(track.Artists), uiColumnWidth)),
+	}
+}
+
+func (r *Radio) tuningText() string {
+	return fmt.Sprintf("Energy: %s (e)  Tempo: %s (t)", radioEnergyRanges[r.energy].name, radioTempoRanges[r.tempo].name)
+}
+
+// trackAttributes returns attributes limiting recommendations to the ranges,
+// it returns nil when any value is accepted.
+func trackAttributes(energy, tempo radioRange) *spotify.TrackAttributes {
+	if energy.max == 0 && tempo.max == 0 {
+		return nil
+	}
+	attributes := spotify.NewTrackAttributes()
+	if energy.max != 0 {
+		attributes.MinEnergy(energy.min).MaxEnergy(energy.max)
+	}
+	if tempo.max != 0 {
+		attributes.MinTempo(tempo.min).MaxTempo(tempo.max)
+	}
+	return attributes
+}
+
+// onItemActivated plays all recommended tracks, starting from the selected one.
+func (r *Radio) onItemActivated() func(*tui.Table) {
+	return func(*tui.Table) {
+		idx := r.table.selectedRow()
+		if idx < 0 || idx >= len(r.tracks) {
+			return
+		}
+		uris := make([]spotify.URI, 0, len(r.tracks))
+		for _, track := range r.tracks {
+			uris = append(uris, track.URI)
+		}
+		err := r.client.PlayOpt(&spotify.PlayOptions{URIs: uris, PlaybackOffset: &spotify.PlaybackOffset{Position: idx}})
+		if err != nil {
+			r.reportError("Could not play radio from %s: %s", r.tracks[idx].URI, err)
+		}
+	}
+}
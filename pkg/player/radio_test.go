@@ -0,0 +1,145 @@
+package player
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zmb3/spotify"
+)
+
+type FakeRecommender struct {
+	DebugRecommender
+	givenSeeds      spotify.Seeds
+	givenAttributes *spotify.TrackAttributes
+}
+
+func (fake *FakeRecommender) GetRecommendations(seeds spotify.Seeds, trackAttributes *spotify.TrackAttributes, opt *spotify.Options) (*spotify.Recommendations, error) {
+	fake.givenSeeds = seeds
+	fake.givenAttributes = trackAttributes
+	return fake.DebugRecommender.GetRecommendations(seeds, trackAttributes, opt)
+}
+
+func TestRadioStartUsesSeed(t *testing.T) {
+	cases := []struct {
+		seed          RadioSeed
+		expectedSeeds spotify.Seeds
+	}{
+		{
+			seed:          RadioSeed{Type: "track", ID: "track1"},
+			expectedSeeds: spotify.Seeds{Tracks: []spotify.ID{"track1"}},
+		},
+		{
+			seed:          RadioSeed{Type: "artist", ID: "artist1"},
+			expectedSeeds: spotify.Seeds{Artists: []spotify.ID{"artist1"}},
+		},
+		{
+			seed: RadioSeed{Type: "album", ID: "album1"},
+			expectedSeeds: spotify.Seeds{Tracks: []spotify.ID{
+				"album1track1", "album1track2", "album1track3", "album1track4", "album1track5",
+			}},
+		},
+	}
+	for _, c := range cases {
+		recommender := &FakeRecommender{}
+		radio := NewRadio(&DebugClient{Recommender: recommender})
+		if err := radio.Start(c.seed); err != nil {
+			t.Fatalf("Did not expect to fail, but it did with %s", err)
+		}
+		if !reflect.DeepEqual(recommender.givenSeeds, c.expectedSeeds) {
+			t.Errorf("Expected seeds %v, got %v", c.expectedSeeds, recommender.givenSeeds)
+		}
+		if len(radio.tracks) != 20 {
+			t.Errorf("Expected to have 20 recommended tracks, have %d", len(radio.tracks))
+		}
+	}
+}
+
+func TestRadioStartFailsForUnsupportedSeed(t *testing.T) {
+	radio := NewRadio(&DebugClient{Recommender: &FakeRecommender{}})
+	if err := radio.Start(RadioSeed{Type: "playlist", ID: "playlist1"}); err == nil {
+		t.Fatalf("Expected to fail when starting radio from playlist")
+	}
+}
+
+func TestRadioTryStartReportsFailure(t *testing.T) {
+	radio := NewRadio(&DebugClient{Recommender: &FakeRecommender{}})
+	statusLine := NewStatusLine()
+	radio.SetStatusLine(statusLine)
+	if radio.TryStart(RadioSeed{Type: "playlist", ID: "playlist1", Name: "Mix"}) {
+		t.Fatalf("Expected radio not to be started from playlist")
+	}
+	if expected := "Could not start radio from playlist Mix: radio can not be started from playlist"; statusLine.Label.Text() != expected {
+		t.Errorf("Expected status line %q, got %q", expected, statusLine.Label.Text())
+	}
+	if !radio.TryStart(RadioSeed{Type: "track", ID: "track1"}) {
+		t.Errorf("Expected radio to be started from track")
+	}
+}
+
+func TestRadioTune(t *testing.T) {
+	recommender := &FakeRecommender{}
+	radio := NewRadio(&DebugClient{Recommender: recommender})
+	radio.Start(RadioSeed{Type: "track", ID: "track1"})
+	if recommender.givenAttributes != nil {
+		t.Fatalf("Expected not to limit attributes by default")
+	}
+
+	radio.tune(3, 0)
+	if recommender.givenAttributes == nil {
+		t.Fatalf("Expected to limit energy")
+	}
+	if expected := "Energy: high (e)  Tempo: any (t)"; radio.tuningText() != expected {
+		t.Fatalf("Expected tuning to be %q, got %q", expected, radio.tuningText())
+	}
+}
+
+func TestTrackAttributes(t *testing.T) {
+	cases := []struct {
+		energy, tempo radioRange
+		expectedNil   bool
+	}{
+		{energy: radioEnergyRanges[0], tempo: radioTempoRanges[0], expectedNil: true},
+		{energy: radioEnergyRanges[1], tempo: radioTempoRanges[0], expectedNil: false},
+		{energy: radioEnergyRanges[0], tempo: radioTempoRanges[3], expectedNil: false},
+	}
+	for _, c := range cases {
+		if got := trackAttributes(c.energy, c.tempo); (got == nil) != c.expectedNil {
+			t.Errorf("Expected attributes for %s energy and %s tempo to be nil: %v", c.energy.name, c.tempo.name, c.expectedNil)
+		}
+	}
+}
+
+func TestRadioOnItemActivatedPlaysQueueFromSelectedTrack(t *testing.T) {
+	fakePlayer := &FakeTracksPlayer{}
+	radio := NewRadio(&DebugClient{Player: fakePlayer, Recommender: DebugRecommender{}})
+	radio.Start(RadioSeed{Type: "track", ID: "track1"})
+
+	radio.table.selectRow(2)
+	radio.onItemActivated()(radio.table.Table)
+
+	opt := fakePlayer.givenOptions
+	if len(opt.URIs) != len(radio.tracks) || opt.PlaybackOffset == nil || opt.PlaybackOffset.Position != 2 {
+		t.Fatalf("Expected to play all tracks starting from the third one, got %#v", opt)
+	}
+}
+
+func TestRadioSeedFromURI(t *testing.T) {
+	seed := radioSeedFromURI("spotify:album:album1", "Album")
+	expected := RadioSeed{Type: "album", ID: "album1", Name: "Album"}
+	if seed != expected {
+		t.Fatalf("Expected seed %v, got %v", expected, seed)
+	}
+}
+
+func TestRadioStarterIgnoresEmptyAndUnsupportedSeed(t *testing.T) {
+	started := []RadioSeed{}
+	starter := &radioStarter{}
+	starter.startRadio(RadioSeed{Type: "track", ID: "track1"}) // no function set yet
+	starter.OnStartRadio(func(seed RadioSeed) { started = append(started, seed) })
+	starter.startRadio(RadioSeed{Type: "track"})
+	starter.startRadio(RadioSeed{Type: "show", ID: "show1"})
+	starter.startRadio(RadioSeed{Type: "track", ID: "track1"})
+	if len(started) != 1 {
+		t.Fatalf("Expected radio to be started once, started %d times", len(started))
+	}
+}
@@ -0,0 +1,361 @@
+package player
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// recordingVersion is the version of the format in which interactions
+// with Spotify Web API are recorded.
+const recordingVersion = 1
+
+// Recording are requests to Spotify Web API together with responses to
+// them, in the order in which they were sent. Tokens and IDs of users are
+// scrubbed from them, so that recording can be shared.
+type Recording struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a request to Web API and response to it. URL is relative
+// to Web API base URL, its query is sorted.
+type Interaction struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	// Response is the body of the response when it is JSON, Text when it
+	// is not.
+	Response json.RawMessage `json:"response,omitempty"`
+	Text     string          `json:"text,omitempty"`
+}
+
+// recordedHeaders are headers of responses which are recorded, others may
+// identify user or are not used.
+var recordedHeaders = []string{"Content-Type", "Retry-After"}
+
+// LoadRecording reads recording from the file.
+func LoadRecording(path string) (*Recording, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read recording: %v", err)
+	}
+	var recording Recording
+	if err := json.Unmarshal(data, &recording); err != nil {
+		return nil, fmt.Errorf("could not parse recording %s: %v", path, err)
+	}
+	if recording.Version != recordingVersion {
+		return nil, fmt.Errorf("recording %s has version %d, only version %d can be replayed", path, recording.Version, recordingVersion)
+	}
+	return &recording, nil
+}
+
+// requestKey identifies request, requests with the same key get the same
+// recorded response.
+func requestKey(method, url, body string) string {
+	return method + " " + url + "\n" + body
+}
+
+// relativeURL returns path of the request relative to Web API base URL,
+// with sorted query.
+func relativeURL(req *http.Request) string {
+	path := strings.TrimPrefix(req.URL.Path, "/")
+	path = strings.TrimPrefix(path, "v1/")
+	if query := req.URL.Query().Encode(); query != "" {
+		path += "?" + query
+	}
+	return path
+}
+
+// requestBody returns body of the request, request can be sent afterwards.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.GetBody == nil {
+		return nil, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
+
+// RecordingTransport is an http.RoundTripper which records requests sent
+// with base transport to Spotify Web API, together with responses to them,
+// into a file. Recording is saved after every response, so it is complete
+// even when app does not quit cleanly.
+type RecordingTransport struct {
+	base http.RoundTripper
+	path string
+
+	mu        sync.Mutex
+	recording Recording
+	scrubber  *scrubber
+}
+
+// NewRecordingTransport creates transport which sends requests with base,
+// or with http.DefaultTransport when base is nil, and records them into
+// the file at path.
+func NewRecordingTransport(base http.RoundTripper, path string) *RecordingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RecordingTransport{
+		base:      base,
+		path:      path,
+		recording: Recording{Version: recordingVersion, Interactions: []Interaction{}},
+		scrubber:  newScrubber(),
+	}
+}
+
+// RoundTrip sends request and records it with the response. Requests which
+// failed without response are not recorded.
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	interaction := Interaction{
+		Method: req.Method,
+		URL:    t.scrubber.scrubString(relativeURL(req)),
+		Body:   string(t.scrubber.scrubJSON(reqBody)),
+		Status: resp.StatusCode,
+		Header: map[string]string{},
+	}
+	for _, name := range recordedHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			interaction.Header[name] = value
+		}
+	}
+	if json.Valid(respBody) {
+		interaction.Response = t.scrubber.scrubJSON(respBody)
+	} else {
+		interaction.Text = t.scrubber.scrubString(string(respBody))
+	}
+	t.recording.Interactions = append(t.recording.Interactions, interaction)
+	if err := t.save(); err != nil {
+		log.Printf("Could not save recording: %s", err)
+	}
+	return resp, nil
+}
+
+// save writes recording, it is called with mu locked.
+func (t *RecordingTransport) save() error {
+	data, err := json.MarshalIndent(t.recording, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(t.path, data)
+}
+
+// ReplayTransport is an http.RoundTripper which answers requests with
+// recorded responses, without sending them to Spotify. When the same
+// request was recorded more than once, its responses are given in the
+// order in which they were recorded, and the last one is repeated.
+// Requests which were not recorded fail with 404 Not Found.
+type ReplayTransport struct {
+	mu        sync.Mutex
+	responses map[string][]Interaction
+	replayed  map[string]int
+	scrubber  *scrubber
+}
+
+// NewReplayTransport creates transport which replays recording.
+func NewReplayTransport(recording *Recording) *ReplayTransport {
+	t := &ReplayTransport{
+		responses: map[string][]Interaction{},
+		replayed:  map[string]int{},
+		// replayed requests are sent with IDs from recorded responses,
+		// which are already scrubbed
+		scrubber: &scrubber{},
+	}
+	for _, interaction := range recording.Interactions {
+		key := requestKey(interaction.Method, interaction.URL, interaction.Body)
+		t.responses[key] = append(t.responses[key], interaction)
+	}
+	return t
+}
+
+// NewReplayClient creates Client which gets recorded responses instead of
+// talking with Spotify Web API.
+func NewReplayClient(recording *Recording) *Client {
+	token := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "replay"})
+	return newClient(token, NewReplayTransport(recording))
+}
+
+// RoundTrip answers request with the next recorded response to it.
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// secrets are scrubbed from requests the same way as from recorded
+	// ones, so that they can be matched
+	url := t.scrubber.scrubString(relativeURL(req))
+	key := requestKey(req.Method, url, string(t.scrubber.scrubJSON(reqBody)))
+	responses := t.responses[key]
+	if len(responses) == 0 {
+		log.Printf("No recorded response to %s %s", req.Method, url)
+		return replayedResponse(req, Interaction{
+			Status:   http.StatusNotFound,
+			Header:   map[string]string{"Content-Type": "application/json"},
+			Response: json.RawMessage(`{"error": {"status": 404, "message": "No recorded response"}}`),
+		}), nil
+	}
+	idx := t.replayed[key]
+	if idx < len(responses)-1 {
+		t.replayed[key]++
+	}
+	return replayedResponse(req, responses[idx]), nil
+}
+
+func replayedResponse(req *http.Request, interaction Interaction) *http.Response {
+	body := []byte(interaction.Response)
+	if len(body) == 0 {
+		body = []byte(interaction.Text)
+	}
+	header := http.Header{}
+	for name, value := range interaction.Header {
+		header.Set(name, value)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+		StatusCode:    interaction.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// scrubbedKeys are keys of JSON objects whose values are secret, or which
+// tell who the user is.
+var scrubbedKeys = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"email":         true,
+	"country":       true,
+	"birthdate":     true,
+	"display_name":  true,
+}
+
+// userReference matches ID of a user in URIs and URLs, also in URLs which
+// are relative to Web API base URL.
+var userReference = regexp.MustCompile(`(spotify:user:|(?:^|/)users?/)([^:/?&#"\s]+)`)
+
+// scrubber removes tokens, personal details and IDs of users from recorded
+// requests and responses. Every user gets the same made up ID everywhere, so that it
+// is still known which items belong to the same user. Scrubber without
+// users keeps IDs of users, as they are already scrubbed in replayed
+// requests.
+type scrubber struct {
+	users map[string]string
+}
+
+func newScrubber() *scrubber {
+	return &scrubber{users: map[string]string{}}
+}
+
+func (s *scrubber) user(id string) string {
+	if s.users == nil {
+		return id
+	}
+	scrubbed, ok := s.users[id]
+	if !ok {
+		scrubbed = fmt.Sprintf("user%d", len(s.users)+1)
+		s.users[id] = scrubbed
+	}
+	return scrubbed
+}
+
+func (s *scrubber) scrubString(text string) string {
+	return userReference.ReplaceAllStringFunc(text, func(reference string) string {
+		parts := userReference.FindStringSubmatch(reference)
+		return parts[1] + s.user(parts[2])
+	})
+}
+
+// scrubJSON returns data with secrets scrubbed, data which is not JSON
+// is returned as it is.
+func (s *scrubber) scrubJSON(data []byte) []byte {
+	if len(data) == 0 {
+		return data
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return data
+	}
+	scrubbed, err := json.Marshal(s.scrub(value))
+	if err != nil {
+		return data
+	}
+	return scrubbed
+}
+
+func (s *scrubber) scrub(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v["type"] == "user" {
+			if id, ok := v["id"].(string); ok {
+				v["id"] = s.user(id)
+			}
+		}
+		// keys are sorted, so that users get the same made up IDs every time
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if scrubbedKeys[key] {
+				v[key] = "scrubbed"
+				continue
+			}
+			v[key] = s.scrub(v[key])
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = s.scrub(item)
+		}
+		return v
+	case string:
+		return s.scrubString(v)
+	}
+	return value
+}
@@ -0,0 +1,200 @@
+package player
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestScrubber(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{name: "not JSON", data: "not JSON", expected: "not JSON"},
+		{name: "tokens",
+			data:     `{"access_token":"secret","refresh_token":"secret","expires_in":3600}`,
+			expected: `{"access_token":"scrubbed","expires_in":3600,"refresh_token":"scrubbed"}`},
+		{name: "user",
+			data:     `{"owner":{"type":"user","id":"jan","display_name":"Jan","uri":"spotify:user:jan"}}`,
+			expected: `{"owner":{"display_name":"scrubbed","id":"user1","type":"user","uri":"spotify:user:user1"}}`},
+		{name: "personal details",
+			data:     `{"type":"user","id":"jan","email":"jan@example.com","country":"PL","birthdate":"1990-01-01"}`,
+			expected: `{"birthdate":"scrubbed","country":"scrubbed","email":"scrubbed","id":"user1","type":"user"}`},
+		{name: "user references",
+			data:     `{"href":"https://api.spotify.com/v1/users/jan/playlists?offset=0","uri":"spotify:user:anna:playlist:1"}`,
+			expected: `{"href":"https://api.spotify.com/v1/users/user1/playlists?offset=0","uri":"spotify:user:user2:playlist:1"}`},
+		{name: "large numbers",
+			data:     `{"total":12345678901234567890,"artist":{"type":"artist","id":"artist1"}}`,
+			expected: `{"artist":{"id":"artist1","type":"artist"},"total":12345678901234567890}`},
+	}
+	for _, c := range cases {
+		if scrubbed := string(newScrubber().scrubJSON([]byte(c.data))); scrubbed != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.name, c.expected, scrubbed)
+		}
+	}
+
+	s := newScrubber()
+	s.scrubString("spotify:user:jan")
+	if scrubbed := s.scrubString("users/anna/playlists and /users/jan"); scrubbed != "users/user2/playlists and /users/user1" {
+		t.Errorf("Expected users to be scrubbed consistently, got %s", scrubbed)
+	}
+}
+
+func TestRecordedInteractionsAreReplayed(t *testing.T) {
+	var str bytes.Buffer
+	log.SetOutput(&str)
+
+	dir, err := ioutil.TempDir("", "recording")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "recording.json")
+
+	api := newFakeSpotifyAPI(60, 55)
+	client, closeAPI := newFakeAPIClient(t, api)
+	transport := client.http.Transport.(*oauth2.Transport)
+	transport.Base = NewRecordingTransport(transport.Base, path)
+	sideBar, err := NewSideBar(client, NewLibrary(client))
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	updates := make(chan func())
+	sideBar.LoadAlbums(func(fn func()) { updates <- fn })
+	waitForUpdates(t, updates, func() bool { return !sideBar.AlbumList.loading.inProgress() })
+	expected := sideBar.AlbumList.shownAlbums()
+	closeAPI()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected recording to be saved, got %s", err)
+	}
+	if strings.Contains(string(data), "access-token") || strings.Contains(string(data), api.refreshToken) {
+		t.Errorf("Expected tokens not to be recorded, got %s", data)
+	}
+	recording, err := LoadRecording(path)
+	if err != nil {
+		t.Fatalf("Expected recording to be loaded, got %s", err)
+	}
+	if len(recording.Interactions) < 2 || recording.Interactions[0].URL != "me/albums?limit=25&offset=0" {
+		t.Fatalf("Expected pages of albums to be recorded, got %+v", recording.Interactions)
+	}
+
+	replayed := NewReplayClient(recording)
+	sideBar, err = NewSideBar(replayed, NewLibrary(replayed))
+	if err != nil {
+		t.Fatalf("Expected recorded albums to be replayed, got %s", err)
+	}
+	sideBar.LoadAlbums(func(fn func()) { updates <- fn })
+	waitForUpdates(t, updates, func() bool { return !sideBar.AlbumList.loading.inProgress() })
+	albums := sideBar.AlbumList.shownAlbums()
+	if len(albums) != len(expected) || len(albums) != 55 {
+		t.Fatalf("Expected %d albums to be replayed, got %d", len(expected), len(albums))
+	}
+	for i := range albums {
+		if albums[i].title != expected[i].title || albums[i].runtime != expected[i].runtime {
+			t.Errorf("Expected album %+v to be replayed, got %+v", expected[i], albums[i])
+		}
+	}
+
+	if _, err := replayed.GetAlbum("missing"); err == nil || !strings.Contains(str.String(), "No recorded response to GET albums/missing") {
+		t.Errorf("Expected request which was not recorded to fail, got %v and log %s", err, str.String())
+	}
+}
+
+func TestPersonalDetailsAreNotRecorded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recording")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "recording.json")
+
+	api := newFakeSpotifyAPI(0, 0)
+	client, closeAPI := newFakeAPIClient(t, api)
+	defer closeAPI()
+	transport := client.http.Transport.(*oauth2.Transport)
+	transport.Base = NewRecordingTransport(transport.Base, path)
+	user, err := client.CurrentUser()
+	if err != nil || user.Email != fakeUser.Email {
+		t.Fatalf("Expected current user to be fetched, got %+v and %v", user, err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected recording to be saved, got %s", err)
+	}
+	if !strings.Contains(string(data), "premium") {
+		t.Fatalf("Expected response of me to be recorded, got %s", data)
+	}
+	for _, detail := range []string{fakeUser.ID, fakeUser.DisplayName, fakeUser.Email, fakeUser.Country, fakeUser.Birthdate} {
+		if strings.Contains(string(data), detail) {
+			t.Errorf("Expected %s not to be recorded, got %s", detail, data)
+		}
+	}
+}
+
+func TestReplayedUsersKeepRecordedIDs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recording")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "recording.json")
+
+	api := newFakeSpotifyAPI(0, 0)
+	client, closeAPI := newFakeAPIClient(t, api)
+	transport := client.http.Transport.(*oauth2.Transport)
+	transport.Base = NewRecordingTransport(transport.Base, path)
+	if _, err := client.CurrentUser(); err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	if _, err := client.GetPlaylistsForUser("bob"); err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	closeAPI()
+
+	recording, err := LoadRecording(path)
+	if err != nil {
+		t.Fatalf("Expected recording to be loaded, got %s", err)
+	}
+	if len(recording.Interactions) != 2 || recording.Interactions[1].URL != "users/user2/playlists" {
+		t.Fatalf("Expected playlists of the second user to be recorded, got %+v", recording.Interactions)
+	}
+
+	replayed := NewReplayClient(recording)
+	user, err := replayed.CurrentUser()
+	if err != nil || user.ID != "user1" {
+		t.Fatalf("Expected the first user to be replayed, got %+v and %v", user, err)
+	}
+	playlists, err := replayed.GetPlaylistsForUser("user2")
+	if err != nil || len(playlists.Playlists) != 1 || playlists.Playlists[0].Owner.URI != "spotify:user:user2" {
+		t.Fatalf("Expected playlists of the second user to be replayed, got %+v and %v", playlists, err)
+	}
+}
+
+func TestReplayRepeatsRecordedOrder(t *testing.T) {
+	recording := &Recording{Version: recordingVersion, Interactions: []Interaction{
+		{Method: "GET", URL: "me/player/currently-playing", Status: 204},
+		{Method: "GET", URL: "me/player/currently-playing", Status: 200, Response: []byte(`{"is_playing":true}`)},
+	}}
+	client := NewReplayClient(recording)
+	statuses := []bool{false, true, true}
+	for i, playing := range statuses {
+		current, err := client.PlayerCurrentlyPlaying()
+		if playing && (err != nil || !current.Playing) {
+			t.Errorf("Expected response %d to be playing, got %+v and %v", i, current, err)
+		}
+		if !playing && err == nil && current.Playing {
+			t.Errorf("Expected response %d not to be playing, got %+v", i, current)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package player
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zmb3/spotify"
+)
+
+// searchItem is a found item, displayed as a row of search results.
+type searchItem interface {
+	itemURI() spotify.URI
+	itemName() string
+}
+
+type trackResult struct {
+	uri        spotify.URI
+	name       string
+	artist     string
+	album      string
+	duration   time.Duration
+	popularity int
+	explicit   bool
+}
+
+type albumResult struct {
+	uri    spotify.URI
+	name   string
+	artist string
+	year   int // 0 when release date is not known
+}
+
+type artistResult struct {
+	uri       spotify.URI
+	name      string
+	genres    []string
+	followers uint
+}
+
+type playlistResult struct {
+	uri    spotify.URI
+	name   string
+	owner  string
+	tracks uint
+}
+
+type showResult struct {
+	uri       spotify.URI
+	name      string
+	publisher string
+}
+
+type episodeResult struct {
+	uri      spotify.URI
+	name     string
+	show     string
+	released string
+	duration time.Duration
+}
+
+func (r trackResult) itemURI() spotify.URI    { return r.uri }
+func (r trackResult) itemName() string        { return r.name }
+func (r albumResult) itemURI() spotify.URI    { return r.uri }
+func (r albumResult) itemName() string        { return r.name }
+func (r artistResult) itemURI() spotify.URI   { return r.uri }
+func (r artistResult) itemName() string       { return r.name }
+func (r playlistResult) itemURI() spotify.URI { return r.uri }
+func (r playlistResult) itemName() string     { return r.name }
+func (r showResult) itemURI() spotify.URI     { return r.uri }
+func (r showResult) itemName() string         { return r.name }
+func (r episodeResult) itemURI() spotify.URI  { return r.uri }
+func (r episodeResult) itemName() string      { return r.name }
+
+// libraryItemOf returns item which can be saved in user's library.
+func libraryItemOf(item searchItem) libraryItem {
+	libraryItem := libraryItem{id: idFromURI(item.itemURI()), uri: item.itemURI(), name: item.itemName()}
+	switch i := item.(type) {
+	case trackResult:
+		libraryItem.artist = i.artist
+	case albumResult:
+		libraryItem.artist = i.artist
+	}
+	return libraryItem
+}
+
+// resultColumn is a column of search results, results can be sorted by it.
+type resultColumn struct {
+	title   string
+	stretch int
+	// width is a display width to which cells are truncated.
+	width int
+	cell  func(searchItem) string
+	less  func(a, b searchItem) bool
+}
+
+func textColumn(title string, stretch, width int, text func(searchItem) string) resultColumn {
+	return resultColumn{
+		title:   title,
+		stretch: stretch,
+		width:   width,
+		cell:    text,
+		less:    func(a, b searchItem) bool { return strings.ToLower(text(a)) < strings.ToLower(text(b)) },
+	}
+}
+
+func numberColumn(title string, width int, number func(searchItem) int, format func(int) string) resultColumn {
+	return resultColumn{
+		title:   title,
+		stretch: 1,
+		width:   width,
+		cell:    func(item searchItem) string { return format(number(item)) },
+		less:    func(a, b searchItem) bool { return number(a) < number(b) },
+	}
+}
+
+func formatDuration(ms int) string {
+	d := time.Duration(ms) * time.Millisecond
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+func formatYear(year int) string {
+	if year == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d", year)
+}
+
+func formatCount(count int) string {
+	switch {
+	case count >= 1000000:
+		return fmt.Sprintf("%.1fM", float64(count)/1000000)
+	case count >= 1000:
+		return fmt.Sprintf("%.1fk", float64(count)/1000)
+	}
+	return fmt.Sprintf("%d", count)
+}
+
+var (
+	nameColumns = []resultColumn{
+		textColumn("Name", 1, uiColumnWidth*2, func(i searchItem) string { return i.itemName() }),
+	}
+	trackColumns = []resultColumn{
+		textColumn("Title", 4, uiColumnWidth*2, func(i searchItem) string {
+			if t := i.(trackResult); t.explicit {
+				return t.name + " [E]"
+			}
+			return i.itemName()
+		}),
+		textColumn("Artist", 3, uiColumnWidth, func(i searchItem) string { return i.(trackResult).artist }),
+		textColumn("Album", 3, uiColumnWidth, func(i searchItem) string { return i.(trackResult).album }),
+		numberColumn("Time", 6, func(i searchItem) int { return int(i.(trackResult).duration / time.Millisecond) }, formatDuration),
+	}
+	albumColumns = []resultColumn{
+		textColumn("Album", 4, uiColumnWidth*2, func(i searchItem) string { return i.itemName() }),
+		textColumn("Artist", 3, uiColumnWidth, func(i searchItem) string { return i.(albumResult).artist }),
+		numberColumn("Year", 4, func(i searchItem) int { return i.(albumResult).year }, formatYear),
+	}
+	artistColumns = []resultColumn{
+		textColumn("Artist", 3, uiColumnWidth, func(i searchItem) string { return i.itemName() }),
+		textColumn("Genres", 4, uiColumnWidth*2, func(i searchItem) string { return strings.Join(i.(artistResult).genres, ", ") }),
+		numberColumn("Followers", 9, func(i searchItem) int { return int(i.(artistResult).followers) }, formatCount),
+	}
+	playlistColumns = []resultColumn{
+		textColumn("Playlist", 4, uiColumnWidth*2, func(i searchItem) string { return i.itemName() }),
+		textColumn("Owner", 3, uiColumnWidth, func(i searchItem) string { return i.(playlistResult).owner }),
+		numberColumn("Tracks", 6, func(i searchItem) int { return int(i.(playlistResult).tracks) }, formatCount),
+	}
+	showColumns = []resultColumn{
+		textColumn("Show", 4, uiColumnWidth*2, func(i searchItem) string { return i.itemName() }),
+		textColumn("Publisher", 3, uiColumnWidth, func(i searchItem) string { return i.(showResult).publisher }),
+	}
+	episodeColumns = []resultColumn{
+		textColumn("Episode", 4, uiColumnWidth*2, func(i searchItem) string { return i.itemName() }),
+		textColumn("Show", 3, uiColumnWidth, func(i searchItem) string { return i.(episodeResult).show }),
+		textColumn("Released", 2, 10, func(i searchItem) string { return i.(episodeResult).released }),
+		numberColumn("Time", 6, func(i searchItem) int { return int(i.(episodeResult).duration / time.Millisecond) }, formatDuration),
+	}
+)
+
+// sortItems sorts items by the column, keeping order of equal items.
+func sortItems(items []searchItem, column resultColumn, descending bool) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if descending {
+			return column.less(items[j], items[i])
+		}
+		return column.less(items[i], items[j])
+	})
+}
+
+// columnTitle returns title of the column with arrow telling in which direction
+// results are sorted by it, together with key which sorts them.
+func columnTitle(column resultColumn, idx int, sorted, descending bool) string {
+	title := fmt.Sprintf("%s (%d)", column.title, idx+1)
+	if !sorted {
+		return title
+	}
+	if descending {
+		return title + " ▼"
+	}
+	return title + " ▲"
+}
+
+// albumYears looks up years in which albums were released, because they
+// are not a part of search results. Albums are looked up in batches.
+func albumYears(client SpotifyClient, items []searchItem) error {
+	const batch = 20 // maximum number of albums in a single request
+	for start := 0; start < len(items); start += batch {
+		end := start + batch
+		if end > len(items) {
+			end = len(items)
+		}
+		ids := []spotify.ID{}
+		for _, item := range items[start:end] {
+			ids = append(ids, idFromURI(item.itemURI()))
+		}
+		albums, err := client.GetAlbums(ids...)
+		if err != nil {
+			return fmt.Errorf("could not fetch release dates of albums: %v", err)
+		}
+		for i, album := range albums {
+			if album == nil || start+i >= len(items) {
+				continue
+			}
+			released := album.ReleaseDateTime()
+			if result, ok := items[start+i].(albumResult); ok && !released.IsZero() {
+				result.year = released.Year()
+				items[start+i] = result
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package player
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zmb3/spotify"
+)
+
+func TestSearchResultsColumns(t *testing.T) {
+	client := NewDebugClient()
+	library := NewLibrary(client)
+	results := newSavedSearchResults(client, "Songs", trackColumns, library.tracks, "l", heart)
+	results.appendSearchResult(trackResult{
+		uri:      "spotify:track:1",
+		name:     "So What",
+		artist:   "Miles Davis",
+		album:    "Kind of Blue",
+		duration: 562 * time.Second,
+		explicit: true,
+	})
+
+	expected := []string{"So What [E]", "Miles Davis", "Kind of Blue", "9:22"}
+	if cells := results.rowTexts(results.items[0]); !reflect.DeepEqual(cells, expected) {
+		t.Errorf("Expected row %v, got %v", expected, cells)
+	}
+	expectedHeader := []string{"Title (1)", "Artist (2)", "Album (3)", "Time (4)"}
+	if cells := results.headerTexts(); !reflect.DeepEqual(cells, expectedHeader) {
+		t.Errorf("Expected header %v, got %v", expectedHeader, cells)
+	}
+	if len(results.marks) != 1 {
+		t.Errorf("Expected saved mark to be displayed next to the row, got %d marks", len(results.marks))
+	}
+
+	long := artistResult{name: "坂本龍一", genres: []string{"ambient", "classical", "electronic", "japanese jazz", "soundtrack"}, followers: 1234567}
+	expected = []string{"坂本龍一", "ambient, classical, electronic, japanese...", "1.2M"}
+	if cells := newSearchResults(client, "Artists", artistColumns).rowTexts(long); !reflect.DeepEqual(cells, expected) {
+		t.Errorf("Expected row %v, got %v", expected, cells)
+	}
+}
+
+func TestSortSearchResults(t *testing.T) {
+	client := NewDebugClient()
+	results := newSearchResults(client, "Albums", albumColumns)
+	for i, year := range []int{1959, 0, 1970} {
+		results.appendSearchResult(albumResult{
+			uri:    spotify.URI(fmt.Sprintf("spotify:album:%d", i)),
+			name:   fmt.Sprintf("Album %c", 'C'-i),
+			artist: "Miles Davis",
+			year:   year,
+		})
+	}
+
+	cases := []struct {
+		key            string
+		expectedURIs   []spotify.URI
+		expectedHeader string
+	}{
+		{key: "3", expectedURIs: []spotify.URI{"spotify:album:1", "spotify:album:0", "spotify:album:2"}, expectedHeader: "Year (3) ▲"},
+		{key: "3", expectedURIs: []spotify.URI{"spotify:album:2", "spotify:album:0", "spotify:album:1"}, expectedHeader: "Year (3) ▼"},
+		{key: "1", expectedURIs: []spotify.URI{"spotify:album:2", "spotify:album:1", "spotify:album:0"}, expectedHeader: "Album (1) ▲"},
+		{key: "2", expectedURIs: []spotify.URI{"spotify:album:2", "spotify:album:1", "spotify:album:0"}, expectedHeader: "Artist (2) ▲"}, // equal artists keep their order
+	}
+	for _, c := range cases {
+		results.table.actions[c.key](results.getTable())
+		if !reflect.DeepEqual(results.data, c.expectedURIs) {
+			t.Errorf("Expected %s to sort results to %v, got %v", c.key, c.expectedURIs, results.data)
+		}
+		if header := results.headerTexts(); !contains(header, c.expectedHeader) {
+			t.Errorf("Expected header to contain %q, got %v", c.expectedHeader, header)
+		}
+	}
+
+	results.resetSearchResults()
+	if results.sortedBy != -1 || results.headerTexts()[0] != "Album (1)" {
+		t.Errorf("Expected new results not to be sorted, got header %v", results.headerTexts())
+	}
+}
+
+// FailingAlbumSearcher fails to fetch details of albums.
+type FailingAlbumSearcher struct {
+	DebugSearcher
+}
+
+func (fs FailingAlbumSearcher) GetAlbums(ids ...spotify.ID) ([]*spotify.FullAlbum, error) {
+	return nil, fmt.Errorf("not found")
+}
+
+func TestAlbumYears(t *testing.T) {
+	items := []searchItem{}
+	for i := 0; i < 25; i++ {
+		items = append(items, albumResult{uri: spotify.URI(fmt.Sprintf("spotify:album:%d", i))})
+	}
+	if err := albumYears(NewDebugClient(), items); err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	// debug albums are released in subsequent years, counting from each batch
+	if items[0].(albumResult).year != 1960 || items[19].(albumResult).year != 1979 || items[20].(albumResult).year != 1960 {
+		t.Errorf("Expected years to be looked up in batches of 20, got %v", items)
+	}
+
+	client := &DebugClient{Searcher: FailingAlbumSearcher{}}
+	if err := albumYears(client, []searchItem{albumResult{uri: "spotify:album:1"}}); err == nil {
+		t.Errorf("Expected error when albums could not be fetched")
+	}
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
@@ -1,103 +1,821 @@
 package player
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/marcusolsson/tui-go"
 	"github.com/zmb3/spotify"
 )
 
-type URIName struct {
-	URI  spotify.URI
-	Name string
+type Search struct {
+	Box             *tui.Box
+	input           *recallEntry
+	categories      []*searchCategory
+	categoriesLabel *tui.Label
+	// hint displays filters of the typed query.
+	hint       *tui.Label
+	resultsBox *tui.Box
+	// query is the last searched query.
+	query string
+
+	// update applies results of background search in UI goroutine.
+	update    func(func())
+	typeAhead bool
+	// SavedSearches displays searches pinned by user, they are kept
+	// in history together with previous queries.
+	SavedSearches *tui.Box
+	saved         *actionTable
+	history       *SearchHistory
+
+	// cancel cancels search which is in flight, searching is its query.
+	cancel    context.CancelFunc
+	searching string
+
+	*radioStarter
+	*errorReporter
 }
 
-type Search struct {
-	Focusables []tui.Widget
-	Box        *tui.Box
+// searchPageSize is a number of results of each type on a page, it is
+// the default limit of Spotify search.
+var searchPageSize = 20
+
+// searchDebounce is how long search waits for user to stop typing the query.
+var searchDebounce = 300 * time.Millisecond
+
+// foundItems are items found for one of the results, or the reason
+// why they could not be found.
+type foundItems struct {
+	results appendReseter
+	items   []searchItem
+	total   int
+	err     error
 }
 
-func searchInputOnSubmit(client SpotifyClient, searchedSongs, searchedAlbums, searchedArtists searchResultsInterface) func(*tui.Entry) {
-	return func(entry *tui.Entry) {
-		result, err := client.Search(
-			entry.Text(),
-			spotify.SearchTypeAlbum|spotify.SearchTypeTrack|spotify.SearchTypeArtist,
-		)
+// searchItems fetches items of all given types at once, it does not touch
+// the results, so it can be run outside of UI goroutine.
+func searchItems(client SpotifyClient, input string, searched map[spotify.SearchType]appendReseter) []foundItems {
+	found := []foundItems{}
+	query, err := parseSearchQuery(input)
+	if err == nil {
+		var searchType spotify.SearchType
+		for t := range searched {
+			searchType |= t
+		}
+		var result *spotify.SearchResult
+		result, err = client.SearchOpt(query.String(), searchType, query.options())
+		if err == nil {
+			for t, results := range searched {
+				items, total := searchResultItems(result, t)
+				if t == spotify.SearchTypeAlbum {
+					if err := albumYears(client, items); err != nil {
+						log.Printf("Could not display years of albums found for %s: %s", input, err)
+					}
+				}
+				found = append(found, foundItems{results: results, items: items, total: total})
+			}
+			return found
+		}
+	}
+	for _, results := range searched {
+		found = append(found, foundItems{results: results, err: err})
+	}
+	return found
+}
+
+// searchPodcastItems fetches shows and episodes, results which are nil are skipped.
+func searchPodcastItems(client SpotifyClient, input string, searchedShows, searchedEpisodes appendReseter) []foundItems {
+	found := []foundItems{}
+	query, err := parseSearchQuery(input)
+	var result *PodcastSearchResult
+	if err == nil {
+		result, err = client.SearchPodcasts(query.String(), searchPageSize, 0)
+	}
+	for kind, results := range map[string]appendReseter{"show": searchedShows, "episode": searchedEpisodes} {
+		if results == nil {
+			continue
+		}
 		if err != nil {
-			log.Fatalf("could not search for %v, %s", entry, err)
+			found = append(found, foundItems{results: results, err: err})
+			continue
 		}
+		items, total := podcastSearchResultItems(result, kind)
+		found = append(found, foundItems{results: results, items: items, total: total})
+	}
+	return found
+}
 
-		searchedAlbums.resetSearchResults()
-		for _, i := range result.Albums.Albums {
-			searchedAlbums.appendSearchResult(URIName{Name: i.Name, URI: i.URI})
+// applyFoundItems displays found items in their results, the first
+// error is also reported with reporter.
+func applyFoundItems(reporter *errorReporter, query string, found []foundItems) {
+	reported := false
+	for _, f := range found {
+		if f.err != nil {
+			if !reported {
+				reporter.reportError("Could not search for %s: %s", query, f.err)
+				reported = true
+			}
+			failSearchResults(f.results, f.err)
+			continue
 		}
+		fillSearchResults(f.results, query, f.items, f.total)
+	}
+}
 
-		searchedSongs.resetSearchResults()
-		for _, i := range result.Tracks.Tracks {
-			searchedSongs.appendSearchResult(URIName{Name: i.Name, URI: i.URI})
+// fillSearchResults replaces search results with the first page of found items.
+func fillSearchResults(results appendReseter, query string, items []searchItem, total int) {
+	results.resetSearchResults()
+	for _, item := range items {
+		results.appendSearchResult(item)
+	}
+	if refresher, ok := results.(savedStateRefresher); ok {
+		refresher.refreshSavedState()
+	}
+	if paged, ok := results.(pagedResults); ok {
+		paged.setPage(query, 0, total)
+	}
+	if displayer, ok := results.(messageDisplayer); ok {
+		if len(items) == 0 {
+			displayer.showMessage(fmt.Sprintf("No results for %s", query))
+		} else {
+			displayer.showMessage("")
 		}
+	}
+}
 
-		searchedArtists.resetSearchResults()
+// failSearchResults removes results of the previous search, and tells
+// why there are none.
+func failSearchResults(results appendReseter, err error) {
+	results.resetSearchResults()
+	if paged, ok := results.(pagedResults); ok {
+		paged.setPage("", 0, 0)
+	}
+	if displayer, ok := results.(messageDisplayer); ok {
+		displayer.showMessage(fmt.Sprintf("Search failed: %s", err))
+	}
+}
+
+// searchResultItems returns found items of given type together with
+// the number of all items of this type which were found.
+func searchResultItems(result *spotify.SearchResult, t spotify.SearchType) ([]searchItem, int) {
+	items := []searchItem{}
+	if result == nil {
+		return items, 0
+	}
+	switch {
+	case t == spotify.SearchTypeAlbum && result.Albums != nil:
+		for _, i := range result.Albums.Albums {
+			items = append(items, albumResult{uri: i.URI, name: i.Name, artist: artistName(i.Artists)})
+		}
+		return items, result.Albums.Total
+	case t == spotify.SearchTypeTrack && result.Tracks != nil:
+		for _, i := range result.Tracks.Tracks {
+			items = append(items, trackResult{
+				uri:        i.URI,
+				name:       i.Name,
+				artist:     artistName(i.Artists),
+				album:      i.Album.Name,
+				duration:   time.Duration(i.Duration) * time.Millisecond,
+				popularity: i.Popularity,
+				explicit:   i.Explicit,
+			})
+		}
+		return items, result.Tracks.Total
+	case t == spotify.SearchTypeArtist && result.Artists != nil:
 		for _, i := range result.Artists.Artists {
-			searchedArtists.appendSearchResult(URIName{Name: i.Name, URI: i.URI})
+			items = append(items, artistResult{uri: i.URI, name: i.Name, genres: i.Genres, followers: i.Followers.Count})
+		}
+		return items, result.Artists.Total
+	case t == spotify.SearchTypePlaylist && result.Playlists != nil:
+		for _, i := range result.Playlists.Playlists {
+			owner := i.Owner.DisplayName
+			if owner == "" {
+				owner = i.Owner.ID
+			}
+			items = append(items, playlistResult{uri: i.URI, name: i.Name, owner: owner, tracks: i.Tracks.Total})
 		}
+		return items, result.Playlists.Total
+	}
+	return items, 0
+}
 
+// podcastSearchResultItems returns found shows or episodes together with
+// the number of all of them which were found.
+func podcastSearchResultItems(result *PodcastSearchResult, kind string) ([]searchItem, int) {
+	items := []searchItem{}
+	if kind == "show" {
+		for _, show := range result.Shows.Items {
+			items = append(items, showResult{uri: show.URI, name: show.Name, publisher: show.Publisher})
+		}
+		return items, result.Shows.Total
+	}
+	for _, episode := range result.Episodes.Items {
+		if episode.URI == "" {
+			continue // Spotify returns null for episodes which are not available
+		}
+		item := episodeResult{
+			uri:      episode.URI,
+			name:     episode.Name,
+			released: episode.ReleaseDate,
+			duration: time.Duration(episode.DurationMs) * time.Millisecond,
+		}
+		if episode.Show != nil {
+			item.show = episode.Show.Name
+		}
+		items = append(items, item)
+	}
+	return items, result.Episodes.Total
+}
+
+// searchPageFetcher returns function fetching pages of search results of given type.
+func searchPageFetcher(client SpotifyClient, t spotify.SearchType) pageFetcher {
+	return func(input string, offset int) ([]searchItem, int, error) {
+		query, err := parseSearchQuery(input)
+		if err != nil {
+			return nil, 0, err
+		}
+		opt := query.options()
+		opt.Limit = &searchPageSize
+		opt.Offset = &offset
+		result, err := client.SearchOpt(query.String(), t, opt)
+		if err != nil {
+			return nil, 0, err
+		}
+		items, total := searchResultItems(result, t)
+		if t == spotify.SearchTypeAlbum {
+			if err := albumYears(client, items); err != nil {
+				log.Printf("Could not display years of albums found for %s: %s", input, err)
+			}
+		}
+		return items, total, nil
+	}
+}
+
+// podcastPageFetcher returns function fetching pages of found shows or episodes.
+func podcastPageFetcher(client SpotifyClient, kind string) pageFetcher {
+	return func(input string, offset int) ([]searchItem, int, error) {
+		query, err := parseSearchQuery(input)
+		if err != nil {
+			return nil, 0, err
+		}
+		result, err := client.SearchPodcasts(query.String(), searchPageSize, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		items, total := podcastSearchResultItems(result, kind)
+		return items, total, nil
 	}
 }
 
 // NewSearch creates data structure which represent search input
-// with search results.
-func NewSearch(client SpotifyClient) *Search {
-	searchedSongs := NewSearchResults(client, "Songs")
-	searchedAlbums := NewSearchResults(client, "Albums")
-	searchedArtists := NewSearchResults(client, "Artists")
+// with search results of enabled categories.
+func NewSearch(client SpotifyClient, library *Library, categories []string) (*Search, error) {
+	searchCategories := newSearchCategories(client, library)
+	if err := enableSearchCategories(searchCategories, categories); err != nil {
+		return nil, err
+	}
+	radio := &radioStarter{}
+	reporter := &errorReporter{}
+	for _, category := range searchCategories {
+		category.results.errorReporter = reporter
+		switch category.searchType {
+		case spotify.SearchTypeTrack, spotify.SearchTypeAlbum, spotify.SearchTypeArtist:
+			category.results.table.onKey("r", category.results.onStartRadio(radio))
+		}
+	}
 
-	searchInput := tui.NewEntry()
+	searchInput := newRecallEntry()
 	searchInput.SetSizePolicy(tui.Preferred, tui.Minimum)
-	searchInput.OnSubmit(searchInputOnSubmit(client, searchedSongs, searchedAlbums, searchedArtists))
 
-	searchInputBox := tui.NewHBox(searchInput, tui.NewSpacer())
+	hint := tui.NewLabel("")
+	categoriesLabel := tui.NewLabel("")
+	searchInputBox := tui.NewVBox(tui.NewHBox(searchInput, tui.NewSpacer()), hint, categoriesLabel)
 	searchInputBox.SetTitle("Search")
 	searchInputBox.SetBorder(true)
 
-	searchResults := tui.NewVBox(searchedSongs.getBox(), searchedAlbums.getBox(), searchedArtists.getBox())
-	searchResults.SetTitle("Search Results")
-	searchResults.SetBorder(true)
+	resultsBox := tui.NewVBox()
+	resultsBox.SetTitle("Search Results")
+	resultsBox.SetBorder(true)
+
+	saved := newActionTable()
+	savedBox := tui.NewVBox(saved, tui.NewSpacer())
+	savedBox.SetTitle("Saved Searches")
+	savedBox.SetBorder(true)
+
+	search := &Search{
+		Box:             tui.NewVBox(searchInputBox, resultsBox),
+		SavedSearches:   savedBox,
+		saved:           saved,
+		input:           searchInput,
+		categories:      searchCategories,
+		categoriesLabel: categoriesLabel,
+		hint:            hint,
+		resultsBox:      resultsBox,
+		radioStarter:    radio,
+		errorReporter:   reporter,
+		update:          func(fn func()) { fn() },
+	}
+	searchInput.OnSubmit(search.onSubmit(client))
+	searchInput.OnChanged(search.onChanged(client))
+	searchInput.onRecalled = search.onChanged(client)
+	saved.OnItemActivated(search.onSavedSearchActivated(client))
+	saved.onKey("d", search.onUnpin())
+	search.renderCategories()
+	return search, nil
+}
+
+// Focusables returns saved searches, search input and tables of enabled categories.
+func (s *Search) Focusables() []tui.Widget {
+	focusables := []tui.Widget{s.saved, s.input}
+	for _, category := range s.categories {
+		if category.enabled {
+			focusables = append(focusables, category.results.getTable())
+		}
+	}
+	return focusables
+}
+
+// completeQuery completes name of the filter typed at the end of search input.
+func (s *Search) completeQuery() {
+	if !s.input.IsFocused() {
+		return
+	}
+	completed, _ := completeSearchField(s.input.Text())
+	s.input.SetText(completed)
+	s.hint.SetText(queryHint(completed))
+}
+
+// onSubmit searches for items of enabled categories right away.
+func (s *Search) onSubmit(client SpotifyClient) func(*tui.Entry) {
+	return func(entry *tui.Entry) {
+		if _, err := parseSearchQuery(entry.Text()); err != nil {
+			s.hint.SetText(fmt.Sprintf("Invalid query, %s", err))
+			return
+		}
+		s.search(client, entry.Text(), 0)
+		if s.history != nil {
+			if err := s.history.add(entry.Text()); err != nil {
+				s.reportError("Could not remember query %s: %s", entry.Text(), err)
+			}
+		}
+	}
+}
+
+// SetHistory sets history in which queries are remembered, and from which
+// they are recalled.
+func (s *Search) SetHistory(history *SearchHistory) {
+	s.history = history
+	s.input.history = history
+	history.onChange(s.renderSavedSearches)
+	s.renderSavedSearches()
+}
+
+func (s *Search) renderSavedSearches() {
+	s.saved.RemoveRows()
+	if s.history == nil {
+		return
+	}
+	for _, query := range s.history.Pinned {
+		s.saved.AppendRow(tui.NewLabel(trimWithCommasIfTooLong(query, uiColumnWidth)))
+	}
+}
+
+// pinQuery pins the query typed in search input, so that it is displayed
+// in saved searches.
+func (s *Search) pinQuery() {
+	if s.history == nil || !s.input.IsFocused() {
+		return
+	}
+	if _, err := parseSearchQuery(s.input.Text()); err != nil {
+		s.hint.SetText(fmt.Sprintf("Invalid query, %s", err))
+		return
+	}
+	if err := s.history.pin(s.input.Text()); err != nil {
+		s.reportError("Could not pin query %s: %s", s.input.Text(), err)
+	}
+}
+
+func (s *Search) selectedSavedSearch(t *tui.Table) (string, bool) {
+	if s.history == nil || t.Selected() < 0 || t.Selected() >= len(s.history.Pinned) {
+		return "", false
+	}
+	return s.history.Pinned[t.Selected()], true
+}
+
+// onSavedSearchActivated searches for the selected saved search.
+func (s *Search) onSavedSearchActivated(client SpotifyClient) func(*tui.Table) {
+	return func(t *tui.Table) {
+		query, ok := s.selectedSavedSearch(t)
+		if !ok {
+			return
+		}
+		s.input.SetText(query)
+		s.hint.SetText(queryHint(query))
+		s.search(client, query, 0)
+	}
+}
+
+func (s *Search) onUnpin() func(*tui.Table) {
+	return func(t *tui.Table) {
+		query, ok := s.selectedSavedSearch(t)
+		if !ok {
+			return
+		}
+		if err := s.history.unpin(query); err != nil {
+			s.reportError("Could not unpin query %s: %s", query, err)
+		}
+	}
+}
+
+// onChanged describes typed query, and searches for it when user stops
+// typing for searchDebounce, if type-ahead is enabled.
+func (s *Search) onChanged(client SpotifyClient) func(*tui.Entry) {
+	return func(entry *tui.Entry) {
+		s.hint.SetText(queryHint(entry.Text()))
+		if !s.typeAhead {
+			return
+		}
+		if _, err := parseSearchQuery(entry.Text()); err != nil {
+			s.cancelSearch()
+			return
+		}
+		s.search(client, entry.Text(), searchDebounce)
+	}
+}
+
+// SetUpdater sets function which applies results of background search
+// in UI goroutine, i.e. ui.Update.
+func (s *Search) SetUpdater(update func(func())) {
+	s.update = update
+	for _, category := range s.categories {
+		category.results.update = update
+	}
+}
+
+// Start makes results of background search applied with update when app
+// runs, see SetUpdater.
+func (s *Search) Start(update func(func())) {
+	s.SetUpdater(update)
+}
+
+// SetTypeAhead enables searching while query is typed.
+func (s *Search) SetTypeAhead(enabled bool) {
+	s.typeAhead = enabled
+}
+
+// search searches for items of enabled categories in background after delay.
+// Search which is in flight is cancelled, only results of the latest one are
+// displayed.
+func (s *Search) search(client SpotifyClient, input string, delay time.Duration) {
+	s.cancelSearch()
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.query = input
+	s.setSearching(input)
 
-	return &Search{
-		Focusables: []tui.Widget{searchInput, searchedSongs.getTable(), searchedAlbums.getTable(), searchedArtists.getTable()},
-		Box:        tui.NewVBox(searchInputBox, searchResults),
+	searched := map[spotify.SearchType]appendReseter{}
+	var searchedShows, searchedEpisodes appendReseter
+	for _, category := range s.categories {
+		switch {
+		case !category.enabled:
+		case category.searchType != 0:
+			searched[category.searchType] = category.results
+		case category.name == "shows":
+			searchedShows = category.results
+		case category.name == "episodes":
+			searchedEpisodes = category.results
+		}
 	}
+	update := s.update
+	run := func() {
+		if ctx.Err() != nil {
+			return
+		}
+		// requests of search which is cancelled are cancelled too
+		client := withContext(ctx, client)
+		found := []foundItems{}
+		if len(searched) > 0 {
+			found = append(found, searchItems(client, input, searched)...)
+		}
+		if searchedShows != nil || searchedEpisodes != nil {
+			found = append(found, searchPodcastItems(client, input, searchedShows, searchedEpisodes)...)
+		}
+		update(func() {
+			if ctx.Err() != nil || s.query != input {
+				return // results are stale, user searches for something else
+			}
+			applyFoundItems(s.errorReporter, input, found)
+			s.setSearching("")
+		})
+	}
+	if delay > 0 {
+		timer := time.AfterFunc(delay, run)
+		s.cancel = func() {
+			timer.Stop()
+			cancel()
+		}
+		return
+	}
+	go run()
+}
+
+// cancelSearch cancels search which is in flight, its results are not displayed.
+func (s *Search) cancelSearch() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.cancel = nil
+	s.setSearching("")
+}
+
+// setSearching displays which query is searched for, empty query hides it.
+func (s *Search) setSearching(query string) {
+	s.searching = query
+	s.resultsBox.SetTitle(s.resultsTitle())
+}
 
+func (s *Search) resultsTitle() string {
+	if s.searching == "" {
+		return "Search Results"
+	}
+	return fmt.Sprintf("Search Results (searching for %s...)", s.searching)
 }
 
+// pageFetcher fetches page of search results starting at offset, it returns
+// found items together with the number of all items which were found.
+type pageFetcher func(query string, offset int) ([]searchItem, int, error)
+
 type searchResults struct {
-	table *tui.Table
-	box   *tui.Box
-	data  []spotify.URI
+	table   *virtualTable
+	box     *tui.Box
+	message *tui.Label
+	name    string
+	data    []spotify.URI
+	items   []searchItem
+
+	// header displays titles of columns above the table, it is a separate
+	// table so that rows of results are not shifted by it.
+	header  *tui.Table
+	columns []resultColumn
+	// sortedBy is index of column by which displayed page is sorted,
+	// or -1 when it is displayed in order of relevance.
+	sortedBy   int
+	descending bool
+
+	// query is paged with fetchPage, data contains only items
+	// from the displayed page, so it is aligned with rows of the table.
+	query     string
+	offset    int
+	total     int
+	fetchPage pageFetcher
+
+	// update applies pages and saved state, which are fetched in
+	// background, in UI goroutine.
+	update func(func())
+
+	// saved is set only for results which display whether
+	// item is saved in user's library, using mark function.
+	saved *savedItems
+	mark  func(bool) string
+	marks []*tui.Label
+
+	*errorReporter
 }
 
 type appendReseter interface {
-	appendSearchResult(URIName)
+	appendSearchResult(searchItem)
 	resetSearchResults()
 }
 
-type searchResultsInterface interface {
-	appendReseter
-	getBox() *tui.Box
-	getTable() *tui.Table
-	getData() []spotify.URI
-	onItemActivated(SpotifyClient) func(*tui.Table)
+type savedStateRefresher interface {
+	refreshSavedState()
 }
 
-func (sr *searchResults) appendSearchResult(uriName URIName) {
-	sr.table.AppendRow(tui.NewLabel(uriName.Name))
-	sr.data = append(sr.data, uriName.URI)
+type pagedResults interface {
+	setPage(query string, offset, total int)
+}
+
+// messageDisplayer displays message instead of results, i.e. when
+// nothing was found.
+type messageDisplayer interface {
+	showMessage(message string)
+}
+
+func (sr *searchResults) appendSearchResult(item searchItem) {
+	sr.items = append(sr.items, item)
+	sr.appendRow(item)
+	sr.table.refresh()
+}
+
+// appendRow adds data of item which is displayed in the table.
+func (sr *searchResults) appendRow(item searchItem) {
+	if sr.saved != nil {
+		sr.marks = append(sr.marks, tui.NewLabel(sr.mark(sr.saved.isSaved(idFromURI(item.itemURI())))))
+	}
+	sr.data = append(sr.data, item.itemURI())
+}
+
+func (sr *searchResults) rowCount() int {
+	return len(sr.items)
+}
+
+// row returns cells of item with given index, truncated to widths of columns.
+func (sr *searchResults) row(idx int) []tui.Widget {
+	cells := []tui.Widget{}
+	if sr.saved != nil {
+		cells = append(cells, sr.marks[idx])
+	}
+	for _, text := range sr.rowTexts(sr.items[idx]) {
+		cells = append(cells, tui.NewLabel(text))
+	}
+	return cells
+}
+
+// rowTexts returns cells of item in displayed columns.
+func (sr *searchResults) rowTexts(item searchItem) []string {
+	texts := []string{}
+	for _, column := range sr.columns {
+		texts = append(texts, trimWithCommasIfTooLong(column.cell(item), column.width))
+	}
+	return texts
 }
 
 func (sr *searchResults) resetSearchResults() {
-	sr.table.RemoveRows()
 	sr.data = sr.data[:0]
+	sr.marks = sr.marks[:0]
+	sr.items = sr.items[:0]
+	sr.sortedBy = -1
+	sr.descending = false
+	sr.renderHeader()
+	sr.table.reset()
+}
+
+// renderHeader displays titles of columns, marking one by which results are sorted.
+func (sr *searchResults) renderHeader() {
+	sr.header.RemoveRows()
+	titles := []tui.Widget{}
+	if sr.saved != nil {
+		titles = append(titles, tui.NewLabel(sr.mark(false)))
+	}
+	for _, title := range sr.headerTexts() {
+		titles = append(titles, tui.NewLabel(title))
+	}
+	sr.header.AppendRow(titles...)
+}
+
+func (sr *searchResults) headerTexts() []string {
+	texts := []string{}
+	for i, column := range sr.columns {
+		texts = append(texts, columnTitle(column, i, i == sr.sortedBy, sr.descending))
+	}
+	return texts
+}
+
+// sortBy sorts displayed page by the column, sorting again by the same
+// column reverses the order.
+func (sr *searchResults) sortBy(idx int) {
+	if idx < 0 || idx >= len(sr.columns) {
+		return
+	}
+	if sr.sortedBy == idx {
+		sr.descending = !sr.descending
+	} else {
+		sr.sortedBy = idx
+		sr.descending = false
+	}
+	sortItems(sr.items, sr.columns[idx], sr.descending)
+	sr.data = sr.data[:0]
+	sr.marks = sr.marks[:0]
+	for _, item := range sr.items {
+		sr.appendRow(item)
+	}
+	sr.renderHeader()
+	sr.table.reset()
+}
+
+func (sr *searchResults) onSortBy(idx int) func(*tui.Table) {
+	return func(*tui.Table) {
+		sr.sortBy(idx)
+	}
+}
+
+// refreshSavedState looks up in background, in a single batch, which of
+// the items from results are saved and updates marks displayed next to them.
+func (sr *searchResults) refreshSavedState() {
+	if sr.saved == nil {
+		return
+	}
+	ids := make([]spotify.ID, 0, len(sr.data))
+	for _, uri := range sr.data {
+		ids = append(ids, idFromURI(uri))
+	}
+	marks := append([]*tui.Label{}, sr.marks...)
+	update := sr.update
+	go func() {
+		err := sr.saved.lookup(ids)
+		update(func() {
+			if err != nil {
+				sr.reportError("Could not refresh saved state of searched items: %s", err)
+				return
+			}
+			for i, markLabel := range marks {
+				markLabel.SetText(sr.mark(sr.saved.isSaved(ids[i])))
+			}
+		})
+	}()
+}
+
+func (sr *searchResults) onToggleSaved() func(*tui.Table) {
+	return func(t *tui.Table) {
+		selectedRow := sr.table.selectedRow()
+		if selectedRow < 0 || selectedRow >= len(sr.data) {
+			return
+		}
+		item := libraryItemOf(sr.items[selectedRow])
+		sr.saved.toggle(item, func(_ bool, err error) {
+			if err != nil {
+				sr.reportError("Could not toggle saved state of searched item: %s", err)
+			}
+		})
+	}
+}
+
+// onSavedChanged updates mark of the item when it is saved or removed from
+// library anywhere in the application.
+func (sr *searchResults) onSavedChanged(item libraryItem, saved bool) {
+	for i, uri := range sr.data {
+		if idFromURI(uri) == item.id && i < len(sr.marks) {
+			sr.marks[i].SetText(sr.mark(saved))
+		}
+	}
+}
+
+func (sr *searchResults) onStartRadio(radio *radioStarter) func(*tui.Table) {
+	return func(t *tui.Table) {
+		selectedRow := sr.table.selectedRow()
+		if selectedRow < 0 || selectedRow >= len(sr.items) {
+			return
+		}
+		item := sr.items[selectedRow]
+		radio.startRadio(radioSeedFromURI(item.itemURI(), item.itemName()))
+	}
+}
+
+// setPage remembers which page of results for the query is displayed.
+func (sr *searchResults) setPage(query string, offset, total int) {
+	sr.query = query
+	sr.offset = offset
+	sr.total = total
+	sr.box.SetTitle(sr.title())
+}
+
+// title returns name of results with displayed range, i.e. "Songs 21-40 of 532".
+func (sr *searchResults) title() string {
+	if len(sr.data) == 0 {
+		return sr.name
+	}
+	return fmt.Sprintf("%s %d-%d of %d", sr.name, sr.offset+1, sr.offset+len(sr.data), sr.total)
+}
+
+// showPage fetches page of results starting at offset in background, and
+// displays it unless results of another search are displayed meanwhile.
+func (sr *searchResults) showPage(offset int) {
+	if sr.fetchPage == nil || offset < 0 || offset >= sr.total || offset == sr.offset {
+		return
+	}
+	query, update := sr.query, sr.update
+	go func() {
+		items, total, err := sr.fetchPage(query, offset)
+		update(func() {
+			if sr.query != query {
+				return // user searched for something else
+			}
+			if err != nil {
+				sr.reportError("Could not change page of search results: could not fetch %s %d-%d: %s", sr.name, offset+1, offset+searchPageSize, err)
+				return
+			}
+			sr.resetSearchResults()
+			for _, item := range items {
+				sr.appendSearchResult(item)
+			}
+			sr.refreshSavedState()
+			sr.setPage(query, offset, total)
+			sr.table.reset()
+		})
+	}()
+}
+
+func (sr *searchResults) onPageChange(pages int) func(*tui.Table) {
+	return func(*tui.Table) {
+		sr.showPage(sr.offset + pages*searchPageSize)
+	}
+}
+
+func (sr *searchResults) showMessage(message string) {
+	sr.message.SetText(message)
 }
 
 func (sr *searchResults) getBox() *tui.Box {
@@ -105,22 +823,21 @@ func (sr *searchResults) getBox() *tui.Box {
 }
 
 func (sr *searchResults) getTable() *tui.Table {
-	return sr.table
-}
-
-func (sr *searchResults) getData() []spotify.URI {
-	return sr.data
+	return sr.table.Table
 }
 
 func (sr *searchResults) onItemActivated(client SpotifyClient) func(*tui.Table) {
 	return func(t *tui.Table) {
-		selectedRow := t.Selected()
+		selectedRow := sr.table.selectedRow()
+		if selectedRow < 0 || selectedRow >= len(sr.data) {
+			return
+		}
 		trackURI := &sr.data[selectedRow]
 		err := client.PlayOpt(&spotify.PlayOptions{URIs: []spotify.URI{*trackURI}})
 		if err != nil {
 			err := client.PlayOpt(&spotify.PlayOptions{PlaybackContext: trackURI}) // Fallback to these if previous vall won't work parameters.
 			if err != nil {
-				log.Printf("Could not play searched URI: %s\n", *trackURI)
+				sr.reportError("Could not play searched URI: %s", *trackURI)
 				return
 			}
 		}
@@ -129,19 +846,60 @@ func (sr *searchResults) onItemActivated(client SpotifyClient) func(*tui.Table)
 	}
 }
 
-func NewSearchResults(client SpotifyClient, name string) searchResultsInterface {
-	table := tui.NewTable(0, 0)
+// newSearchResults creates search results displaying given columns of items,
+// which are sorted by the column with key of its number.
+func newSearchResults(client SpotifyClient, name string, columns []resultColumn) *searchResults {
+	header := tui.NewTable(0, 0)
 	data := make([]spotify.URI, 0)
-	box := tui.NewVBox(table, tui.NewSpacer())
+	message := tui.NewLabel("")
+
+	results := &searchResults{
+		message:  message,
+		name:     name,
+		data:     data,
+		header:   header,
+		columns:  columns,
+		sortedBy: -1,
+		update:   func(fn func()) { fn() },
 
+		errorReporter: &errorReporter{},
+	}
+	table := newVirtualTable(results, searchPageSize)
+	box := tui.NewVBox(header, table, message, tui.NewSpacer())
 	box.SetTitle(name)
 	box.SetBorder(true)
-
-	results := &searchResults{
-		table: table,
-		box:   box,
-		data:  data,
+	results.table = table
+	results.box = box
+	for i, column := range columns {
+		table.SetColumnStretch(i, column.stretch)
+		header.SetColumnStretch(i, column.stretch)
+		table.onKey(fmt.Sprintf("%d", i+1), results.onSortBy(i))
 	}
+	results.renderHeader()
 	table.OnItemActivated(results.onItemActivated(client))
+	// whole page of results fits in the table, so PgDn and PgUp
+	// fetch next and previous pages instead of scrolling
+	table.onKey("PgDn", results.onPageChange(1))
+	table.onKey("PgUp", results.onPageChange(-1))
+	return results
+}
+
+// newSavedSearchResults creates search results which show whether items are
+// saved in user's library (or followed, for artists). Saved state of selected
+// item is toggled with given key.
+func newSavedSearchResults(client SpotifyClient, name string, columns []resultColumn, saved *savedItems, key string, mark func(bool) string) *searchResults {
+	results := newSearchResults(client, name, columns)
+	results.saved = saved
+	results.mark = mark
+	// mark is the first column, so columns of items are shifted by one
+	results.table.SetColumnStretch(0, 0)
+	results.header.SetColumnStretch(0, 0)
+	for i, column := range columns {
+		results.table.SetColumnStretch(i+1, column.stretch)
+		results.header.SetColumnStretch(i+1, column.stretch)
+	}
+	results.renderHeader()
+	results.table.onKey(key, results.onToggleSaved())
+	saved.onChange(results.onSavedChanged)
 	return results
 }
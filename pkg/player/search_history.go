@@ -0,0 +1,201 @@
+package player
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode"
+
+	tui "github.com/marcusolsson/tui-go"
+)
+
+// searchHistoryLimit is a number of queries which are remembered,
+// the oldest ones are forgotten first.
+var searchHistoryLimit = 200
+
+// SearchHistory holds queries which were searched for, the latest first,
+// together with searches pinned by user. It is saved after every change.
+type SearchHistory struct {
+	Queries []string `json:"queries"`
+	Pinned  []string `json:"pinned"`
+
+	path      string
+	listeners []func()
+}
+
+// LoadSearchHistory reads history from the file, history is empty
+// when there is no such file yet.
+func LoadSearchHistory(path string) (*SearchHistory, error) {
+	history := &SearchHistory{path: path}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read search history: %v", err)
+	}
+	if err := json.Unmarshal(data, history); err != nil {
+		return nil, fmt.Errorf("could not parse search history %s: %v", path, err)
+	}
+	return history, nil
+}
+
+// save writes history, previous history is kept when writing fails.
+func (h *SearchHistory) save() error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomically(h.path, data); err != nil {
+		return fmt.Errorf("could not save search history: %v", err)
+	}
+	return nil
+}
+
+// onChange registers function which is called when history changes.
+func (h *SearchHistory) onChange(fn func()) {
+	h.listeners = append(h.listeners, fn)
+}
+
+func (h *SearchHistory) changed() error {
+	for _, fn := range h.listeners {
+		fn()
+	}
+	return h.save()
+}
+
+// add remembers the query as the latest one.
+func (h *SearchHistory) add(query string) error {
+	h.Queries = append([]string{query}, without(h.Queries, query)...)
+	if len(h.Queries) > searchHistoryLimit {
+		h.Queries = h.Queries[:searchHistoryLimit]
+	}
+	return h.changed()
+}
+
+// Clear forgets all queries, pinned searches are kept.
+func (h *SearchHistory) Clear() error {
+	h.Queries = nil
+	return h.changed()
+}
+
+func (h *SearchHistory) pin(query string) error {
+	h.Pinned = append(without(h.Pinned, query), query)
+	return h.changed()
+}
+
+func (h *SearchHistory) unpin(query string) error {
+	h.Pinned = without(h.Pinned, query)
+	return h.changed()
+}
+
+// matching returns queries which fuzzy match the pattern, the latest first.
+func (h *SearchHistory) matching(pattern string) []string {
+	matching := []string{}
+	for _, query := range h.Queries {
+		if fuzzyMatch(pattern, query) {
+			matching = append(matching, query)
+		}
+	}
+	return matching
+}
+
+func without(items []string, item string) []string {
+	filtered := []string{}
+	for _, i := range items {
+		if i != item {
+			filtered = append(filtered, i)
+		}
+	}
+	return filtered
+}
+
+// fuzzyMatch tells whether all characters of the pattern appear in s in the
+// same order, ignoring case and whitespace of the pattern, i.e. "kob" matches
+// "Kind of Blue".
+func fuzzyMatch(pattern, s string) bool {
+	_, ok := fuzzyMatchIndexes(pattern, s)
+	return ok
+}
+
+// fuzzyMatchIndexes returns indexes of runes of s which matched the pattern.
+func fuzzyMatchIndexes(pattern, s string) ([]int, bool) {
+	target := []rune(strings.ToLower(s))
+	indexes := []int{}
+	i := 0
+	for _, r := range strings.ToLower(pattern) {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		for i < len(target) && target[i] != r {
+			i++
+		}
+		if i == len(target) {
+			return nil, false
+		}
+		indexes = append(indexes, i)
+		i++
+	}
+	return indexes, true
+}
+
+// recallEntry is an entry in which previous queries are recalled with Up
+// and Down keys, only queries fuzzy matching typed text are recalled.
+type recallEntry struct {
+	*tui.Entry
+	history *SearchHistory
+	// onRecalled is called after query is recalled, because entry
+	// does not call its OnChanged function then.
+	onRecalled func(*tui.Entry)
+
+	typed    string
+	matches  []string
+	recalled int // -1 when typed text is displayed
+}
+
+func newRecallEntry() *recallEntry {
+	return &recallEntry{Entry: tui.NewEntry(), recalled: -1}
+}
+
+// OnKeyEvent recalls queries with Up and Down keys, other keys
+// are passed to the underlying entry.
+func (e *recallEntry) OnKeyEvent(ev tui.KeyEvent) {
+	if e.IsFocused() && e.history != nil {
+		switch ev.Key {
+		case tui.KeyUp:
+			e.recall(1)
+			return
+		case tui.KeyDown:
+			e.recall(-1)
+			return
+		}
+	}
+	if e.IsFocused() {
+		e.recalled = -1
+	}
+	e.Entry.OnKeyEvent(ev)
+}
+
+// recall displays query which is step queries older than the displayed one,
+// going past the latest query displays the typed text again.
+func (e *recallEntry) recall(step int) {
+	if e.recalled < 0 {
+		e.typed = e.Text()
+		e.matches = e.history.matching(e.typed)
+	}
+	idx := e.recalled + step
+	if idx < -1 || idx >= len(e.matches) {
+		return
+	}
+	e.recalled = idx
+	if idx < 0 {
+		e.SetText(e.typed)
+	} else {
+		e.SetText(e.matches[idx])
+	}
+	if e.onRecalled != nil {
+		e.onRecalled(e.Entry)
+	}
+}
@@ -0,0 +1,170 @@
+package player
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/marcusolsson/tui-go"
+)
+
+func newTestSearchHistory(t *testing.T) (*SearchHistory, func()) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, err := LoadSearchHistory(filepath.Join(dir, "search-history.json"))
+	if err != nil {
+		t.Fatalf("Did not expect error when there is no history yet, got %s", err)
+	}
+	return history, func() { os.RemoveAll(dir) }
+}
+
+func TestSearchHistoryIsSaved(t *testing.T) {
+	history, cleanup := newTestSearchHistory(t)
+	defer cleanup()
+
+	for _, query := range []string{"blue", "miles", "blue", "coltrane"} {
+		if err := history.add(query); err != nil {
+			t.Fatalf("Did not expect error, got %s", err)
+		}
+	}
+	history.pin("artist:Davis")
+
+	loaded, err := LoadSearchHistory(history.path)
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	if expected := []string{"coltrane", "blue", "miles"}; !reflect.DeepEqual(loaded.Queries, expected) {
+		t.Errorf("Expected queries %v, latest first without duplicates, got %v", expected, loaded.Queries)
+	}
+	if expected := []string{"artist:Davis"}; !reflect.DeepEqual(loaded.Pinned, expected) {
+		t.Errorf("Expected pinned searches %v, got %v", expected, loaded.Pinned)
+	}
+
+	loaded.Clear()
+	loaded, _ = LoadSearchHistory(history.path)
+	if len(loaded.Queries) != 0 || len(loaded.Pinned) != 1 {
+		t.Errorf("Expected queries to be cleared and pinned searches to be kept, got %v and %v", loaded.Queries, loaded.Pinned)
+	}
+}
+
+func TestSearchHistoryLimit(t *testing.T) {
+	defer func(limit int) { searchHistoryLimit = limit }(searchHistoryLimit)
+	searchHistoryLimit = 3
+	history, cleanup := newTestSearchHistory(t)
+	defer cleanup()
+
+	for _, query := range []string{"a", "b", "c", "d", "e"} {
+		history.add(query)
+	}
+	if expected := []string{"e", "d", "c"}; !reflect.DeepEqual(history.Queries, expected) {
+		t.Errorf("Expected only the latest queries %v, got %v", expected, history.Queries)
+	}
+}
+
+func TestLoadInvalidSearchHistory(t *testing.T) {
+	history, cleanup := newTestSearchHistory(t)
+	defer cleanup()
+	ioutil.WriteFile(history.path, []byte("{not json"), 0600)
+	if _, err := LoadSearchHistory(history.path); err == nil {
+		t.Errorf("Expected error for invalid history")
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		s        string
+		expected bool
+	}{
+		{pattern: "", s: "anything", expected: true},
+		{pattern: "kob", s: "Kind of Blue", expected: true},
+		{pattern: "kind blue", s: "Kind of Blue", expected: true},
+		{pattern: "KIND", s: "kind of blue", expected: true},
+		{pattern: "bok", s: "Kind of Blue", expected: false},
+		{pattern: "bluee", s: "Kind of Blue", expected: false},
+		{pattern: "zółw", s: "Żółw Zółw", expected: true},
+	}
+	for _, c := range cases {
+		if fuzzyMatch(c.pattern, c.s) != c.expected {
+			t.Errorf("Expected fuzzyMatch(%q, %q) to be %v", c.pattern, c.s, c.expected)
+		}
+	}
+}
+
+func TestRecallQueries(t *testing.T) {
+	history, cleanup := newTestSearchHistory(t)
+	defer cleanup()
+	for _, query := range []string{"kind of blue", "coltrane", "blue train"} {
+		history.add(query)
+	}
+	entry := newRecallEntry()
+	entry.history = history
+	entry.SetFocused(true)
+	recalled := 0
+	entry.onRecalled = func(*tui.Entry) { recalled++ }
+	entry.SetText("blu")
+
+	cases := []struct {
+		key          tui.Key
+		expectedText string
+	}{
+		{key: tui.KeyUp, expectedText: "blue train"},
+		{key: tui.KeyUp, expectedText: "kind of blue"},
+		{key: tui.KeyUp, expectedText: "kind of blue"}, // there are no older queries matching "blu"
+		{key: tui.KeyDown, expectedText: "blue train"},
+		{key: tui.KeyDown, expectedText: "blu"},
+		{key: tui.KeyDown, expectedText: "blu"},
+	}
+	for _, c := range cases {
+		entry.OnKeyEvent(tui.KeyEvent{Key: c.key})
+		if entry.Text() != c.expectedText {
+			t.Errorf("Expected %q, got %q", c.expectedText, entry.Text())
+		}
+	}
+	if recalled != 4 {
+		t.Errorf("Expected to be notified about 4 recalled queries, got %d", recalled)
+	}
+}
+
+func TestSavedSearches(t *testing.T) {
+	history, cleanup := newTestSearchHistory(t)
+	defer cleanup()
+	client := NewDebugClient()
+	search, err := NewSearch(client, NewLibrary(client), []string{"artists"})
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	search.SetHistory(history)
+	search.input.SetFocused(true)
+	updates := make(chan func(), 1)
+	search.SetUpdater(func(fn func()) { updates <- fn })
+
+	for _, query := range []string{"miles", "tag:old", "coltrane"} {
+		search.input.SetText(query)
+		search.pinQuery()
+	}
+	if expected := []string{"miles", "coltrane"}; !reflect.DeepEqual(history.Pinned, expected) {
+		t.Fatalf("Expected valid queries %v to be pinned, got %v", expected, history.Pinned)
+	}
+
+	search.saved.Select(1)
+	search.onSavedSearchActivated(client)(search.saved.Table)
+	(<-updates)()
+	if search.input.Text() != "coltrane" || search.query != "coltrane" {
+		t.Errorf("Expected to search for saved search, got %q", search.query)
+	}
+	artists := search.categories[2].results
+	if len(artists.data) == 0 || !strings.HasPrefix(artists.items[0].itemName(), "coltrane") {
+		t.Errorf("Expected results of saved search, got %v", artists.items)
+	}
+
+	search.onUnpin()(search.saved.Table)
+	if expected := []string{"miles"}; !reflect.DeepEqual(history.Pinned, expected) {
+		t.Errorf("Expected %v to be left pinned, got %v", expected, history.Pinned)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"fmt"
 	"log"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/marcusolsson/tui-go"
 	"github.com/zmb3/spotify"
@@ -13,16 +16,21 @@ import (
 
 func TestNewSearch(t *testing.T) {
 	client := &DebugClient{}
-	search := NewSearch(client)
-	if len(search.Focusables) != 4 {
-		t.Fatalf("Expected to have 4 focusables elements, got %d", len(search.Focusables))
+	search, err := NewSearch(client, NewLibrary(client), DefaultSearchCategories)
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	if len(search.Focusables()) != 5 {
+		t.Fatalf("Expected to have 5 focusables elements, got %d", len(search.Focusables()))
 	}
 	if (search.Box.Length()) != 2 {
 		t.Fatalf("Expected to have 2 elements in search box, got %d", search.Box.Length())
 	}
 }
 
-type FakeSearcher struct{}
+type FakeSearcher struct {
+	DebugSearcher
+}
 
 func (fs *FakeSearcher) Search(query string, t spotify.SearchType) (*spotify.SearchResult, error) {
 	return &spotify.SearchResult{
@@ -39,48 +47,8 @@ func (fs *FakeSearcher) Search(query string, t spotify.SearchType) (*spotify.Sea
 	}, nil
 }
 
-type FakeSearchResult struct {
-	searchResultsInterface // I do not actually implement it, but I guarantee that this struct implements these methods (but it does not, but I am not using them so it does not matter)
-	appendCalls            int
-	resetCalls             int
-}
-
-func (fsr *FakeSearchResult) appendSearchResult(uriName URIName) {
-	fsr.appendCalls++
-}
-
-func (fsr *FakeSearchResult) resetSearchResults() {
-	fsr.resetCalls++
-}
-
-func TestSearchInputOnSubmit(t *testing.T) {
-	client := &DebugClient{}
-	client.Searcher = &FakeSearcher{}
-	testEntry := tui.Entry{}
-	testEntry.SetText("Some search query")
-
-	searchedSongs := &FakeSearchResult{}
-	searchedAlbums := &FakeSearchResult{}
-	searchedArtists := &FakeSearchResult{}
-	callback := searchInputOnSubmit(client, searchedSongs, searchedAlbums, searchedArtists)
-	callback(&testEntry)
-	for _, s := range []*FakeSearchResult{searchedSongs, searchedAlbums, searchedArtists} {
-		if s.resetCalls != 1 {
-			t.Fatalf("Expected to reset old results once, got %d resets", s.resetCalls)
-		}
-	}
-	if searchedAlbums.appendCalls != 2 {
-		t.Fatalf("Expected to append results 2 times, got %d appends", searchedAlbums.appendCalls)
-
-	}
-	if searchedArtists.appendCalls != 3 {
-		t.Fatalf("Expected to append results 3 times, got %d appends", searchedArtists.appendCalls)
-
-	}
-	if searchedSongs.appendCalls != 1 {
-		t.Fatalf("Expected to append results once, got %d appends", searchedSongs.appendCalls)
-	}
-
+func (fs *FakeSearcher) SearchOpt(query string, t spotify.SearchType, opt *spotify.Options) (*spotify.SearchResult, error) {
+	return fs.Search(query, t)
 }
 
 type FakePlayer struct {
@@ -88,10 +56,12 @@ type FakePlayer struct {
 	playCalls                 int
 	playOptErrCallWithURI     bool
 	playOptErrCallWithContext bool
+	lastPlayOpt               *spotify.PlayOptions
 }
 
 func (fp *FakePlayer) PlayOpt(opt *spotify.PlayOptions) error {
 	fp.playOptCalls++
+	fp.lastPlayOpt = opt
 
 	if fp.playOptErrCallWithContext && opt.PlaybackContext != nil {
 		return fmt.Errorf("")
@@ -151,8 +121,8 @@ func TestOnItemActivatedCallback(t *testing.T) {
 		}
 		client.Player = fakePlayer
 
-		results := NewSearchResults(client, "Results")
-		results.appendSearchResult(URIName{Name: "Name", URI: "some:spotify:uri"})
+		results := newSearchResults(client, "Results", nameColumns)
+		results.appendSearchResult(trackResult{name: "Name", uri: "some:spotify:uri"})
 		callback := results.onItemActivated(client)
 		callback(results.getTable())
 
@@ -168,16 +138,429 @@ func TestOnItemActivatedCallback(t *testing.T) {
 
 func TestAppendRemoveSearchResults(t *testing.T) {
 	client := &DebugClient{}
-	results := NewSearchResults(client, "Results")
-	testURIName := URIName{URI: "test:spotify:uri", Name: "Test Name"}
-
-	results.appendSearchResult(testURIName)
-	if resultsItemsCount := len(results.getData()); resultsItemsCount != 1 {
+	results := newSearchResults(client, "Results", nameColumns)
+	results.appendSearchResult(trackResult{uri: "test:spotify:uri", name: "Test Name"})
+	if resultsItemsCount := len(results.data); resultsItemsCount != 1 {
 		t.Fatalf("Expect results to have 1 item, but results have %d items", resultsItemsCount)
 	}
 
 	results.resetSearchResults()
-	if resultsItemsCount := len(results.getData()); resultsItemsCount != 0 {
+	if resultsItemsCount := len(results.data); resultsItemsCount != 0 {
 		t.Fatalf("Expect results to have 0 item, but results have %d items", resultsItemsCount)
 	}
 }
+
+func TestTrackSearchResultsShowSavedState(t *testing.T) {
+	fakeLibrary := &FakeTrackLibrary{DebugTrackLibrary: NewDebugTrackLibrary(1)}
+	client := &DebugClient{TrackLibrary: fakeLibrary}
+	library := NewLibrary(client)
+	results := newSavedSearchResults(client, "Songs", trackColumns, library.tracks, "l", heart)
+	updates := make(chan func())
+	results.update = func(fn func()) { updates <- fn }
+
+	results.appendSearchResult(trackResult{name: "Saved", uri: "spotify:track:savedtrack1"})
+	results.appendSearchResult(trackResult{name: "Not saved", uri: "spotify:track:other"})
+	results.refreshSavedState()
+	waitForUpdates(t, updates, func() bool { return results.marks[0].Text() == "♥" })
+
+	if len(fakeLibrary.hasTracksCalls) != 1 {
+		t.Fatalf("Expected saved state to be looked up in single call, got %d calls", len(fakeLibrary.hasTracksCalls))
+	}
+	if results.marks[0].Text() != "♥" || results.marks[1].Text() != "♡" {
+		t.Fatalf("Expected only first track to be marked as saved, got %s and %s", results.marks[0].Text(), results.marks[1].Text())
+	}
+
+	toggled := false
+	library.SetUpdater(func(fn func()) { updates <- func() { fn(); toggled = true } })
+	results.table.selectRow(1)
+	results.onToggleSaved()(results.getTable())
+	if !library.tracks.isSaved("other") || results.marks[1].Text() != "♥" {
+		t.Fatalf("Expected second track to be marked as saved before Spotify is asked")
+	}
+	waitForUpdates(t, updates, func() bool { return toggled })
+	if saved, _ := fakeLibrary.UserHasTracks("other"); !saved[0] {
+		t.Fatalf("Expected second track to be saved after toggling")
+	}
+}
+
+// FakePagedSearcher finds given number of tracks, and returns them page by page.
+type FakePagedSearcher struct {
+	DebugSearcher
+	total int
+}
+
+func (fs *FakePagedSearcher) SearchOpt(query string, t spotify.SearchType, opt *spotify.Options) (*spotify.SearchResult, error) {
+	page := &spotify.FullTrackPage{}
+	page.Total = fs.total
+	for i := *opt.Offset; i < *opt.Offset+*opt.Limit && i < fs.total; i++ {
+		track := spotify.FullTrack{}
+		track.Name = fmt.Sprintf("Track %d", i)
+		track.URI = spotify.URI(fmt.Sprintf("spotify:track:%d", i))
+		page.Tracks = append(page.Tracks, track)
+	}
+	return &spotify.SearchResult{Tracks: page}, nil
+}
+
+func TestSearchResultsPaging(t *testing.T) {
+	client := &DebugClient{Searcher: &FakePagedSearcher{total: 45}, TrackLibrary: NewDebugTrackLibrary(0)}
+	library := NewLibrary(client)
+	results := newSavedSearchResults(client, "Songs", trackColumns, library.tracks, "l", heart)
+	results.fetchPage = searchPageFetcher(client, spotify.SearchTypeTrack)
+	// saved state of every page is looked up after it is displayed
+	updates := make(chan func(), 10)
+	results.update = func(fn func()) { updates <- fn }
+
+	firstPage, _, _ := results.fetchPage("query", 0)
+	fillSearchResults(results, "query", firstPage, 45)
+
+	// changePage waits until page, if there is one, is fetched in background
+	changePage := func(pages int) {
+		offset := results.offset
+		results.onPageChange(pages)(results.getTable())
+		if next := offset + pages*searchPageSize; next >= 0 && next < results.total {
+			waitForUpdates(t, updates, func() bool { return results.offset == next })
+		}
+	}
+
+	cases := []struct {
+		pages            int
+		expectedTitle    string
+		expectedFirstURI spotify.URI
+	}{
+		{pages: 1, expectedTitle: "Songs 21-40 of 45", expectedFirstURI: "spotify:track:20"},
+		{pages: 1, expectedTitle: "Songs 41-45 of 45", expectedFirstURI: "spotify:track:40"},
+		{pages: 1, expectedTitle: "Songs 41-45 of 45", expectedFirstURI: "spotify:track:40"}, // there is no next page
+		{pages: -2, expectedTitle: "Songs 1-20 of 45", expectedFirstURI: "spotify:track:0"},
+		{pages: -1, expectedTitle: "Songs 1-20 of 45", expectedFirstURI: "spotify:track:0"}, // there is no previous page
+	}
+	for _, c := range cases {
+		for i := 0; i < c.pages; i++ {
+			changePage(1)
+		}
+		for i := 0; i > c.pages; i-- {
+			changePage(-1)
+		}
+		if results.title() != c.expectedTitle {
+			t.Errorf("Expected title %q, got %q", c.expectedTitle, results.title())
+		}
+		if results.data[0] != c.expectedFirstURI {
+			t.Errorf("Expected first result to be %s, got %s", c.expectedFirstURI, results.data[0])
+		}
+		if len(results.data) != len(results.items) || len(results.data) != len(results.marks) {
+			t.Errorf("Expected data to be aligned with %d rows, have %d items", len(results.marks), len(results.data))
+		}
+	}
+}
+
+func TestNewSearchWithUnknownCategory(t *testing.T) {
+	client := &DebugClient{}
+	if _, err := NewSearch(client, NewLibrary(client), []string{"songs", "audiobooks"}); err == nil {
+		t.Fatalf("Expected error for unknown category")
+	}
+}
+
+func TestSearchRadioIsStartedFromSongsAlbumsAndArtists(t *testing.T) {
+	client := NewDebugClient()
+	search, err := NewSearch(client, NewLibrary(client), SearchCategories)
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	for _, category := range search.categories {
+		_, bound := category.results.table.actions["r"]
+		expected := category.name == "songs" || category.name == "albums" || category.name == "artists"
+		if bound != expected {
+			t.Errorf("Expected radio to be started from %s: %t", category.name, expected)
+		}
+	}
+}
+
+func TestToggleSearchCategory(t *testing.T) {
+	client := NewDebugClient()
+	search, err := NewSearch(client, NewLibrary(client), []string{"songs"})
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	search.query = "query"
+	updates := make(chan func())
+	search.SetUpdater(func(fn func()) { updates <- fn })
+
+	cases := []struct {
+		idx                int
+		expectedFocusables int
+		expectedText       string
+	}{
+		{
+			idx:                4,
+			expectedFocusables: 4,
+			expectedText:       "[x] Songs (Alt+1)  [ ] Albums (Alt+2)  [ ] Artists (Alt+3)  [ ] Playlists (Alt+4)  [x] Shows (Alt+5)  [ ] Episodes (Alt+6)",
+		},
+		{
+			idx:                0,
+			expectedFocusables: 3,
+			expectedText:       "[ ] Songs (Alt+1)  [ ] Albums (Alt+2)  [ ] Artists (Alt+3)  [ ] Playlists (Alt+4)  [x] Shows (Alt+5)  [ ] Episodes (Alt+6)",
+		},
+		{
+			idx:                10, // there is no such category
+			expectedFocusables: 3,
+			expectedText:       "[ ] Songs (Alt+1)  [ ] Albums (Alt+2)  [ ] Artists (Alt+3)  [ ] Playlists (Alt+4)  [x] Shows (Alt+5)  [ ] Episodes (Alt+6)",
+		},
+	}
+	for _, c := range cases {
+		search.toggleCategory(c.idx)
+		if len(search.Focusables()) != c.expectedFocusables {
+			t.Errorf("Expected %d focusables, got %d", c.expectedFocusables, len(search.Focusables()))
+		}
+		if search.resultsBox.Length() != c.expectedFocusables-2 { // saved searches and search input are not results
+			t.Errorf("Expected %d boxes with results, got %d", c.expectedFocusables-2, search.resultsBox.Length())
+		}
+		if search.categoriesLabel.Text() != c.expectedText {
+			t.Errorf("Expected categories %q, got %q", c.expectedText, search.categoriesLabel.Text())
+		}
+	}
+	shows := search.categories[4].results
+	waitForUpdates(t, updates, func() bool { return len(shows.data) == 3 })
+}
+
+func TestPlaylistSearchResultItems(t *testing.T) {
+	playlist := spotify.SimplePlaylist{Name: "Playlist", URI: "spotify:playlist:1"}
+	playlist.Owner.ID = "owner"
+	playlist.Tracks.Total = 12
+	named := playlist
+	named.Owner.DisplayName = "Owner Name"
+	result := &spotify.SearchResult{Playlists: &spotify.SimplePlaylistPage{Playlists: []spotify.SimplePlaylist{playlist, named}}}
+	result.Playlists.Total = 7
+
+	items, total := searchResultItems(result, spotify.SearchTypePlaylist)
+	if total != 7 {
+		t.Errorf("Expected total of 7 playlists, got %d", total)
+	}
+	expected := []searchItem{
+		playlistResult{uri: "spotify:playlist:1", name: "Playlist", owner: "owner", tracks: 12},
+		playlistResult{uri: "spotify:playlist:1", name: "Playlist", owner: "Owner Name", tracks: 12},
+	}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("Expected items %v, got %v", expected, items)
+	}
+}
+
+type FailingSearcher struct {
+	DebugSearcher
+}
+
+func (fs FailingSearcher) SearchOpt(query string, t spotify.SearchType, opt *spotify.Options) (*spotify.SearchResult, error) {
+	return nil, fmt.Errorf("rate limited")
+}
+
+// searchAndWait searches for query, and applies results when they are found.
+func searchAndWait(t *testing.T, search *Search, client SpotifyClient, query string) {
+	updates := make(chan func())
+	search.SetUpdater(func(fn func()) { updates <- fn })
+	search.search(client, query, 0)
+	waitForUpdates(t, updates, func() bool { return search.searching == "" })
+}
+
+func TestSearchFillsResultsOfEnabledCategories(t *testing.T) {
+	fakeSearcherClient := NewDebugClient().(DebugClient)
+	fakeSearcherClient.Searcher = &FakeSearcher{}
+	cases := []struct {
+		client          SpotifyClient
+		categories      []string
+		expectedResults []int
+	}{
+		{fakeSearcherClient, DefaultSearchCategories, []int{1, 2, 3}},
+		{NewDebugClient(), []string{"shows", "episodes"}, []int{3, 3}},
+	}
+	for _, c := range cases {
+		search, err := NewSearch(c.client, NewLibrary(c.client), c.categories)
+		if err != nil {
+			t.Fatalf("Did not expect error, got %s", err)
+		}
+		searchAndWait(t, search, c.client, "Some search query")
+		for i, name := range c.categories {
+			results := findSearchCategory(search.categories, name).results
+			if len(results.items) != c.expectedResults[i] {
+				t.Errorf("Expected %d %s, got %d", c.expectedResults[i], name, len(results.items))
+			}
+		}
+	}
+}
+
+func TestSearchReportsFailure(t *testing.T) {
+	client := &DebugClient{Searcher: FailingSearcher{}}
+	search, err := NewSearch(client, NewLibrary(client), []string{"songs"})
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	status := NewStatusLine()
+	search.SetStatusLine(status)
+	results := search.categories[0].results
+	results.appendSearchResult(trackResult{name: "Old result", uri: "spotify:track:old"})
+
+	searchAndWait(t, search, client, "blue")
+	if expected := "Could not search for blue: rate limited"; status.Label.Text() != expected {
+		t.Errorf("Expected status line %q, got %q", expected, status.Label.Text())
+	}
+	if len(results.data) != 0 {
+		t.Errorf("Expected old results to be removed, got %d", len(results.data))
+	}
+	if results.message.Text() != "Search failed: rate limited" {
+		t.Errorf("Expected error to be displayed in results, got %q", results.message.Text())
+	}
+}
+
+func TestSearchResultsMessage(t *testing.T) {
+	client := NewDebugClient()
+	search, err := NewSearch(client, NewLibrary(client), []string{"artists"})
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	results := search.categories[2].results
+	cases := []struct {
+		query           string
+		expectedResults int
+		expectedMessage string
+	}{
+		{query: "nothing", expectedResults: 0, expectedMessage: "No results for nothing"},
+		{query: "blue", expectedResults: 8, expectedMessage: ""},
+	}
+	for _, c := range cases {
+		searchAndWait(t, search, client, c.query)
+		if len(results.data) != c.expectedResults {
+			t.Errorf("Expected %d results for %q, got %d", c.expectedResults, c.query, len(results.data))
+		}
+		if results.message.Text() != c.expectedMessage {
+			t.Errorf("Expected message %q for %q, got %q", c.expectedMessage, c.query, results.message.Text())
+		}
+	}
+}
+
+func TestDebugSearcher(t *testing.T) {
+	searcher := DebugSearcher{}
+	result, err := searcher.Search("blue", spotify.SearchTypeTrack|spotify.SearchTypePlaylist)
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	if result.Albums != nil || result.Artists != nil {
+		t.Errorf("Expected to find only requested types")
+	}
+	if len(result.Tracks.Tracks) != searchPageSize || result.Tracks.Total != 45 {
+		t.Errorf("Expected first page of 45 tracks, got %d of %d", len(result.Tracks.Tracks), result.Tracks.Total)
+	}
+	if result.Tracks.Tracks[0].Name != "blue Song 1" {
+		t.Errorf("Expected tracks to be named after query, got %s", result.Tracks.Tracks[0].Name)
+	}
+	if len(result.Playlists.Playlists) != 12 {
+		t.Errorf("Expected 12 playlists, got %d", len(result.Playlists.Playlists))
+	}
+
+	offset := 40
+	result, _ = searcher.SearchOpt("blue", spotify.SearchTypeTrack, &spotify.Options{Offset: &offset})
+	if len(result.Tracks.Tracks) != 5 || result.Tracks.Tracks[0].Name != "blue Song 41" {
+		t.Errorf("Expected the last 5 tracks, got %d", len(result.Tracks.Tracks))
+	}
+}
+
+// FakeBlockingSearcher finds one track named after the query, but only once
+// search for the query is released.
+type FakeBlockingSearcher struct {
+	DebugSearcher
+	mu       sync.Mutex
+	released map[string]chan bool
+	started  chan string
+	calls    int
+}
+
+func (fs *FakeBlockingSearcher) release(query string) chan bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.released[query] == nil {
+		fs.released[query] = make(chan bool, 1)
+	}
+	return fs.released[query]
+}
+
+func (fs *FakeBlockingSearcher) SearchOpt(query string, t spotify.SearchType, opt *spotify.Options) (*spotify.SearchResult, error) {
+	fs.mu.Lock()
+	fs.calls++
+	fs.mu.Unlock()
+	fs.started <- query
+	<-fs.release(query)
+	track := spotify.FullTrack{}
+	track.Name = query
+	track.URI = spotify.URI("spotify:track:" + query)
+	page := &spotify.FullTrackPage{Tracks: []spotify.FullTrack{track}}
+	page.Total = 1
+	return &spotify.SearchResult{Tracks: page}, nil
+}
+
+func newBackgroundSearch(t *testing.T, searcher *FakeBlockingSearcher) (*Search, chan func()) {
+	client := &DebugClient{Searcher: searcher, TrackLibrary: NewDebugTrackLibrary(0)}
+	search, err := NewSearch(client, NewLibrary(client), []string{"songs"})
+	if err != nil {
+		t.Fatalf("Did not expect error, got %s", err)
+	}
+	updates := make(chan func(), 10)
+	search.SetUpdater(func(fn func()) { updates <- fn })
+	return search, updates
+}
+
+func TestBackgroundSearchDisplaysOnlyLatestResults(t *testing.T) {
+	searcher := &FakeBlockingSearcher{released: map[string]chan bool{}, started: make(chan string, 10)}
+	search, updates := newBackgroundSearch(t, searcher)
+	client := &DebugClient{Searcher: searcher}
+
+	search.search(client, "first", 0)
+	if search.resultsTitle() != "Search Results (searching for first...)" {
+		t.Errorf("Expected loading indicator, got %q", search.resultsTitle())
+	}
+	<-searcher.started // first search is in flight when the second one starts
+	search.search(client, "second", 0)
+	searcher.release("second") <- true
+	(<-updates)()
+	searcher.release("first") <- true
+	(<-updates)()
+
+	songs := search.categories[0].results
+	if len(songs.data) != 1 || songs.data[0] != "spotify:track:second" {
+		t.Errorf("Expected only results of the latest query, got %v", songs.data)
+	}
+	if search.resultsTitle() != "Search Results" {
+		t.Errorf("Expected loading indicator to disappear, got %q", search.resultsTitle())
+	}
+}
+
+func TestTypeAheadSearchIsDebounced(t *testing.T) {
+	defer func(debounce time.Duration) { searchDebounce = debounce }(searchDebounce)
+	searchDebounce = 20 * time.Millisecond
+
+	searcher := &FakeBlockingSearcher{released: map[string]chan bool{}, started: make(chan string, 10)}
+	search, updates := newBackgroundSearch(t, searcher)
+	client := &DebugClient{Searcher: searcher}
+	search.SetTypeAhead(true)
+	onChanged := search.onChanged(client)
+
+	entry := tui.NewEntry()
+	for _, text := range []string{"b", "bl", "blu", "blue"} {
+		entry.SetText(text)
+		onChanged(entry)
+	}
+	searcher.release("blue") <- true
+	(<-updates)()
+
+	searcher.mu.Lock()
+	defer searcher.mu.Unlock()
+	if searcher.calls != 1 {
+		t.Errorf("Expected to search once user stops typing, searched %d times", searcher.calls)
+	}
+	if songs := search.categories[0].results; len(songs.data) != 1 || songs.data[0] != "spotify:track:blue" {
+		t.Errorf("Expected results of the typed query, got %v", songs.data)
+	}
+
+	search.SetTypeAhead(false)
+	entry.SetText("bluer")
+	onChanged(entry)
+	time.Sleep(2 * searchDebounce)
+	if searcher.calls != 1 {
+		t.Errorf("Expected not to search while typing when type-ahead is disabled, searched %d times", searcher.calls)
+	}
+}
@@ -0,0 +1,46 @@
+package player
+
+import "github.com/jedruniu/spotify-cli/pkg/web"
+
+// playerStatesBuffer is the number of player states which are kept for a part
+// of the application which did not receive them yet. When it lags behind by
+// more states, the oldest ones are dropped.
+var playerStatesBuffer = 16
+
+// SplitPlayerStates forwards player state changes to n channels, so that
+// every part of the application can follow them. Every state is delivered
+// to all channels, in order, but one which is not received fast enough
+// does not stall the others, see playerStatesBuffer.
+func SplitPlayerStates(playerStateChanges <-chan *web.WebPlaybackState, n int) []chan *web.WebPlaybackState {
+	outputs := make([]chan *web.WebPlaybackState, n)
+	for i := range outputs {
+		outputs[i] = make(chan *web.WebPlaybackState, playerStatesBuffer)
+	}
+	go func() {
+		for state := range playerStateChanges {
+			for _, output := range outputs {
+				sendLatest(output, state)
+			}
+		}
+		for _, output := range outputs {
+			close(output)
+		}
+	}()
+	return outputs
+}
+
+// sendLatest sends state without waiting for it to be received, the oldest
+// buffered state is dropped when output is full.
+func sendLatest(output chan *web.WebPlaybackState, state *web.WebPlaybackState) {
+	for {
+		select {
+		case output <- state:
+			return
+		default:
+		}
+		select {
+		case <-output:
+		default:
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package player
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jedruniu/spotify-cli/pkg/web"
+)
+
+func TestSplitPlayerStates(t *testing.T) {
+	input := make(chan *web.WebPlaybackState)
+	outputs := SplitPlayerStates(input, 2)
+
+	state := &web.WebPlaybackState{CurrentTrackName: "Track"}
+	go func() {
+		input <- state
+		close(input)
+	}()
+
+	for i, output := range outputs {
+		if got := <-output; got != state {
+			t.Fatalf("Expected output %d to receive state, got %v", i, got)
+		}
+	}
+	for i, output := range outputs {
+		if _, open := <-output; open {
+			t.Fatalf("Expected output %d to be closed after input is closed", i)
+		}
+	}
+}
+
+func TestSplitPlayerStatesDropsOldestForLaggingOutput(t *testing.T) {
+	input := make(chan *web.WebPlaybackState)
+	outputs := SplitPlayerStates(input, 2)
+
+	states := make([]*web.WebPlaybackState, 3*playerStatesBuffer)
+	for i := range states {
+		states[i] = &web.WebPlaybackState{CurrentTrackName: fmt.Sprintf("Track %d", i)}
+		input <- states[i] // outputs are not received, so they must not stall input
+	}
+	close(input)
+
+	for i, output := range outputs {
+		received := []*web.WebPlaybackState{}
+		for state := range output {
+			received = append(received, state)
+		}
+		// the last state may be still forwarded while output is received
+		if len(received) < playerStatesBuffer || len(received) > playerStatesBuffer+1 {
+			t.Fatalf("Expected output %d to keep %d states, got %d", i, playerStatesBuffer, len(received))
+		}
+		for j, state := range received {
+			if expected := states[len(states)-len(received)+j]; state != expected {
+				t.Fatalf("Expected output %d to keep the latest states in order, got %s at %d", i, state.CurrentTrackName, j)
+			}
+		}
+	}
+}
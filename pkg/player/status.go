@@ -0,0 +1,83 @@
+package player
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	tui "github.com/marcusolsson/tui-go"
+)
+
+// statusMessageTimeout is how long message is displayed in the status line.
+var statusMessageTimeout = 5 * time.Second
+
+// StatusLine displays errors of actions which user triggered, so that they
+// are noticed without looking into logs. Message disappears after a while.
+type StatusLine struct {
+	Label *tui.Label
+
+	mu       sync.Mutex
+	messages int
+	// update clears message in UI goroutine, i.e. ui.Update.
+	update func(func())
+}
+
+// NewStatusLine creates status line, errors are reported to it by views
+// to which it is set with SetStatusLine.
+func NewStatusLine() *StatusLine {
+	label := tui.NewLabel("")
+	label.SetStyleName("error")
+	return &StatusLine{Label: label, update: func(fn func()) { fn() }}
+}
+
+// Start makes messages cleared with update when app runs, so that they
+// are cleared in UI goroutine.
+func (s *StatusLine) Start(update func(func())) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.update = update
+}
+
+// show displays message until it is replaced or its timeout passes, it is
+// called in UI goroutine.
+func (s *StatusLine) show(message string) {
+	s.mu.Lock()
+	s.messages++
+	shown := s.messages
+	s.mu.Unlock()
+	s.Label.SetText(message)
+	time.AfterFunc(statusMessageTimeout, func() {
+		s.mu.Lock()
+		update := s.update
+		s.mu.Unlock()
+		update(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.messages == shown {
+				s.Label.SetText("")
+			}
+		})
+	})
+}
+
+// errorReporter reports errors to the status line, which is set after
+// views are created. It is shared by view and its parts.
+type errorReporter struct {
+	status *StatusLine
+}
+
+// SetStatusLine sets status line to which errors are reported, until it
+// is set they are only logged.
+func (r *errorReporter) SetStatusLine(status *StatusLine) {
+	r.status = status
+}
+
+// reportError logs the error and displays it in the status line.
+func (r *errorReporter) reportError(format string, v ...interface{}) {
+	message := fmt.Sprintf(format, v...)
+	log.Output(2, message)
+	if r != nil && r.status != nil {
+		r.status.show(message)
+	}
+}
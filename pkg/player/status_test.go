@@ -0,0 +1,49 @@
+package player
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReportError(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	defer func(timeout time.Duration) { statusMessageTimeout = timeout }(statusMessageTimeout)
+	statusMessageTimeout = 10 * time.Millisecond
+
+	// uiMu stands for UI goroutine, label is changed only while it is held
+	var uiMu sync.Mutex
+	statusLine := NewStatusLine()
+	statusLine.Start(func(fn func()) {
+		uiMu.Lock()
+		defer uiMu.Unlock()
+		fn()
+	})
+	reporter := &errorReporter{}
+	reporter.SetStatusLine(statusLine)
+
+	uiMu.Lock()
+	reporter.reportError("Could not play %s: %s", "spotify:track:1", "premium required")
+	text := statusLine.Label.Text()
+	uiMu.Unlock()
+	if text != "Could not play spotify:track:1: premium required" {
+		t.Errorf("Expected error to be displayed, got %q", text)
+	}
+	if !strings.Contains(logs.String(), "Could not play spotify:track:1: premium required") {
+		t.Errorf("Expected error to be logged, got %q", logs.String())
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	uiMu.Lock()
+	text = statusLine.Label.Text()
+	uiMu.Unlock()
+	if text != "" {
+		t.Errorf("Expected error to disappear after timeout, got %q", text)
+	}
+}
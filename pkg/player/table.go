@@ -0,0 +1,43 @@
+package player
+
+import (
+	tui "github.com/marcusolsson/tui-go"
+)
+
+// actionTable is a tui.Table which additionally runs actions bound to
+// key names (the same names which are used by tui.UI keybindings, i.e. "l"
+// or "PgDn") while table is focused.
+type actionTable struct {
+	*tui.Table
+	actions map[string]func(*tui.Table)
+	// intercept handles key events before actions while table is focused,
+	// it returns whether event was handled, i.e. when text is typed into table.
+	intercept func(tui.KeyEvent) bool
+}
+
+func newActionTable() *actionTable {
+	return &actionTable{
+		Table:   tui.NewTable(0, 0),
+		actions: map[string]func(*tui.Table){},
+	}
+}
+
+// onKey binds action to the key with given name.
+func (t *actionTable) onKey(name string, fn func(*tui.Table)) {
+	t.actions[name] = fn
+}
+
+// OnKeyEvent runs action bound to the pressed key, or passes event
+// to the underlying table when there is no such action.
+func (t *actionTable) OnKeyEvent(ev tui.KeyEvent) {
+	if t.IsFocused() {
+		if t.intercept != nil && t.intercept(ev) {
+			return
+		}
+		if fn, ok := t.actions[ev.Name()]; ok {
+			fn(t.Table)
+			return
+		}
+	}
+	t.Table.OnKeyEvent(ev)
+}
@@ -0,0 +1,31 @@
+package player
+
+import (
+	"testing"
+
+	"github.com/marcusolsson/tui-go"
+)
+
+func TestActionTableRunsActionsOnlyWhenFocused(t *testing.T) {
+	table := newActionTable()
+	table.AppendRow(tui.NewLabel("first"))
+	table.AppendRow(tui.NewLabel("second"))
+	calls := 0
+	table.onKey("l", func(*tui.Table) { calls++ })
+
+	table.OnKeyEvent(tui.KeyEvent{Key: tui.KeyRune, Rune: 'l'})
+	if calls != 0 {
+		t.Fatalf("Expected action not to be called when table is not focused")
+	}
+
+	table.SetFocused(true)
+	table.OnKeyEvent(tui.KeyEvent{Key: tui.KeyRune, Rune: 'l'})
+	if calls != 1 {
+		t.Fatalf("Expected action to be called once, it was called %d times", calls)
+	}
+
+	table.OnKeyEvent(tui.KeyEvent{Key: tui.KeyDown})
+	if table.Selected() != 1 {
+		t.Fatalf("Expected keys without actions to be handled by table, selected row is %d", table.Selected())
+	}
+}
@@ -0,0 +1,309 @@
+package player
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tui "github.com/marcusolsson/tui-go"
+	"github.com/zmb3/spotify"
+)
+
+// topTimeranges are time ranges for which Spotify computes top items,
+// from the last 4 weeks, through the last 6 months, to several years.
+var topTimeranges = []string{"short", "medium", "long"}
+
+var topItemsLimit = 50
+
+type topKind int
+
+const (
+	topTracks topKind = iota
+	topArtists
+)
+
+func (k topKind) String() string {
+	if k == topArtists {
+		return "artists"
+	}
+	return "tracks"
+}
+
+// topItem is a row of Top view, for artists details are genres and
+// artist is empty, for tracks details are the album.
+type topItem struct {
+	name    string
+	artist  string
+	details string
+	uri     spotify.URI
+}
+
+// Top represents view with user's top tracks and artists in one
+// of time ranges. Items are fetched once for every tab.
+type Top struct {
+	client    SpotifyClient
+	table     *virtualTable
+	box       *tui.Box
+	tabs      *tui.Label
+	exportDir string
+
+	kind      topKind
+	timerange int
+	items     map[string][]topItem
+	// offline tells that top items could not be fetched, because Spotify
+	// was not reachable, see ConnectionChanged.
+	offline bool
+
+	*radioStarter
+	*errorReporter
+}
+
+// NewTop creates Top view with top tracks from short term. Exported
+// lists are written to exportDir.
+func NewTop(client SpotifyClient, exportDir string) (*Top, error) {
+	top := &Top{
+		client:    client,
+		exportDir: exportDir,
+		items:     map[string][]topItem{},
+
+		radioStarter:  &radioStarter{},
+		errorReporter: &errorReporter{},
+	}
+	table := newVirtualTable(top, viewRows)
+	table.SetColumnStretch(0, 1)
+	table.SetColumnStretch(1, 6)
+	table.SetColumnStretch(2, 4)
+	table.SetColumnStretch(3, 4)
+
+	tabs := tui.NewLabel("")
+	box := tui.NewVBox(tabs, table, tui.NewSpacer())
+	box.SetSizePolicy(tui.Expanding, tui.Expanding)
+	top.table, top.box, top.tabs = table, box, tabs
+
+	table.OnItemActivated(top.onItemActivated())
+	table.onKey("t", func(*tui.Table) { top.show(top.kind, (top.timerange+1)%len(topTimeranges)) })
+	table.onKey("a", func(*tui.Table) { top.show((top.kind+1)%2, top.timerange) })
+	table.onKey("r", func(*tui.Table) {
+		items := top.currentItems()
+		if idx := top.table.selectedRow(); idx >= 0 && idx < len(items) {
+			top.startRadio(radioSeedFromURI(items[idx].uri, items[idx].name))
+		}
+	})
+	table.onKey("e", func(*tui.Table) {
+		path, err := top.export()
+		if err != nil {
+			top.reportError("Could not export top %s: %s", top.kind, err)
+			top.box.SetTitle("Export failed")
+			return
+		}
+		top.box.SetTitle(fmt.Sprintf("Exported to %s", path))
+	})
+
+	err := top.show(topTracks, 0)
+	if isConnectionError(err) {
+		top.offline = true
+		top.tabs.SetText(top.tabsText())
+		box.SetTitle("Offline, top tracks are loaded when connection returns")
+		return top, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return top, nil
+}
+
+// ConnectionChanged fetches top items of the current tab in background,
+// when they could not be fetched while Spotify was not reachable. They are
+// displayed with update.
+func (top *Top) ConnectionChanged(online bool, update func(func())) {
+	if !online || !top.offline {
+		return
+	}
+	top.offline = false
+	kind, timerange := top.kind, top.timerange
+	go func() {
+		items, err := top.fetch(kind, topTimeranges[timerange])
+		update(func() {
+			if err != nil {
+				top.offline = isConnectionError(err)
+				top.reportError("Could not show top %s: %s", top.tabKey(kind, timerange), err)
+				return
+			}
+			top.items[top.tabKey(kind, timerange)] = items
+			top.show(kind, timerange)
+		})
+	}()
+}
+
+// Title returns name of the view.
+func (top *Top) Title() string {
+	return "Top"
+}
+
+// Widget returns widget in which view is displayed.
+func (top *Top) Widget() tui.Widget {
+	return top.box
+}
+
+// Focusables returns widgets of the view which can be focused.
+func (top *Top) Focusables() []tui.Widget {
+	return []tui.Widget{top.table}
+}
+
+func (top *Top) tabKey(kind topKind, timerange int) string {
+	return kind.String() + "/" + topTimeranges[timerange]
+}
+
+func (top *Top) show(kind topKind, timerange int) error {
+	key := top.tabKey(kind, timerange)
+	if _, fetched := top.items[key]; !fetched {
+		items, err := top.fetch(kind, topTimeranges[timerange])
+		if err != nil {
+			top.reportError("Could not show top %s: %s", key, err)
+			return err
+		}
+		top.items[key] = items
+	}
+	top.kind = kind
+	top.timerange = timerange
+
+	if kind == topArtists {
+		top.table.setHeader(tui.NewLabel("#"), tui.NewLabel("Artist"), tui.NewLabel("Genres"), tui.NewLabel(""))
+	} else {
+		top.table.setHeader(tui.NewLabel("#"), tui.NewLabel("Title"), tui.NewLabel("Artist"), tui.NewLabel("Album"))
+	}
+	top.table.reset()
+	top.tabs.SetText(top.tabsText())
+	top.box.SetTitle(fmt.Sprintf("Top %s", kind))
+	return nil
+}
+
+func (top *Top) rowCount() int {
+	return len(top.currentItems())
+}
+
+func (top *Top) row(idx int) []tui.Widget {
+	item := top.currentItems()[idx]
+	columns := []string{item.artist, item.details}
+	if top.kind == topArtists {
+		columns = []string{item.details, ""}
+	}
+	return []tui.Widget{
+		tui.NewLabel(fmt.Sprintf("%d", idx+1)),
+		tui.NewLabel(trimWithCommasIfTooLong(item.name, uiColumnWidth)),
+		tui.NewLabel(trimWithCommasIfTooLong(columns[0], uiColumnWidth)),
+		tui.NewLabel(trimWithCommasIfTooLong(columns[1], uiColumnWidth)),
+	}
+}
+
+func (top *Top) fetch(kind topKind, timerange string) ([]topItem, error) {
+	opt := &spotify.Options{Limit: &topItemsLimit, Timerange: &timerange}
+	items := []topItem{}
+	if kind == topArtists {
+		page, err := top.client.CurrentUsersTopArtistsOpt(opt)
+		if err != nil {
+			return nil, wrapError(err, "could not fetch top artists: %v", err)
+		}
+		for _, artist := range page.Artists {
+			items = append(items, topItem{name: artist.Name, details: strings.Join(artist.Genres, ", "), uri: artist.URI})
+		}
+		return items, nil
+	}
+	page, err := top.client.CurrentUsersTopTracksOpt(opt)
+	if err != nil {
+		return nil, wrapError(err, "could not fetch top tracks: %v", err)
+	}
+	for _, track := range page.Tracks {
+		items = append(items, topItem{name: track.Name, artist: artistName(track.Artists), details: track.Album.Name, uri: track.URI})
+	}
+	return items, nil
+}
+
+// tabsText renders tabs with the current one in brackets, together with
+// keys which switch them, i.e. "[Tracks] Artists (a)  [Short] Medium Long (t)".
+func (top *Top) tabsText() string {
+	kinds := []string{}
+	for _, kind := range []topKind{topTracks, topArtists} {
+		name := strings.Title(kind.String())
+		if kind == top.kind {
+			name = "[" + name + "]"
+		}
+		kinds = append(kinds, name)
+	}
+	timeranges := []string{}
+	for i, timerange := range topTimeranges {
+		name := strings.Title(timerange) + " term"
+		if i == top.timerange {
+			name = "[" + name + "]"
+		}
+		timeranges = append(timeranges, name)
+	}
+	return fmt.Sprintf("%s (a)  %s (t)  Export (e)  Radio (r)", strings.Join(kinds, " "), strings.Join(timeranges, " "))
+}
+
+func (top *Top) currentItems() []topItem {
+	return top.items[top.tabKey(top.kind, top.timerange)]
+}
+
+func (top *Top) onItemActivated() func(*tui.Table) {
+	return func(*tui.Table) {
+		items := top.currentItems()
+		idx := top.table.selectedRow()
+		if idx < 0 || idx >= len(items) {
+			return
+		}
+		var opt *spotify.PlayOptions
+		if top.kind == topArtists {
+			uri := items[idx].uri
+			opt = &spotify.PlayOptions{PlaybackContext: &uri}
+		} else {
+			uris := make([]spotify.URI, 0, len(items)-idx)
+			for _, item := range items[idx:] {
+				uris = append(uris, item.uri)
+			}
+			opt = &spotify.PlayOptions{URIs: uris}
+		}
+		if err := top.client.PlayOpt(opt); err != nil {
+			top.reportError("Could not play top %s %s: %s", top.kind, items[idx].uri, err)
+		}
+	}
+}
+
+// export writes displayed list to CSV file in export directory, which is
+// created when it does not exist, and returns path of the file.
+func (top *Top) export() (string, error) {
+	if err := os.MkdirAll(top.exportDir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(top.exportDir, fmt.Sprintf("top-%s-%s-term.csv", top.kind, topTimeranges[top.timerange]))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := writeTopCSV(f, top.kind, top.currentItems()); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func writeTopCSV(w io.Writer, kind topKind, items []topItem) error {
+	writer := csv.NewWriter(w)
+	header := []string{"rank", "title", "artist", "album", "uri"}
+	if kind == topArtists {
+		header = []string{"rank", "artist", "genres", "uri"}
+	}
+	writer.Write(header)
+	for i, item := range items {
+		record := []string{fmt.Sprintf("%d", i+1), item.name, item.artist, item.details, string(item.uri)}
+		if kind == topArtists {
+			record = []string{fmt.Sprintf("%d", i+1), item.name, item.details, string(item.uri)}
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,124 @@
+package player
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zmb3/spotify"
+)
+
+func TestTopSwitchesTabs(t *testing.T) {
+	top, err := NewTop(NewDebugClient(), "")
+	if err != nil {
+		t.Fatalf("Did not expect to fail, but it did with %s", err)
+	}
+
+	cases := []struct {
+		kind            topKind
+		timerange       int
+		expectedName    string
+		expectedDetails string
+	}{
+		{kind: topTracks, timerange: 0, expectedName: "Top Short Term Song 1", expectedDetails: "Album Name 1"},
+		{kind: topTracks, timerange: 2, expectedName: "Top Long Term Song 1", expectedDetails: "Album Name 1"},
+		{kind: topArtists, timerange: 1, expectedName: "Top Medium Term Artist 1", expectedDetails: "rock, jazz"},
+	}
+	for _, c := range cases {
+		if err := top.show(c.kind, c.timerange); err != nil {
+			t.Fatalf("Did not expect to fail, but it did with %s", err)
+		}
+		items := top.currentItems()
+		if len(items) != debugTopItemsCount {
+			t.Errorf("Expected to have %d items, have %d", debugTopItemsCount, len(items))
+		}
+		if items[0].name != c.expectedName {
+			t.Errorf("Expected first item to be %s, got %s", c.expectedName, items[0].name)
+		}
+		if items[0].details != c.expectedDetails {
+			t.Errorf("Expected details of %s to be %q, got %q", items[0].name, c.expectedDetails, items[0].details)
+		}
+	}
+}
+
+func TestTopTabsText(t *testing.T) {
+	top, _ := NewTop(NewDebugClient(), "")
+	top.show(topArtists, 1)
+	expected := "Tracks [Artists] (a)  Short term [Medium term] Long term (t)  Export (e)  Radio (r)"
+	if got := top.tabsText(); got != expected {
+		t.Fatalf("Expected tabs to be %q, got %q", expected, got)
+	}
+}
+
+func TestTopOnItemActivated(t *testing.T) {
+	fakePlayer := &FakeTracksPlayer{}
+	client := &DebugClient{Player: fakePlayer, TopItemsFetcher: DebugTopItemsFetcher{}}
+	top, _ := NewTop(client, "")
+	top.table.selectRow(debugTopItemsCount - 2)
+	top.onItemActivated()(top.table.Table)
+	expected := []spotify.URI{"spotify:track:topshorttrack29", "spotify:track:topshorttrack30"}
+	if !reflect.DeepEqual(fakePlayer.givenOptions.URIs, expected) {
+		t.Fatalf("Expected to play %v, got %v", expected, fakePlayer.givenOptions.URIs)
+	}
+
+	top.show(topArtists, 0)
+	top.table.selectRow(0)
+	top.onItemActivated()(top.table.Table)
+	if ctx := fakePlayer.givenOptions.PlaybackContext; ctx == nil || *ctx != "spotify:artist:topshortartist1" {
+		t.Fatalf("Expected to play artist, got %#v", fakePlayer.givenOptions)
+	}
+}
+
+func TestWriteTopCSV(t *testing.T) {
+	cases := []struct {
+		kind     topKind
+		item     topItem
+		expected string
+	}{
+		{
+			kind:     topTracks,
+			item:     topItem{name: "Song, with comma", artist: "Artist", details: "Album", uri: "spotify:track:1"},
+			expected: "rank,title,artist,album,uri\n1,\"Song, with comma\",Artist,Album,spotify:track:1\n",
+		},
+		{
+			kind:     topArtists,
+			item:     topItem{name: "Artist", details: "jazz, bebop", uri: "spotify:artist:1"},
+			expected: "rank,artist,genres,uri\n1,Artist,\"jazz, bebop\",spotify:artist:1\n",
+		},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		if err := writeTopCSV(&buf, c.kind, []topItem{c.item}); err != nil {
+			t.Fatalf("Did not expect to fail, but it did with %s", err)
+		}
+		if buf.String() != c.expected {
+			t.Errorf("Expected CSV to be %q, got %q", c.expected, buf.String())
+		}
+	}
+}
+
+func TestTopExport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "top")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	exportDir := filepath.Join(dir, "exports") // it does not exist yet
+	top, _ := NewTop(NewDebugClient(), exportDir)
+	path, err := top.export()
+	if err != nil {
+		t.Fatalf("Did not expect to fail, but it did with %s", err)
+	}
+	if path != filepath.Join(exportDir, "top-tracks-short-term.csv") {
+		t.Fatalf("Unexpected export path %s", path)
+	}
+	content, _ := ioutil.ReadFile(path)
+	if lines := strings.Count(string(content), "\n"); lines != debugTopItemsCount+1 {
+		t.Fatalf("Expected header and %d rows, got %d lines", debugTopItemsCount, lines)
+	}
+}
@@ -0,0 +1,229 @@
+package player
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// maxRetries is how many times failed request is sent again.
+	maxRetries = 3
+	// retryBackoff is how long the first retry waits, every next one waits
+	// twice as long, up to maxRetryBackoff.
+	retryBackoff    = 500 * time.Millisecond
+	maxRetryBackoff = 10 * time.Second
+	// defaultRetryAfter is how long throttled requests wait when Spotify
+	// does not tell how long.
+	defaultRetryAfter = 5 * time.Second
+	// maxConcurrentRequests is the maximum number of requests which are sent
+	// to Spotify at the same time.
+	maxConcurrentRequests = 8
+)
+
+// RetryTransport is an http.RoundTripper which sends requests to Spotify Web
+// API with base transport. Throttled requests are sent again after the time
+// which Spotify asks for in Retry-After header, no request is sent before
+// then. Idempotent requests which failed are sent again after exponential
+// backoff with jitter. At most maxConcurrentRequests are sent at once.
+type RetryTransport struct {
+	base  http.RoundTripper
+	slots chan struct{}
+	// sleep waits given time, unless request is cancelled.
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu sync.Mutex
+	// throttledUntil is when Spotify accepts requests again.
+	throttledUntil time.Time
+
+	requests  int64
+	retries   int64
+	throttled int64
+	failures  int64
+}
+
+// TransportStats are counters of requests sent with RetryTransport.
+type TransportStats struct {
+	// Requests is the number of requests, not counting retries.
+	Requests int64
+	Retries  int64
+	// Throttled is the number of responses telling to slow down.
+	Throttled int64
+	// Failures is the number of requests which failed after retries.
+	Failures int64
+}
+
+// NewRetryTransport creates transport which sends requests with base, or
+// with http.DefaultTransport when base is nil.
+func NewRetryTransport(base http.RoundTripper) *RetryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RetryTransport{
+		base:  base,
+		slots: make(chan struct{}, maxConcurrentRequests),
+		sleep: sleepContext,
+	}
+}
+
+// Stats returns counters of requests sent so far.
+func (t *RetryTransport) Stats() TransportStats {
+	return TransportStats{
+		Requests:  atomic.LoadInt64(&t.requests),
+		Retries:   atomic.LoadInt64(&t.retries),
+		Throttled: atomic.LoadInt64(&t.throttled),
+		Failures:  atomic.LoadInt64(&t.failures),
+	}
+}
+
+// RoundTrip sends request, and sends it again when it should be retried.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	select {
+	case t.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-t.slots }()
+	atomic.AddInt64(&t.requests, 1)
+
+	for attempt := 0; ; attempt++ {
+		if err := t.sleep(ctx, t.throttledFor()); err != nil {
+			return nil, err
+		}
+		attemptReq, err := rewound(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := t.base.RoundTrip(attemptReq)
+		if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+			atomic.AddInt64(&t.throttled, 1)
+		}
+		wait, retry := t.retryAfter(req, resp, err, attempt)
+		if !retry {
+			if err != nil || resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+				atomic.AddInt64(&t.failures, 1)
+			}
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		atomic.AddInt64(&t.retries, 1)
+		if err := t.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryAfter tells whether request should be sent again, and how long to
+// wait before. Throttled requests are always sent again, as Spotify did not
+// handle them, failed ones only when sending them again changes nothing.
+func (t *RetryTransport) retryAfter(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= maxRetries || req.Context().Err() != nil {
+		return 0, false
+	}
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		wait := retryAfterHeader(resp, defaultRetryAfter)
+		t.throttle(wait)
+		return wait, true
+	}
+	if !isIdempotent(req) || (err == nil && !isRetriedStatus(resp.StatusCode)) {
+		return 0, false
+	}
+	if err == nil {
+		if wait := retryAfterHeader(resp, 0); wait > 0 {
+			return wait, true
+		}
+	}
+	return backoff(attempt), true
+}
+
+// throttle makes requests wait, as Spotify throttles all of them.
+func (t *RetryTransport) throttle(wait time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until := time.Now().Add(wait); until.After(t.throttledUntil) {
+		t.throttledUntil = until
+	}
+}
+
+func (t *RetryTransport) throttledFor() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return time.Until(t.throttledUntil)
+}
+
+// backoff returns how long retry waits, it is random between half and all
+// of exponential backoff, so that requests which failed together are not
+// sent again at the same time.
+func backoff(attempt int) time.Duration {
+	wait := retryBackoff
+	for i := 0; i < attempt && wait < maxRetryBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxRetryBackoff {
+		wait = maxRetryBackoff
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+func retryAfterHeader(resp *http.Response, defaultWait time.Duration) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return defaultWait
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+func isRetriedStatus(status int) bool {
+	switch status {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// rewound returns request which is sent again with its body from the
+// beginning. Request is not modified, as RoundTripper must not do it.
+func rewound(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retried := req.WithContext(req.Context())
+	retried.Body = body
+	return retried, nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
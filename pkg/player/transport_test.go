@@ -0,0 +1,211 @@
+package player
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestTransport creates transport which does not wait, it records how long
+// it would wait instead.
+func newTestTransport() (*RetryTransport, *[]time.Duration) {
+	transport := NewRetryTransport(nil)
+	var waits []time.Duration
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		if d > 0 {
+			waits = append(waits, d)
+		}
+		return ctx.Err()
+	}
+	return transport, &waits
+}
+
+func TestRetryTransportHonorsRetryAfter(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"name":"album"}`))
+	}))
+	defer server.Close()
+	transport, waits := newTestTransport()
+	client := &Client{http: &http.Client{Transport: redirectTransport{base: transport, baseURL: server.URL + "/"}}}
+
+	var result struct{ Name string }
+	if err := client.do("POST", "albums", nil, struct{}{}, &result); err != nil || result.Name != "album" {
+		t.Fatalf("Expected throttled request to be sent again, got %v and %+v", err, result)
+	}
+	if len(*waits) == 0 || (*waits)[0] != 2*time.Second {
+		t.Errorf("Expected request to wait for 2s, waited %v", *waits)
+	}
+	if d := transport.throttledFor(); d <= 0 || d > 2*time.Second {
+		t.Errorf("Expected next requests to wait until Spotify accepts them, got %v", d)
+	}
+	expected := TransportStats{Requests: 1, Retries: 1, Throttled: 1}
+	if stats := transport.Stats(); stats != expected {
+		t.Errorf("Expected stats %+v, got %+v", expected, stats)
+	}
+}
+
+func TestRetryTransportRetriesFailedRequests(t *testing.T) {
+	cases := []struct {
+		name           string
+		method         string
+		statuses       []int
+		expectedStatus int
+		expectedCalls  int
+		expectedStats  TransportStats
+	}{
+		{name: "succeeded", method: "GET", statuses: []int{200}, expectedStatus: 200, expectedCalls: 1,
+			expectedStats: TransportStats{Requests: 1}},
+		{name: "failed once", method: "GET", statuses: []int{503, 502, 200}, expectedStatus: 200, expectedCalls: 3,
+			expectedStats: TransportStats{Requests: 1, Retries: 2}},
+		{name: "idempotent with body", method: "PUT", statuses: []int{500, 204}, expectedStatus: 204, expectedCalls: 2,
+			expectedStats: TransportStats{Requests: 1, Retries: 1}},
+		{name: "not idempotent", method: "POST", statuses: []int{503, 200}, expectedStatus: 503, expectedCalls: 1,
+			expectedStats: TransportStats{Requests: 1, Failures: 1}},
+		{name: "client error", method: "GET", statuses: []int{404, 200}, expectedStatus: 404, expectedCalls: 1,
+			expectedStats: TransportStats{Requests: 1}},
+		{name: "always failing", method: "DELETE", statuses: []int{503, 503, 503, 503, 503}, expectedStatus: 503, expectedCalls: 4,
+			expectedStats: TransportStats{Requests: 1, Retries: 3, Failures: 1}},
+	}
+	for _, c := range cases {
+		calls := 0
+		var bodies []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			w.WriteHeader(c.statuses[calls])
+			calls++
+		}))
+		transport, waits := newTestTransport()
+		req, _ := http.NewRequest(c.method, server.URL, strings.NewReader("body"))
+		resp, err := transport.RoundTrip(req)
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: did not expect error, got %s", c.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != c.expectedStatus || calls != c.expectedCalls {
+			t.Errorf("%s: expected status %d after %d calls, got %d after %d calls", c.name, c.expectedStatus, c.expectedCalls, resp.StatusCode, calls)
+		}
+		for _, body := range bodies {
+			if body != "body" {
+				t.Errorf("%s: expected body to be sent with every call, got %q", c.name, bodies)
+			}
+		}
+		if len(*waits) != calls-1 {
+			t.Errorf("%s: expected retries to wait, waited %v", c.name, *waits)
+		}
+		if stats := transport.Stats(); stats != c.expectedStats {
+			t.Errorf("%s: expected stats %+v, got %+v", c.name, c.expectedStats, stats)
+		}
+	}
+}
+
+func TestRetryTransportRetriesUnreachableServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+	transport, waits := newTestTransport()
+	client := &Client{http: &http.Client{Transport: redirectTransport{base: transport, baseURL: server.URL + "/"}}}
+
+	if err := client.do("GET", "me/albums", nil, nil, nil); !isConnectionError(err) {
+		t.Fatalf("Expected connection error, got %v", err)
+	}
+	expected := TransportStats{Requests: 1, Retries: int64(maxRetries), Failures: 1}
+	if stats := transport.Stats(); stats != expected || len(*waits) != maxRetries {
+		t.Errorf("Expected stats %+v after %d waits, got %+v after %v", expected, maxRetries, stats, *waits)
+	}
+}
+
+func TestRetryTransportStopsWhenCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	transport := NewRetryTransport(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	start := time.Now()
+	if _, err := transport.RoundTrip(req.WithContext(ctx)); err != context.Canceled {
+		t.Errorf("Expected request to be cancelled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > retryBackoff {
+		t.Errorf("Expected cancelled request not to wait for retry, waited %v", elapsed)
+	}
+}
+
+func TestRetryTransportCapsConcurrency(t *testing.T) {
+	defer func(max int) { maxConcurrentRequests = max }(maxConcurrentRequests)
+	maxConcurrentRequests = 2
+
+	var mu sync.Mutex
+	sent, maxSent := 0, 0
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sent++
+		if sent > maxSent {
+			maxSent = sent
+		}
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		sent--
+		mu.Unlock()
+	}))
+	defer server.Close()
+	transport := NewRetryTransport(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", server.URL, nil)
+			if resp, err := transport.RoundTrip(req); err == nil {
+				resp.Body.Close()
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if maxSent != 2 {
+		t.Errorf("Expected 2 requests to be sent at once, got %d", maxSent)
+	}
+	if stats := transport.Stats(); stats.Requests != 5 {
+		t.Errorf("Expected 5 requests to be sent, got %+v", stats)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{attempt: 0, max: retryBackoff},
+		{attempt: 1, max: 2 * retryBackoff},
+		{attempt: 2, max: 4 * retryBackoff},
+		{attempt: 10, max: maxRetryBackoff},
+		{attempt: 100, max: maxRetryBackoff},
+	}
+	for _, c := range cases {
+		for i := 0; i < 20; i++ {
+			if wait := backoff(c.attempt); wait < c.max/2 || wait > c.max {
+				t.Errorf("Expected backoff of attempt %d to be between %v and %v, got %v", c.attempt, c.max/2, c.max, wait)
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package player
+
+import (
+	"fmt"
+	"strings"
+
+	tui "github.com/marcusolsson/tui-go"
+)
+
+// View is a panel which can be displayed inside of Views.
+type View interface {
+	Title() string
+	Widget() tui.Widget
+	Focusables() []tui.Widget
+}
+
+// Views represents box which displays one of many views at once,
+// with list of all views and keys switching between them above.
+type Views struct {
+	Box      *tui.Box
+	header   *tui.Label
+	content  *tui.Box
+	views    []View
+	current  int
+	onChange func()
+}
+
+// NewViews creates Views, first of given views is displayed.
+func NewViews(views ...View) *Views {
+	header := tui.NewLabel("")
+	content := tui.NewVBox()
+	content.SetSizePolicy(tui.Expanding, tui.Expanding)
+
+	box := tui.NewVBox(header, content)
+	box.SetBorder(true)
+	box.SetSizePolicy(tui.Expanding, tui.Expanding)
+
+	v := &Views{
+		Box:     box,
+		header:  header,
+		content: content,
+		views:   views,
+	}
+	v.Show(0)
+	return v
+}
+
+// Show displays view with given index.
+func (v *Views) Show(idx int) {
+	if idx < 0 || idx >= len(v.views) {
+		return
+	}
+	v.current = idx
+	for v.content.Length() > 0 {
+		v.content.Remove(0)
+	}
+	v.content.Append(v.views[idx].Widget())
+	v.Box.SetTitle(v.views[idx].Title())
+	v.header.SetText(v.headerText())
+	if v.onChange != nil {
+		v.onChange()
+	}
+}
+
+// ShowView displays given view, when it is one of the views.
+func (v *Views) ShowView(view View) {
+	for i := range v.views {
+		if v.views[i] == view {
+			v.Show(i)
+			return
+		}
+	}
+}
+
+// OnChange sets the function which is called after displayed view changes.
+func (v *Views) OnChange(fn func()) {
+	v.onChange = fn
+}
+
+// Focusables returns focusable widgets of the displayed view.
+func (v *Views) Focusables() []tui.Widget {
+	if len(v.views) == 0 {
+		return nil
+	}
+	return v.views[v.current].Focusables()
+}
+
+// SetKeybindings binds F1, F2, ... keys to displaying consecutive views.
+func (v *Views) SetKeybindings(ui tui.UI) {
+	for i := range v.views {
+		idx := i
+		ui.SetKeybinding(viewKey(idx), func() { v.Show(idx) })
+	}
+}
+
+func (v *Views) headerText() string {
+	names := make([]string, 0, len(v.views))
+	for i, view := range v.views {
+		name := fmt.Sprintf("%s %s", viewKey(i), view.Title())
+		if i == v.current {
+			name = "[" + name + "]"
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, "  ")
+}
+
+func viewKey(idx int) string {
+	return fmt.Sprintf("F%d", idx+1)
+}
+
+// FocusChain is tui.FocusChain which asks for focusable widgets every time
+// focus moves, so it follows views being switched. When focused widget is not
+// part of the chain anymore, focus goes back to the first widget.
+type FocusChain struct {
+	widgets func() []tui.Widget
+}
+
+// NewFocusChain creates FocusChain which takes widgets from given function.
+func NewFocusChain(widgets func() []tui.Widget) *FocusChain {
+	return &FocusChain{widgets: widgets}
+}
+
+// FocusNext returns widget which is after the given one.
+func (c *FocusChain) FocusNext(current tui.Widget) tui.Widget {
+	return c.focusWithOffset(current, 1)
+}
+
+// FocusPrev returns widget which is before the given one.
+func (c *FocusChain) FocusPrev(current tui.Widget) tui.Widget {
+	return c.focusWithOffset(current, -1)
+}
+
+// FocusDefault returns first widget of the chain.
+func (c *FocusChain) FocusDefault() tui.Widget {
+	widgets := c.widgets()
+	if len(widgets) == 0 {
+		return nil
+	}
+	return widgets[0]
+}
+
+func (c *FocusChain) focusWithOffset(current tui.Widget, offset int) tui.Widget {
+	widgets := c.widgets()
+	for i, w := range widgets {
+		if w == current {
+			return widgets[(i+offset+len(widgets))%len(widgets)]
+		}
+	}
+	if len(widgets) == 0 {
+		return current
+	}
+	return widgets[0]
+}
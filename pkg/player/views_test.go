@@ -0,0 +1,76 @@
+package player
+
+import (
+	"testing"
+
+	"github.com/marcusolsson/tui-go"
+)
+
+type fakeView struct {
+	title      string
+	widget     *tui.Box
+	focusables []tui.Widget
+}
+
+func newFakeView(title string, focusablesCount int) *fakeView {
+	view := &fakeView{title: title, widget: tui.NewVBox()}
+	for i := 0; i < focusablesCount; i++ {
+		view.focusables = append(view.focusables, tui.NewTable(0, 0))
+	}
+	return view
+}
+
+func (fake *fakeView) Title() string            { return fake.title }
+func (fake *fakeView) Widget() tui.Widget       { return fake.widget }
+func (fake *fakeView) Focusables() []tui.Widget { return fake.focusables }
+
+func TestViewsShow(t *testing.T) {
+	first, second := newFakeView("First", 1), newFakeView("Second", 2)
+	views := NewViews(first, second)
+	changes := 0
+	views.OnChange(func() { changes++ })
+
+	if len(views.Focusables()) != 1 {
+		t.Fatalf("Expected first view to be displayed")
+	}
+	if views.header.Text() != "[F1 First]  F2 Second" {
+		t.Fatalf("Unexpected header: %s", views.header.Text())
+	}
+
+	views.Show(1)
+	if len(views.Focusables()) != 2 || views.content.Length() != 1 {
+		t.Fatalf("Expected only second view to be displayed")
+	}
+	if views.header.Text() != "F1 First  [F2 Second]" {
+		t.Fatalf("Unexpected header: %s", views.header.Text())
+	}
+
+	views.Show(2) // there is no such view
+	if len(views.Focusables()) != 2 {
+		t.Fatalf("Expected second view to be still displayed")
+	}
+	if changes != 1 {
+		t.Fatalf("Expected OnChange callback to be called once, it was called %d times", changes)
+	}
+}
+
+func TestFocusChainFollowsWidgets(t *testing.T) {
+	a, b, c := tui.NewButton("a"), tui.NewButton("b"), tui.NewButton("c")
+	widgets := []tui.Widget{a, b}
+	chain := NewFocusChain(func() []tui.Widget { return widgets })
+
+	if chain.FocusDefault() != a {
+		t.Fatalf("Expected first widget to be focused by default")
+	}
+	if chain.FocusNext(a) != b || chain.FocusNext(b) != a {
+		t.Fatalf("Expected next focus to loop over widgets")
+	}
+	if chain.FocusPrev(a) != b {
+		t.Fatalf("Expected previous focus to loop over widgets")
+	}
+
+	widgets = []tui.Widget{c}
+	if chain.FocusNext(a) != c || chain.FocusPrev(b) != c {
+		t.Fatalf("Expected focus to go back to first widget when focused one is gone")
+	}
+}
@@ -0,0 +1,239 @@
+package player
+
+import (
+	"image"
+
+	tui "github.com/marcusolsson/tui-go"
+)
+
+// rowSource provides rows displayed by a virtualTable. Rows are built only
+// when they are visible, so source may have any number of them.
+type rowSource interface {
+	rowCount() int
+	row(idx int) []tui.Widget
+}
+
+// viewRows is the maximum number of rows displayed in tables of views,
+// remaining rows are scrolled to.
+var viewRows = 20
+
+// rowFuncs is a rowSource which builds rows with functions, i.e. for views
+// which display several tables.
+type rowFuncs struct {
+	count func() int
+	build func(idx int) []tui.Widget
+}
+
+func (r rowFuncs) rowCount() int {
+	return r.count()
+}
+
+func (r rowFuncs) row(idx int) []tui.Widget {
+	return r.build(idx)
+}
+
+// rowLoader is a rowSource whose rows are fetched when they are about to be
+// displayed, i.e. page by page from Spotify.
+type rowLoader interface {
+	// loadRows starts loading rows up to end, exclusive, without waiting
+	// for them. Rows which are not loaded yet are still built by row, i.e. as
+	// placeholders, and table is refreshed when they are loaded.
+	loadRows(end int)
+}
+
+// virtualTable is an actionTable which displays only visible window of rows
+// of its source. Selected row is an index of the row in the source, no matter
+// which rows are displayed, window is scrolled so that it is always visible.
+// Selection is moved with Up/Down (or k/j), PgUp/PgDn, Home and End keys.
+type virtualTable struct {
+	*actionTable
+	source rowSource
+	// header is displayed above rows, it can not be selected.
+	header []tui.Widget
+
+	// height is the maximum number of displayed rows, visible is the number
+	// of rows which fit in the space given to the table.
+	height  int
+	visible int
+	// offset is index of the first displayed row.
+	offset   int
+	selected int
+	// rowSelected is called with index of selected row, when selection
+	// changes.
+	rowSelected func(idx int)
+}
+
+func newVirtualTable(source rowSource, height int) *virtualTable {
+	table := &virtualTable{
+		actionTable: newActionTable(),
+		source:      source,
+		height:      height,
+		visible:     height,
+		selected:    -1,
+	}
+	table.onKey("Up", table.onMove(-1))
+	table.onKey("k", table.onMove(-1))
+	table.onKey("Down", table.onMove(1))
+	table.onKey("j", table.onMove(1))
+	table.onKey("PgUp", table.onPageMove(-1))
+	table.onKey("PgDn", table.onPageMove(1))
+	table.onKey("Home", func(*tui.Table) { table.selectRow(0) })
+	table.onKey("End", func(*tui.Table) { table.selectRow(table.source.rowCount() - 1) })
+	return table
+}
+
+// setHeader displays given cells above rows.
+func (t *virtualTable) setHeader(cells ...tui.Widget) {
+	t.header = cells
+	t.render()
+}
+
+// selectedRow returns index of selected row in the source, or -1 when
+// source has no rows.
+func (t *virtualTable) selectedRow() int {
+	return t.selected
+}
+
+// selectRow selects row with given index, or the closest existing one,
+// and scrolls window to it.
+func (t *virtualTable) selectRow(idx int) {
+	t.selected = t.clamp(idx)
+	t.scrollToSelected()
+	t.render()
+	if t.rowSelected != nil && t.selected >= 0 {
+		t.rowSelected(t.selected)
+	}
+}
+
+// onRowSelected sets function which is called with index of the row in the
+// source, every time row is selected.
+func (t *virtualTable) onRowSelected(fn func(idx int)) {
+	t.rowSelected = fn
+}
+
+// reset displays rows from the first one, i.e. after they were sorted.
+func (t *virtualTable) reset() {
+	t.offset = 0
+	t.selectRow(0)
+}
+
+// refresh displays rows again after they changed, keeping selected index.
+func (t *virtualTable) refresh() {
+	t.selectRow(t.selected)
+}
+
+func (t *virtualTable) onMove(rows int) func(*tui.Table) {
+	return func(*tui.Table) {
+		t.move(rows)
+	}
+}
+
+// onPageMove moves selection by number of rows which are visible.
+func (t *virtualTable) onPageMove(pages int) func(*tui.Table) {
+	return func(*tui.Table) {
+		t.move(pages * t.visible)
+	}
+}
+
+func (t *virtualTable) move(rows int) {
+	if t.selected < 0 {
+		return
+	}
+	t.selectRow(t.selected + rows)
+}
+
+func (t *virtualTable) clamp(idx int) int {
+	count := t.source.rowCount()
+	switch {
+	case count == 0:
+		return -1
+	case idx < 0:
+		return 0
+	case idx >= count:
+		return count - 1
+	}
+	return idx
+}
+
+// scrollToSelected moves window so that selected row is visible, and
+// window is filled with rows when there are enough of them.
+func (t *virtualTable) scrollToSelected() {
+	if t.selected < t.offset {
+		t.offset = t.selected
+	}
+	if t.selected >= t.offset+t.visible {
+		t.offset = t.selected - t.visible + 1
+	}
+	if last := t.source.rowCount() - t.visible; t.offset > last {
+		t.offset = last
+	}
+	if t.offset < 0 {
+		t.offset = 0
+	}
+}
+
+// render replaces displayed rows with rows from the window.
+func (t *virtualTable) render() {
+	t.Table.RemoveRows()
+	if len(t.header) > 0 {
+		t.Table.AppendRow(t.header...)
+	}
+	end := t.offset + t.visible
+	if count := t.source.rowCount(); end > count {
+		end = count
+	}
+	if loader, ok := t.source.(rowLoader); ok {
+		loader.loadRows(end)
+	}
+	for i := t.offset; i < end; i++ {
+		t.Table.AppendRow(t.source.row(i)...)
+	}
+	if t.selected >= 0 {
+		t.Table.SetSelected(t.selected - t.offset + t.headerRows())
+	}
+}
+
+func (t *virtualTable) headerRows() int {
+	if len(t.header) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// SizeHint asks for space for the whole window, even when fewer rows
+// are displayed because they did not fit before.
+func (t *virtualTable) SizeHint() image.Point {
+	hint := t.Table.SizeHint()
+	rows := t.source.rowCount()
+	if rows > t.height {
+		rows = t.height
+	}
+	hint.Y = rows + t.headerRows()
+	return hint
+}
+
+// MinSizeHint allows table to shrink to a single row.
+func (t *virtualTable) MinSizeHint() image.Point {
+	hint := t.Table.MinSizeHint()
+	if hint.Y > 0 {
+		hint.Y = 1 + t.headerRows()
+	}
+	return hint
+}
+
+// Resize displays as many rows as fit in the given space.
+func (t *virtualTable) Resize(size image.Point) {
+	visible := size.Y - t.headerRows()
+	if visible > t.height {
+		visible = t.height
+	}
+	if visible < 1 {
+		visible = 1
+	}
+	if visible != t.visible {
+		t.visible = visible
+		t.scrollToSelected()
+		t.render()
+	}
+	t.Table.Resize(size)
+}
@@ -0,0 +1,126 @@
+package player
+
+import (
+	"fmt"
+	"image"
+	"testing"
+
+	"github.com/marcusolsson/tui-go"
+)
+
+type numberRows struct {
+	count int
+	built []int
+}
+
+func (r *numberRows) rowCount() int { return r.count }
+
+func (r *numberRows) row(idx int) []tui.Widget {
+	r.built = append(r.built, idx)
+	return []tui.Widget{tui.NewLabel(fmt.Sprintf("row %d", idx))}
+}
+
+func TestVirtualTableRendersOnlyVisibleRows(t *testing.T) {
+	rows := &numberRows{count: 100000}
+	table := newVirtualTable(rows, 3)
+	table.setHeader(tui.NewLabel("Rows"))
+	table.SetFocused(true)
+
+	rows.built = nil
+	table.reset()
+	table.OnKeyEvent(tui.KeyEvent{Key: tui.KeyEnd})
+	if table.selectedRow() != 99999 || table.offset != 99997 {
+		t.Fatalf("Expected the last row to be selected at the bottom of window, got row %d from %d", table.selectedRow(), table.offset)
+	}
+	if len(rows.built) != 6 {
+		t.Errorf("Expected only rows of two windows to be built, got %d rows", len(rows.built))
+	}
+
+	surface := tui.NewTestSurface(10, 4)
+	painter := tui.NewPainter(surface, tui.NewTheme())
+	painter.Repaint(table)
+	expected := "\nRows      \nrow 99997 \nrow 99998 \nrow 99999 \n"
+	if surface.String() != expected {
+		t.Errorf("Expected last rows to be drawn under header, got %s", surface.String())
+	}
+}
+
+func TestVirtualTableKeepsSelectedRowWhenRowsChange(t *testing.T) {
+	rows := &numberRows{count: 10}
+	table := newVirtualTable(rows, 4)
+	table.selectRow(8)
+
+	rows.count = 5
+	table.refresh()
+	if table.selectedRow() != 4 || table.offset != 1 || table.Selected() != 3 {
+		t.Errorf("Expected the last of remaining rows to be selected, got row %d from %d", table.selectedRow(), table.offset)
+	}
+
+	rows.count = 0
+	table.refresh()
+	if table.selectedRow() != -1 || table.offset != 0 {
+		t.Errorf("Expected nothing to be selected without rows, got row %d from %d", table.selectedRow(), table.offset)
+	}
+	table.SetFocused(true)
+	table.OnKeyEvent(tui.KeyEvent{Key: tui.KeyDown})
+	if table.selectedRow() != -1 {
+		t.Errorf("Expected nothing to be selected without rows, got row %d", table.selectedRow())
+	}
+}
+
+func TestVirtualTableShrinksToGivenSpace(t *testing.T) {
+	rows := &numberRows{count: 50}
+	table := newVirtualTable(rows, 20)
+	table.setHeader(tui.NewLabel("Rows"))
+	table.selectRow(10)
+
+	if hint := table.SizeHint(); hint.Y != 21 {
+		t.Errorf("Expected table to ask for space for 20 rows and header, got %d", hint.Y)
+	}
+	table.Resize(image.Point{X: 10, Y: 6})
+	if table.visible != 5 || table.offset != 6 || table.Selected() != 5 {
+		t.Errorf("Expected 5 rows to fit with selected row at the bottom, got %d rows from %d", table.visible, table.offset)
+	}
+
+	table.SetFocused(true)
+	table.OnKeyEvent(tui.KeyEvent{Key: tui.KeyPgDn})
+	if table.selectedRow() != 15 {
+		t.Errorf("Expected page of visible rows to be scrolled, got row %d", table.selectedRow())
+	}
+
+	table.Resize(image.Point{X: 10, Y: 40})
+	if table.visible != 20 || table.offset != 11 || table.Selected() != 5 {
+		t.Errorf("Expected table to display 20 rows with enough space, got %d rows from %d", table.visible, table.offset)
+	}
+}
+
+type loadedRows struct {
+	numberRows
+	loaded int
+}
+
+func (r *loadedRows) loadRows(end int) {
+	if end > r.loaded {
+		r.loaded = end
+	}
+}
+
+func TestVirtualTableLoadsRowsBeforeDisplayingThem(t *testing.T) {
+	rows := &loadedRows{numberRows: numberRows{count: 100}}
+	table := newVirtualTable(rows, 10)
+	table.reset()
+	if rows.loaded != 10 {
+		t.Fatalf("Expected the first window of rows to be loaded, got %d", rows.loaded)
+	}
+
+	table.SetFocused(true)
+	table.OnKeyEvent(tui.KeyEvent{Key: tui.KeyPgDn})
+	table.OnKeyEvent(tui.KeyEvent{Key: tui.KeyPgDn})
+	if rows.loaded != 21 {
+		t.Errorf("Expected rows to be loaded up to the scrolled window, got %d", rows.loaded)
+	}
+	table.OnKeyEvent(tui.KeyEvent{Key: tui.KeyEnd})
+	if rows.loaded != 100 {
+		t.Errorf("Expected all rows to be loaded, got %d", rows.loaded)
+	}
+}
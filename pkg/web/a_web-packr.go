@@ -7,5 +7,5 @@ import "github.com/gobuffalo/packr"
 // You can use the "packr clean" command to clean up this,
 // and any other packr generated files.
 func init() {
-	packr.PackJSONBytes("../../assets", "index_tmpl.html", "\"PCFET0NUWVBFIGh0bWw+CjxodG1sPgo8aGVhZD4KICA8dGl0bGU+U3BvdGlmeSBXZWIgUGxheWJhY2sgU0RLIFF1aWNrIFN0YXJ0IFR1dG9yaWFsPC90aXRsZT4KPC9oZWFkPgo8Ym9keT4KICA8aDE+U3BvdGlmeSBQbGF5ZXI8L2gxPgogIDxoMj5HbyB0byB0aGUgdGVybWluYWwgYW5kIHBsZWFzZSBkbyBub3QgY2xvc2UgdGhpcyB0YWIuPC9oMj4KCiAgPHNjcmlwdCBzcmM9Imh0dHBzOi8vc2RrLnNjZG4uY28vc3BvdGlmeS1wbGF5ZXIuanMiPjwvc2NyaXB0PgogIDxzY3JpcHQgdHlwZT0idGV4dC9qYXZhc2NyaXB0IiBjaGFyc2V0PSJ1dGYtOCIgYXN5bmMgZGVmZXI+CiAgICBjb25uID0gbmV3IFdlYlNvY2tldCgid3M6Ly8iICsgZG9jdW1lbnQubG9jYXRpb24uaG9zdCArICIvd3MiKTsKICAgIGNvbm4ub25tZXNzYWdlID0gZnVuY3Rpb24gKGV2dCkgewogICAgICBjb25zb2xlLmxvZyhldnQuZGF0YSk7CiAgICAgIHZhciBwYXJzZWQgPSBKU09OLnBhcnNlKEpTT04ucGFyc2UoZXZ0LmRhdGEpKTsKICAgICAgaWYgKHBhcnNlZFsnY2xvc2UnXSkgewogICAgICAgIG9wZW4obG9jYXRpb24sICdfc2VsZicpLmNsb3NlKCk7CiAgICAgIH0KICAgIH0KICAgIHdpbmRvdy5vblNwb3RpZnlXZWJQbGF5YmFja1NES1JlYWR5ID0gKCkgPT4gewogICAgICBjb25zdCB0b2tlbiA9IHt7LlRva2VufX07CiAgICAgIGNvbnN0IHBsYXllciA9IG5ldyBTcG90aWZ5LlBsYXllcih7CiAgICAgICAgbmFtZTogJ0Jyb3dzZXIgcGxheWJhY2snLAogICAgICAgIGdldE9BdXRoVG9rZW46IGNiID0+IHsgY2IodG9rZW4pOyB9CiAgICAgIH0pOwoKICAgICAgLy8gRXJyb3IgaGFuZGxpbmcKICAgICAgcGxheWVyLmFkZExpc3RlbmVyKCdpbml0aWFsaXphdGlvbl9lcnJvcicsICh7IG1lc3NhZ2UgfSkgPT4geyBjb25zb2xlLmVycm9yKG1lc3NhZ2UpOyB9KTsKICAgICAgcGxheWVyLmFkZExpc3RlbmVyKCdhdXRoZW50aWNhdGlvbl9lcnJvcicsICh7IG1lc3NhZ2UgfSkgPT4geyBjb25zb2xlLmVycm9yKG1lc3NhZ2UpOyB9KTsKICAgICAgcGxheWVyLmFkZExpc3RlbmVyKCdhY2NvdW50X2Vycm9yJywgKHsgbWVzc2FnZSB9KSA9PiB7IGNvbnNvbGUuZXJyb3IobWVzc2FnZSk7IH0pOwogICAgICBwbGF5ZXIuYWRkTGlzdGVuZXIoJ3BsYXliYWNrX2Vycm9yJywgKHsgbWVzc2FnZSB9KSA9PiB7IGNvbnNvbGUuZXJyb3IobWVzc2FnZSk7IH0pOwoKICAgICAgLy8gUGxheWJhY2sgc3RhdHVzIHVwZGF0ZXMKICAgICAgcGxheWVyLmFkZExpc3RlbmVyKCdwbGF5ZXJfc3RhdGVfY2hhbmdlZCcsIHN0YXRlID0+IHsKICAgICAgICBzdGF0ZVVwZGF0ZSA9IHt9OwogICAgICAgIGNvbnN0IGN1cnJlbnRUcmFja05hbWUgPSBzdGF0ZS50cmFja193aW5kb3cuY3VycmVudF90cmFjay5uYW1lOwogICAgICAgIGlmIChjdXJyZW50VHJhY2tOYW1lICE9PSB1bmRlZmluZWQpIHsKICAgICAgICAgIHN0YXRlVXBkYXRlWydDdXJyZW50VHJhY2tOYW1lJ10gPSBjdXJyZW50VHJhY2tOYW1lOwogICAgICAgIH0KICAgICAgICBjb25zdCBjdXJyZW50QWxidW1OYW1lID0gc3RhdGUudHJhY2tfd2luZG93LmN1cnJlbnRfdHJhY2suYWxidW0ubmFtZTsKICAgICAgICBpZiAoY3VycmVudEFsYnVtTmFtZSAhPT0gdW5kZWZpbmVkKSB7CiAgICAgICAgICBzdGF0ZVVwZGF0ZVsnQ3VycmVudEFsYnVtTmFtZSddID0gY3VycmVudEFsYnVtTmFtZTsKICAgICAgICB9CiAgICAgICAgY29uc3QgY3VycmVudEFydGlzdE5hbWUgPSBzdGF0ZS50cmFja193aW5kb3cuY3VycmVudF90cmFjay5hcnRpc3RzWzBdLm5hbWU7CiAgICAgICAgaWYgKGN1cnJlbnRBcnRpc3ROYW1lICE9PSB1bmRlZmluZWQpIHsKICAgICAgICAgIHN0YXRlVXBkYXRlWydDdXJyZW50QXJ0aXN0TmFtZSddID0gY3VycmVudEFydGlzdE5hbWU7CiAgICAgICAgfQogICAgICAgIGNvbm4uc2VuZChKU09OLnN0cmluZ2lmeShzdGF0ZVVwZGF0ZSkpOwogICAgICAgIGNvbnNvbGUubG9nKHN0YXRlKTsKICAgICAgfSk7CgogICAgICAvLyBSZWFkeQogICAgICBwbGF5ZXIuYWRkTGlzdGVuZXIoJ3JlYWR5JywgKHsgZGV2aWNlX2lkIH0pID0+IHsKICAgICAgICBjb25zb2xlLmxvZygiRGV2aWNlIHJlYWR5OiAiLCBkZXZpY2VfaWQpCiAgICAgICAgY29ubi5zZW5kKEpTT04uc3RyaW5naWZ5KHsiRGV2aWNlSWQiOiBkZXZpY2VfaWR9KSkKICAgICAgfSk7CgogICAgICAvLyBDb25uZWN0IHRvIHRoZSBwbGF5ZXIhCiAgICAgIHBsYXllci5jb25uZWN0KCk7CiAgICB9OyAgICAKICA8L3NjcmlwdD4KPC9ib2R5Pgo8L2h0bWw+\"")
+	packr.PackJSONBytes("../../assets", "index_tmpl.html", "\"PCFET0NUWVBFIGh0bWw+CjxodG1sPgo8aGVhZD4KICA8dGl0bGU+U3BvdGlmeSBXZWIgUGxheWJhY2sgU0RLIFF1aWNrIFN0YXJ0IFR1dG9yaWFsPC90aXRsZT4KPC9oZWFkPgo8Ym9keT4KICA8aDE+U3BvdGlmeSBQbGF5ZXI8L2gxPgogIDxoMj5HbyB0byB0aGUgdGVybWluYWwgYW5kIHBsZWFzZSBkbyBub3QgY2xvc2UgdGhpcyB0YWIuPC9oMj4KCiAgPHNjcmlwdCBzcmM9Imh0dHBzOi8vc2RrLnNjZG4uY28vc3BvdGlmeS1wbGF5ZXIuanMiPjwvc2NyaXB0PgogIDxzY3JpcHQgdHlwZT0idGV4dC9qYXZhc2NyaXB0IiBjaGFyc2V0PSJ1dGYtOCIgYXN5bmMgZGVmZXI+CiAgICBjb25uID0gbmV3IFdlYlNvY2tldCgid3M6Ly8iICsgZG9jdW1lbnQubG9jYXRpb24uaG9zdCArICIvd3MiKTsKICAgIGNvbm4ub25tZXNzYWdlID0gZnVuY3Rpb24gKGV2dCkgewogICAgICBjb25zb2xlLmxvZyhldnQuZGF0YSk7CiAgICAgIHZhciBwYXJzZWQgPSBKU09OLnBhcnNlKEpTT04ucGFyc2UoZXZ0LmRhdGEpKTsKICAgICAgaWYgKHBhcnNlZFsnY2xvc2UnXSkgewogICAgICAgIG9wZW4obG9jYXRpb24sICdfc2VsZicpLmNsb3NlKCk7CiAgICAgIH0KICAgIH0KICAgIHdpbmRvdy5vblNwb3RpZnlXZWJQbGF5YmFja1NES1JlYWR5ID0gKCkgPT4gewogICAgICBjb25zdCB0b2tlbiA9IHt7LlRva2VufX07CiAgICAgIGNvbnN0IHBsYXllciA9IG5ldyBTcG90aWZ5LlBsYXllcih7CiAgICAgICAgbmFtZTogJ0Jyb3dzZXIgcGxheWJhY2snLAogICAgICAgIGdldE9BdXRoVG9rZW46IGNiID0+IHsgY2IodG9rZW4pOyB9CiAgICAgIH0pOwoKICAgICAgLy8gRXJyb3IgaGFuZGxpbmcKICAgICAgcGxheWVyLmFkZExpc3RlbmVyKCdpbml0aWFsaXphdGlvbl9lcnJvcicsICh7IG1lc3NhZ2UgfSkgPT4geyBjb25zb2xlLmVycm9yKG1lc3NhZ2UpOyB9KTsKICAgICAgcGxheWVyLmFkZExpc3RlbmVyKCdhdXRoZW50aWNhdGlvbl9lcnJvcicsICh7IG1lc3NhZ2UgfSkgPT4geyBjb25zb2xlLmVycm9yKG1lc3NhZ2UpOyB9KTsKICAgICAgcGxheWVyLmFkZExpc3RlbmVyKCdhY2NvdW50X2Vycm9yJywgKHsgbWVzc2FnZSB9KSA9PiB7IGNvbnNvbGUuZXJyb3IobWVzc2FnZSk7IH0pOwogICAgICBwbGF5ZXIuYWRkTGlzdGVuZXIoJ3BsYXliYWNrX2Vycm9yJywgKHsgbWVzc2FnZSB9KSA9PiB7IGNvbnNvbGUuZXJyb3IobWVzc2FnZSk7IH0pOwoKICAgICAgLy8gUGxheWJhY2sgc3RhdHVzIHVwZGF0ZXMKICAgICAgcGxheWVyLmFkZExpc3RlbmVyKCdwbGF5ZXJfc3RhdGVfY2hhbmdlZCcsIHN0YXRlID0+IHsKICAgICAgICBzdGF0ZVVwZGF0ZSA9IHt9OwogICAgICAgIGNvbnN0IGN1cnJlbnRUcmFja05hbWUgPSBzdGF0ZS50cmFja193aW5kb3cuY3VycmVudF90cmFjay5uYW1lOwogICAgICAgIGlmIChjdXJyZW50VHJhY2tOYW1lICE9PSB1bmRlZmluZWQpIHsKICAgICAgICAgIHN0YXRlVXBkYXRlWydDdXJyZW50VHJhY2tOYW1lJ10gPSBjdXJyZW50VHJhY2tOYW1lOwogICAgICAgIH0KICAgICAgICBjb25zdCBjdXJyZW50QWxidW1OYW1lID0gc3RhdGUudHJhY2tfd2luZG93LmN1cnJlbnRfdHJhY2suYWxidW0ubmFtZTsKICAgICAgICBpZiAoY3VycmVudEFsYnVtTmFtZSAhPT0gdW5kZWZpbmVkKSB7CiAgICAgICAgICBzdGF0ZVVwZGF0ZVsnQ3VycmVudEFsYnVtTmFtZSddID0gY3VycmVudEFsYnVtTmFtZTsKICAgICAgICB9CiAgICAgICAgY29uc3QgY3VycmVudEFydGlzdHMgPSBzdGF0ZS50cmFja193aW5kb3cuY3VycmVudF90cmFjay5hcnRpc3RzOwogICAgICAgIGlmIChjdXJyZW50QXJ0aXN0cyAmJiBjdXJyZW50QXJ0aXN0cy5sZW5ndGggPiAwKSB7CiAgICAgICAgICBzdGF0ZVVwZGF0ZVsnQ3VycmVudEFydGlzdE5hbWUnXSA9IGN1cnJlbnRBcnRpc3RzWzBdLm5hbWU7CiAgICAgICAgfQogICAgICAgIGNvbnN0IGN1cnJlbnRUcmFja1VSSSA9IHN0YXRlLnRyYWNrX3dpbmRvdy5jdXJyZW50X3RyYWNrLnVyaTsKICAgICAgICBpZiAoY3VycmVudFRyYWNrVVJJICE9PSB1bmRlZmluZWQpIHsKICAgICAgICAgIHN0YXRlVXBkYXRlWydDdXJyZW50VHJhY2tVUkknXSA9IGN1cnJlbnRUcmFja1VSSTsKICAgICAgICB9CiAgICAgICAgY29uc3QgY3VycmVudEl0ZW1UeXBlID0gc3RhdGUudHJhY2tfd2luZG93LmN1cnJlbnRfdHJhY2sudHlwZTsKICAgICAgICBpZiAoY3VycmVudEl0ZW1UeXBlICE9PSB1bmRlZmluZWQpIHsKICAgICAgICAgIHN0YXRlVXBkYXRlWydDdXJyZW50SXRlbVR5cGUnXSA9IGN1cnJlbnRJdGVtVHlwZTsKICAgICAgICB9CiAgICAgICAgaWYgKHN0YXRlLmNvbnRleHQgJiYgc3RhdGUuY29udGV4dC51cmkpIHsKICAgICAgICAgIHN0YXRlVXBkYXRlWydDb250ZXh0VVJJJ10gPSBzdGF0ZS5jb250ZXh0LnVyaTsKICAgICAgICB9CiAgICAgICAgY29ubi5zZW5kKEpTT04uc3RyaW5naWZ5KHN0YXRlVXBkYXRlKSk7CiAgICAgICAgY29uc29sZS5sb2coc3RhdGUpOwogICAgICB9KTsKCiAgICAgIC8vIFJlYWR5CiAgICAgIHBsYXllci5hZGRMaXN0ZW5lcigncmVhZHknLCAoeyBkZXZpY2VfaWQgfSkgPT4gewogICAgICAgIGNvbnNvbGUubG9nKCJEZXZpY2UgcmVhZHk6ICIsIGRldmljZV9pZCkKICAgICAgICBjb25uLnNlbmQoSlNPTi5zdHJpbmdpZnkoeyJEZXZpY2VJZCI6IGRldmljZV9pZH0pKQogICAgICB9KTsKCiAgICAgIC8vIENvbm5lY3QgdG8gdGhlIHBsYXllciEKICAgICAgcGxheWVyLmNvbm5lY3QoKTsKICAgIH07ICAgIAogIDwvc2NyaXB0Pgo8L2JvZHk+CjwvaHRtbD4=\"")
 }
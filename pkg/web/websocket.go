@@ -33,6 +33,13 @@ type WebPlaybackState struct {
 	CurrentTrackName  string
 	CurrentAlbumName  string
 	CurrentArtistName string
+	CurrentTrackURI   string
+	// ContextURI is an URI of album or playlist from which track
+	// is played, it is empty when track is played on its own.
+	ContextURI string
+	// CurrentItemType is "track" or "episode", for episodes album
+	// is the show.
+	CurrentItemType string
 }
 
 func (s *WebsocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {